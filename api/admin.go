@@ -0,0 +1,283 @@
+package api
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	external_api "github.com/stuneak/sopeko/cron/external_api"
+	db "github.com/stuneak/sopeko/db/sqlc"
+)
+
+const (
+	defaultScrapeIntervalMinutes = 180
+	minScrapeIntervalMinutes     = 15
+	defaultLookbackHours         = 24
+	maxLookbackHours             = 7 * 24
+)
+
+var subredditNameRegex = regexp.MustCompile(`^[a-z0-9_]{2,21}$`)
+
+// Kinds of ticker aliases; they are matched the same way.
+var aliasKinds = map[string]bool{"nickname": true, "former_name": true}
+
+// adminAuthMiddleware only lets requests through that carry the configured
+// token as "Authorization: Bearer <token>". Without a token the admin API is off.
+func (server *Server) adminAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if server.adminToken == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin API is disabled"})
+			return
+		}
+
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(server.adminToken)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid admin token"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+type createSubredditRequest struct {
+	Name                  string `json:"name" binding:"required"`
+	Enabled               *bool  `json:"enabled"`
+	ScrapeIntervalMinutes *int32 `json:"scrape_interval_minutes"`
+	LookbackHours         *int32 `json:"lookback_hours"`
+}
+
+type updateSubredditRequest struct {
+	Enabled               *bool  `json:"enabled"`
+	ScrapeIntervalMinutes *int32 `json:"scrape_interval_minutes"`
+	LookbackHours         *int32 `json:"lookback_hours"`
+}
+
+func (server *Server) listSubreddits(ctx *gin.Context) {
+	subs, err := server.store.ListSubreddits(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if subs == nil {
+		subs = []db.Subreddit{}
+	}
+
+	ctx.JSON(http.StatusOK, subs)
+}
+
+func (server *Server) createSubreddit(ctx *gin.Context) {
+	var req createSubredditRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := parseSubreddit(req.Name)
+	if !subredditNameRegex.MatchString(name) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid subreddit name %q", req.Name)})
+		return
+	}
+
+	params := db.CreateSubredditParams{
+		Name:                  name,
+		Enabled:               true,
+		ScrapeIntervalMinutes: defaultScrapeIntervalMinutes,
+		LookbackHours:         defaultLookbackHours,
+	}
+	if req.Enabled != nil {
+		params.Enabled = *req.Enabled
+	}
+	if req.ScrapeIntervalMinutes != nil {
+		params.ScrapeIntervalMinutes = *req.ScrapeIntervalMinutes
+	}
+	if req.LookbackHours != nil {
+		params.LookbackHours = *req.LookbackHours
+	}
+	if err := validateScrapeSettings(params.ScrapeIntervalMinutes, params.LookbackHours); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err := server.store.GetSubredditByName(ctx, name)
+	if err == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("subreddit %s already exists", name)})
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub, err := server.store.CreateSubreddit(ctx, params)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, sub)
+}
+
+func (server *Server) updateSubreddit(ctx *gin.Context) {
+	var req updateSubredditRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := parseSubreddit(ctx.Param("name"))
+	sub, err := server.store.GetSubredditByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("subreddit %s not found", name)})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	params := db.UpdateSubredditParams{
+		Name:                  sub.Name,
+		Enabled:               sub.Enabled,
+		ScrapeIntervalMinutes: sub.ScrapeIntervalMinutes,
+		LookbackHours:         sub.LookbackHours,
+	}
+	if req.Enabled != nil {
+		params.Enabled = *req.Enabled
+	}
+	if req.ScrapeIntervalMinutes != nil {
+		params.ScrapeIntervalMinutes = *req.ScrapeIntervalMinutes
+	}
+	if req.LookbackHours != nil {
+		params.LookbackHours = *req.LookbackHours
+	}
+	if err := validateScrapeSettings(params.ScrapeIntervalMinutes, params.LookbackHours); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub, err = server.store.UpdateSubreddit(ctx, params)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, sub)
+}
+
+type createAliasRequest struct {
+	Alias  string `json:"alias" binding:"required"`
+	Symbol string `json:"symbol" binding:"required"`
+	Kind   string `json:"kind"`
+}
+
+func (server *Server) listAliases(ctx *gin.Context) {
+	aliases, err := server.store.ListTickerAliases(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if aliases == nil {
+		aliases = []db.TickerAlias{}
+	}
+
+	ctx.JSON(http.StatusOK, aliases)
+}
+
+// createAlias adds a name a ticker is recognized by in comments. The scrape
+// jobs pick it up within the name matcher's refresh interval.
+func (server *Server) createAlias(ctx *gin.Context) {
+	var req createAliasRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alias := external_api.NormalizeAlias(req.Alias)
+	if alias == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid alias %q", req.Alias)})
+		return
+	}
+	kind := req.Kind
+	if kind == "" {
+		kind = "nickname"
+	}
+	if !aliasKinds[kind] {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "kind must be nickname or former_name"})
+		return
+	}
+
+	symbol := strings.ToUpper(strings.TrimSpace(req.Symbol))
+	if _, err := server.store.GetTickerBySymbol(ctx, symbol); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown symbol %s", symbol)})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err := server.store.GetTickerAlias(ctx, alias)
+	if err == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("alias %q already exists", alias)})
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := server.store.CreateTickerAlias(ctx, db.CreateTickerAliasParams{
+		Alias:  alias,
+		Symbol: symbol,
+		Kind:   kind,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+func (server *Server) deleteAlias(ctx *gin.Context) {
+	alias := external_api.NormalizeAlias(ctx.Param("alias"))
+	deleted, err := server.store.DeleteTickerAlias(ctx, alias)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if deleted == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("alias %q not found", alias)})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// getJobStatus reports the scheduled jobs, the price refresh tiers, price
+// provider health and the latest job run summaries.
+func (server *Server) getJobStatus(ctx *gin.Context) {
+	status, err := server.scheduler.Status(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, status)
+}
+
+func validateScrapeSettings(intervalMinutes, lookbackHours int32) error {
+	if intervalMinutes < minScrapeIntervalMinutes {
+		return fmt.Errorf("scrape_interval_minutes must be at least %d", minScrapeIntervalMinutes)
+	}
+	if lookbackHours < 1 || lookbackHours > maxLookbackHours {
+		return fmt.Errorf("lookback_hours must be between 1 and %d", maxLookbackHours)
+	}
+	return nil
+}
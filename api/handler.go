@@ -1,53 +1,49 @@
 package api
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	external_api "github.com/stuneak/sopeko/cron/external_api"
 	db "github.com/stuneak/sopeko/db/sqlc"
+	"github.com/stuneak/sopeko/pkg/source"
 )
 
 // Excluded usernames (mods, bots, special accounts)
-var excludedUsernames = []string{
-	"OhWowMuchFunYouGuys",
-	"miamihausjunkie",
-	"AnnArchist",
-	"Immersions-",
-	"butthoofer",
-	"AutoModerator",
-	"PennyBotWeekly",
-	"PennyPumper",
-	"TransSpeciesDog",
-	"the_male_nurse",
-	"VisualMod",
-	"OPINION_IS_UNPOPULAR",
-	"zjz",
-	"OSRSkarma",
-	"Dan_inKuwait",
-	"Swiifttx",
-	"teddy_riesling",
-	"Stylux",
-	"Latter-day_weeb",
-	"ShopBitter",
-	"CHAINSAW_VASECTOMY",
-}
+var excludedUsernames = source.ExcludedUsernames
 
 type MentionResponse struct {
-	Symbol           string    `json:"symbol"`
-	MentionPrice     string    `json:"mention_price"`
-	CurrentPrice     string    `json:"current_price"`
-	CurrentPriceDate time.Time `json:"current_price_date"`
-	PercentChange    string    `json:"percent_change"`
-	SplitRatio       float64   `json:"split_ratio"`
-	MentionedAt      time.Time `json:"mentioned_at"`
+	Symbol                 string    `json:"symbol"`
+	MentionPrice           string    `json:"mention_price"`
+	CurrentPrice           string    `json:"current_price"`
+	CurrentPriceDate       time.Time `json:"current_price_date"`
+	PercentChange          *string   `json:"percent_change"`
+	Benchmark              string    `json:"benchmark"`
+	BenchmarkPercentChange *string   `json:"benchmark_percent_change"`
+	ExcessReturn           *string   `json:"excess_return"`
+	SplitRatio             float64   `json:"split_ratio"`
+	MentionedAt            time.Time `json:"mentioned_at"`
+	SourceURL              string    `json:"source_url"`
+	Stance                 string    `json:"stance"`
+	EarlyCalls             int64     `json:"early_calls"`
 }
 
+const redditURL = "https://www.reddit.com"
+
 func (server *Server) getUserMentions(ctx *gin.Context) {
-	username := ctx.Param("username")
+	src, err := parseSource(ctx.Query("source"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	username := source.Username(src, ctx.Param("username"))
 
 	for _, u := range excludedUsernames {
 		if u == username {
@@ -58,38 +54,271 @@ func (server *Server) getUserMentions(ctx *gin.Context) {
 
 	cutoffTime := parsePeriodCutoff(ctx.Query("period"))
 
+	horizon, err := parseHorizon(ctx.Query("horizon"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	benchmark, err := server.parseBenchmark(ctx.Query("benchmark"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	mentions, err := server.store.GetUserMentionsComplete(ctx, db.GetUserMentionsCompleteParams{
 		Username:    username,
 		MentionedAt: cutoffTime,
+		Subreddit:   parseSubreddit(ctx.Query("subreddit")),
+		HorizonDays: horizon,
+		Benchmark:   benchmark,
 	})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	earlyCalls, err := server.loadEarlyCallCounts(ctx, []string{username})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	results := make([]MentionResponse, 0, len(mentions))
 	for _, m := range mentions {
-		mentionPrice := fmt.Sprintf("%v", m.MentionPrice)
-		currentPrice := fmt.Sprintf("%v", m.CurrentPrice)
-
-		adjustedMentionPrice := adjustPriceForSplits(mentionPrice, m.SplitRatio)
-
-		results = append(results, MentionResponse{
-			Symbol:           m.Symbol,
-			MentionPrice:     adjustedMentionPrice,
-			CurrentPrice:     currentPrice,
-			CurrentPriceDate: m.CurrentPriceDate,
-			PercentChange:    calculatePercentChange(adjustedMentionPrice, currentPrice),
-			SplitRatio:       m.SplitRatio,
-			MentionedAt:      m.MentionedAt,
+		r := computePickReturn(m.MentionPrice, m.CurrentPrice, m.SplitRatio, m.BenchmarkStartPrice, m.BenchmarkEndPrice, m.Stance)
+		scored := isScorable(m.MentionPrice, m.HasCurrentPrice, horizon)
+		response := newMentionResponse(m.Symbol, r, scored, benchmark, m.CurrentPriceDate, m.SplitRatio, m.MentionedAt, m.Permalink)
+		response.EarlyCalls = earlyCalls[username]
+		results = append(results, response)
+	}
+
+	ctx.JSON(http.StatusOK, results)
+}
+
+const maxBatchUsernames = 300
+
+type batchMentionsRequest struct {
+	Usernames []string `json:"usernames" binding:"required"`
+}
+
+type MentionSummary struct {
+	PickCount            int     `json:"pick_count"`
+	AveragePercentChange float64 `json:"average_percent_change"`
+	EarlyCalls           int64   `json:"early_calls"`
+}
+
+type UserMentionsResponse struct {
+	Mentions []MentionResponse `json:"mentions"`
+	Summary  MentionSummary    `json:"summary"`
+}
+
+// getBatchMentions returns mentions for many usernames at once so the extension
+// can annotate a whole Reddit thread with a single request.
+func (server *Server) getBatchMentions(ctx *gin.Context) {
+	var req batchMentionsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(req.Usernames) > maxBatchUsernames {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d usernames per request", maxBatchUsernames)})
+		return
+	}
+
+	src, err := parseSource(ctx.Query("source"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	horizon, err := parseHorizon(ctx.Query("horizon"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	benchmark, err := server.parseBenchmark(ctx.Query("benchmark"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	excludedMap := make(map[string]bool)
+	for _, u := range excludedUsernames {
+		excludedMap[u] = true
+	}
+
+	// Results are keyed by the requested names, queries use the stored ones
+	results := make(map[string]*UserMentionsResponse, len(req.Usernames))
+	stored := make(map[string]*UserMentionsResponse, len(req.Usernames))
+	usernames := make([]string, 0, len(req.Usernames))
+	for _, u := range req.Usernames {
+		if _, exists := results[u]; exists || u == "" {
+			continue
+		}
+		results[u] = &UserMentionsResponse{Mentions: []MentionResponse{}}
+		username := source.Username(src, u)
+		stored[username] = results[u]
+		if !excludedMap[username] {
+			usernames = append(usernames, username)
+		}
+	}
+
+	if len(usernames) > 0 {
+		mentions, err := server.store.GetUsersMentionsComplete(ctx, db.GetUsersMentionsCompleteParams{
+			Usernames:   usernames,
+			MentionedAt: parsePeriodCutoff(ctx.Query("period")),
+			Subreddit:   parseSubreddit(ctx.Query("subreddit")),
+			HorizonDays: horizon,
+			Benchmark:   benchmark,
 		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		earlyCalls, err := server.loadEarlyCallCounts(ctx, usernames)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for username, count := range earlyCalls {
+			if user, exists := stored[username]; exists {
+				user.Summary.EarlyCalls = count
+			}
+		}
+
+		for _, m := range mentions {
+			user, exists := stored[m.Username]
+			if !exists {
+				continue
+			}
+			r := computePickReturn(m.MentionPrice, m.CurrentPrice, m.SplitRatio, m.BenchmarkStartPrice, m.BenchmarkEndPrice, m.Stance)
+			scored := isScorable(m.MentionPrice, m.HasCurrentPrice, horizon)
+			response := newMentionResponse(m.Symbol, r, scored, benchmark, m.CurrentPriceDate, m.SplitRatio, m.MentionedAt, m.Permalink)
+			response.EarlyCalls = user.Summary.EarlyCalls
+			user.Mentions = append(user.Mentions, response)
+			if scored {
+				user.Summary.PickCount++
+				user.Summary.AveragePercentChange += r.PercentChange
+			}
+		}
+	}
+
+	for _, user := range results {
+		if user.Summary.PickCount > 0 {
+			user.Summary.AveragePercentChange /= float64(user.Summary.PickCount)
+		}
 	}
 
 	ctx.JSON(http.StatusOK, results)
 }
 
+// newMentionResponse builds the response for a mention. Mentions that are not
+// scored yet (no entry price, or a horizon that has not elapsed) are listed
+// with null returns.
+func newMentionResponse(symbol string, r pickReturn, scored bool, benchmark string, currentPriceDate time.Time, splitRatio float64, mentionedAt time.Time, permalink string) MentionResponse {
+	response := MentionResponse{
+		Symbol:           symbol,
+		MentionPrice:     r.MentionPrice,
+		CurrentPrice:     r.CurrentPrice,
+		CurrentPriceDate: currentPriceDate,
+		Benchmark:        benchmark,
+		SplitRatio:       splitRatio,
+		MentionedAt:      mentionedAt,
+		SourceURL:        sourceURL(permalink),
+		Stance:           r.Stance,
+	}
+	if scored {
+		percentChange := formatPercentChange(r.PercentChange)
+		benchmarkPercentChange := formatPercentChange(r.BenchmarkPercentChange)
+		excessReturn := formatPercentChange(r.ExcessReturn)
+		response.PercentChange = &percentChange
+		response.BenchmarkPercentChange = &benchmarkPercentChange
+		response.ExcessReturn = &excessReturn
+	}
+	return response
+}
+
+// sourceURL links back to the comment a mention was found in. Comments store
+// the full URL; older Reddit comments only the permalink path, and comments
+// scraped before permalinks were stored have none.
+func sourceURL(permalink string) string {
+	if !strings.HasPrefix(permalink, "/") {
+		return permalink
+	}
+	return redditURL + permalink
+}
+
+// pickReturn is the split-adjusted performance of a single pick, alongside the
+// benchmark's performance over the same window. Bearish picks are scored as
+// short positions: the pick's return has its sign flipped, so calling a drop
+// earns what the drop was, and the excess return is taken against a short of
+// the benchmark. The benchmark's own return is reported as it moved.
+type pickReturn struct {
+	MentionPrice           string
+	CurrentPrice           string
+	PercentChange          float64
+	BenchmarkPercentChange float64
+	ExcessReturn           float64
+	Stance                 string
+}
+
+func computePickReturn(rawMentionPrice, rawCurrentPrice interface{}, splitRatio float64, rawBenchmarkStart, rawBenchmarkEnd interface{}, stance string) pickReturn {
+	mentionPrice := fmt.Sprintf("%v", rawMentionPrice)
+	currentPrice := fmt.Sprintf("%v", rawCurrentPrice)
+
+	// The return is taken from the unrounded entry, so sub-dollar picks are
+	// not scored off a price rounded to the cent
+	exactMentionPrice := mentionPrice
+	if p, err := strconv.ParseFloat(mentionPrice, 64); err == nil {
+		exactMentionPrice = strconv.FormatFloat(p*splitRatio, 'f', -1, 64)
+	}
+
+	r := pickReturn{
+		MentionPrice:  adjustPriceForSplits(mentionPrice, splitRatio),
+		CurrentPrice:  currentPrice,
+		PercentChange: calculatePercentChangeFloat(exactMentionPrice, currentPrice),
+	}
+
+	// Without both benchmark prices the excess return is just the raw return
+	benchmarkStart := fmt.Sprintf("%v", rawBenchmarkStart)
+	benchmarkEnd := fmt.Sprintf("%v", rawBenchmarkEnd)
+	if isPositivePrice(benchmarkStart) && isPositivePrice(benchmarkEnd) {
+		r.BenchmarkPercentChange = calculatePercentChangeFloat(benchmarkStart, benchmarkEnd)
+	}
+	r.ExcessReturn = r.PercentChange - r.BenchmarkPercentChange
+
+	r.Stance = stance
+	if stance == external_api.StanceBearish {
+		r.PercentChange = -r.PercentChange
+		r.ExcessReturn = r.PercentChange - (-r.BenchmarkPercentChange)
+	}
+
+	return r
+}
+
+// isScorable reports whether a pick can be scored: its entry has a price and,
+// with a horizon, a price exists within a week after the horizon.
+func isScorable(mentionPrice interface{}, hasCurrentPrice bool, horizon int32) bool {
+	if horizon > 0 && !hasCurrentPrice {
+		return false
+	}
+	return isPositivePrice(fmt.Sprintf("%v", mentionPrice))
+}
+
+func isPositivePrice(price string) bool {
+	p, err := strconv.ParseFloat(price, 64)
+	return err == nil && p > 0
+}
+
 func calculatePercentChange(oldPrice, newPrice string) string {
-	change := calculatePercentChangeFloat(oldPrice, newPrice)
+	return formatPercentChange(calculatePercentChangeFloat(oldPrice, newPrice))
+}
+
+func formatPercentChange(change float64) string {
 	if change >= 0 {
 		return fmt.Sprintf("+%.2f%%", change)
 	}
@@ -122,6 +351,16 @@ func adjustPriceForSplits(price string, splitRatio float64) string {
 	return fmt.Sprintf("%.2f", adjustedMentionPrice)
 }
 
+// parsePeriod normalizes the period parameter to the key leaderboard snapshots are stored under.
+func parsePeriod(period string) string {
+	switch period {
+	case "daily", "weekly", "monthly":
+		return period
+	default:
+		return "all"
+	}
+}
+
 func parsePeriodCutoff(period string) time.Time {
 	now := time.Now()
 	switch period {
@@ -136,32 +375,129 @@ func parsePeriodCutoff(period string) time.Time {
 	}
 }
 
+// parseSource validates the source= parameter, which selects whose usernames
+// are looked up. It defaults to Reddit.
+func parseSource(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return source.Reddit, nil
+	}
+	if !source.Valid(name) {
+		return "", fmt.Errorf("invalid source %q", name)
+	}
+	return name, nil
+}
+
+// parseSubreddit normalizes a subreddit filter to the lowercase name stored on
+// mentions, accepting an optional "r/" prefix. Empty means every subreddit.
+func parseSubreddit(subreddit string) string {
+	subreddit = strings.ToLower(strings.TrimSpace(subreddit))
+	return strings.TrimPrefix(subreddit, "r/")
+}
+
+// horizonDays maps the supported holding periods to the number of days after
+// the mention at which a pick is scored.
+var horizonDays = map[string]int32{
+	"1d":   1,
+	"7d":   7,
+	"30d":  30,
+	"90d":  90,
+	"365d": 365,
+}
+
+// parseHorizon returns the holding period in days, or 0 to score against the
+// latest known price.
+func parseHorizon(horizon string) (int32, error) {
+	if horizon == "" {
+		return 0, nil
+	}
+	days, ok := horizonDays[horizon]
+	if !ok {
+		return 0, fmt.Errorf("invalid horizon %q, expected one of 1d, 7d, 30d, 90d, 365d", horizon)
+	}
+	return days, nil
+}
+
+// parseBenchmark returns the requested benchmark symbol, defaulting to the
+// first configured one.
+func (server *Server) parseBenchmark(benchmark string) (string, error) {
+	if benchmark == "" {
+		if len(server.benchmarks) == 0 {
+			return "", nil
+		}
+		return server.benchmarks[0], nil
+	}
+	for _, b := range server.benchmarks {
+		if b == benchmark {
+			return b, nil
+		}
+	}
+	return "", fmt.Errorf("invalid benchmark %q, expected one of %s", benchmark, strings.Join(server.benchmarks, ", "))
+}
+
+const (
+	sortByPercentChange = "percent_change"
+	sortByExcessReturn  = "excess_return"
+)
+
+func parseSortKey(sortKey string) (string, error) {
+	switch sortKey {
+	case "", sortByPercentChange:
+		return sortByPercentChange, nil
+	case sortByExcessReturn:
+		return sortByExcessReturn, nil
+	default:
+		return "", fmt.Errorf("invalid sort %q, expected percent_change or excess_return", sortKey)
+	}
+}
+
 func (server *Server) getExcludedUsernames(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, excludedUsernames)
 }
 
 type PickDetail struct {
-	Symbol       string  `json:"symbol"`
-	PickPrice    string  `json:"pick_price"`
-	CurrentPrice string  `json:"current_price"`
-	PercentGain  float64 `json:"percent_gain"`
-	SplitRatio   float64 `json:"split_ratio"`
+	Symbol                 string  `json:"symbol"`
+	PickPrice              string  `json:"pick_price"`
+	CurrentPrice           string  `json:"current_price"`
+	PercentGain            float64 `json:"percent_gain"`
+	BenchmarkPercentChange float64 `json:"benchmark_percent_change"`
+	ExcessReturn           float64 `json:"excess_return"`
+	SplitRatio             float64 `json:"split_ratio"`
+	Stance                 string  `json:"stance"`
 }
 
 type TopUserResponse struct {
-	Username         string       `json:"username"`
-	TotalPercentGain float64      `json:"total_percent_gain"`
-	Picks            []PickDetail `json:"picks"`
+	Username               string       `json:"username"`
+	TotalPercentGain       float64      `json:"total_percent_gain"`
+	BenchmarkPercentChange float64      `json:"benchmark_percent_change"`
+	ExcessReturn           float64      `json:"excess_return"`
+	Score                  float64      `json:"score"`
+	Stats                  UserStats    `json:"stats"`
+	EarlyCalls             int64        `json:"early_calls"`
+	Picks                  []PickDetail `json:"picks"`
+}
+
+type PicksLeaderboardResponse struct {
+	GeneratedAt time.Time                 `json:"generated_at"`
+	Results     []PickPerformanceResponse `json:"results"`
+}
+
+type UsersLeaderboardResponse struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	Results     []TopUserResponse `json:"results"`
 }
 
 type PickPerformanceResponse struct {
-	Symbol           string    `json:"symbol"`
-	MentionPrice     string    `json:"mention_price"`
-	CurrentPrice     string    `json:"current_price"`
-	CurrentPriceDate time.Time `json:"current_price_date"`
-	PercentChange    float64   `json:"percent_change"`
-	SplitRatio       float64   `json:"split_ratio"`
-	MentionedAt      time.Time `json:"mentioned_at"`
+	Symbol                 string    `json:"symbol"`
+	MentionPrice           string    `json:"mention_price"`
+	CurrentPrice           string    `json:"current_price"`
+	CurrentPriceDate       time.Time `json:"current_price_date"`
+	PercentChange          float64   `json:"percent_change"`
+	BenchmarkPercentChange float64   `json:"benchmark_percent_change"`
+	ExcessReturn           float64   `json:"excess_return"`
+	SplitRatio             float64   `json:"split_ratio"`
+	MentionedAt            time.Time `json:"mentioned_at"`
+	Stance                 string    `json:"stance"`
 }
 
 func (server *Server) getTopPerformingPicks(ctx *gin.Context) {
@@ -173,82 +509,356 @@ func (server *Server) getWorstPerformingPicks(ctx *gin.Context) {
 }
 
 func (server *Server) getPerformingPicks(ctx *gin.Context, topPerformers bool) {
-	cutoffTime := parsePeriodCutoff(ctx.Query("period"))
+	period := parsePeriod(ctx.Query("period"))
 
-	excludedMap := make(map[string]bool)
-	for _, u := range excludedUsernames {
-		excludedMap[u] = true
+	horizon, err := parseHorizon(ctx.Query("horizon"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	benchmark, err := server.parseBenchmark(ctx.Query("benchmark"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sortKey, err := parseSortKey(ctx.Query("sort"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results, generatedAt, err := server.loadPicksLeaderboard(ctx, period, horizon, benchmark, parseSubreddit(ctx.Query("subreddit")), sortKey, topPerformers)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, PicksLeaderboardResponse{
+		GeneratedAt: generatedAt,
+		Results:     results,
+	})
+}
+
+func (server *Server) getTopPerformingUsers(ctx *gin.Context) {
+	period := parsePeriod(ctx.Query("period"))
+
+	horizon, err := parseHorizon(ctx.Query("horizon"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	benchmark, err := server.parseBenchmark(ctx.Query("benchmark"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sortKey, err := parseSortKey(ctx.Query("sort"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	mentions, err := server.store.GetAllMentionsComplete(ctx, cutoffTime)
+	scoreMode, err := parseScoreMode(ctx.Query("score"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	minPicks := 1
+	if v := ctx.Query("min_picks"); v != "" {
+		minPicks, err = strconv.Atoi(v)
+		if err != nil || minPicks < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid min_picks %q", v)})
+			return
+		}
+	}
+
+	results, generatedAt, err := server.loadUsersLeaderboard(ctx, period, horizon, benchmark, parseSubreddit(ctx.Query("subreddit")), sortKey, scoreMode, minPicks)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	topUsernames := make([]string, 0, len(results))
+	for _, r := range results {
+		topUsernames = append(topUsernames, r.Username)
+	}
+	earlyCalls, err := server.loadEarlyCallCounts(ctx, topUsernames)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range results {
+		results[i].EarlyCalls = earlyCalls[results[i].Username]
+	}
+
+	ctx.JSON(http.StatusOK, UsersLeaderboardResponse{
+		GeneratedAt: generatedAt,
+		Results:     results,
+	})
+}
+
+// leaderboardSize is how many picks or users a leaderboard lists.
+const leaderboardSize = 10
+
+// leaderboardGeneration returns the snapshot the leaderboards of a key are
+// read from. ok is false for keys the leaderboard-snapshots job does not
+// materialize, and for all keys until its first run; those are computed live.
+func (server *Server) leaderboardGeneration(ctx *gin.Context, period string, horizon int32, benchmark string) (time.Time, bool, error) {
+	generatedAt, err := server.store.GetLeaderboardGeneration(ctx, db.GetLeaderboardGenerationParams{
+		Period:      period,
+		HorizonDays: horizon,
+		Benchmark:   benchmark,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return generatedAt, true, nil
+}
+
+// loadPicksLeaderboard returns the best or worst picks of a key. Snapshots
+// are ranked and limited in SQL; keys without one are scored from every
+// mention of the period.
+func (server *Server) loadPicksLeaderboard(ctx *gin.Context, period string, horizon int32, benchmark, subreddit, sortKey string, topPerformers bool) ([]PickPerformanceResponse, time.Time, error) {
+	generatedAt, ok, err := server.leaderboardGeneration(ctx, period, horizon, benchmark)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if !ok {
+		mentions, err := server.store.GetAllMentionsComplete(ctx, db.GetAllMentionsCompleteParams{
+			HorizonDays: horizon,
+			Benchmark:   benchmark,
+			MentionedAt: parsePeriodCutoff(period),
+			Subreddit:   subreddit,
+		})
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		return rankPicks(mentions, horizon, sortKey, topPerformers), time.Now(), nil
+	}
+
+	picks, err := server.store.GetLeaderboardPicks(ctx, db.GetLeaderboardPicksParams{
+		Period:            period,
+		HorizonDays:       horizon,
+		Benchmark:         benchmark,
+		GeneratedAt:       generatedAt,
+		ExcludedUsernames: excludedUsernames,
+		Subreddit:         subreddit,
+		ByExcessReturn:    sortKey == sortByExcessReturn,
+		Ascending:         !topPerformers,
+		RowLimit:          leaderboardSize,
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	results := make([]PickPerformanceResponse, 0, len(picks))
+	for _, p := range picks {
+		results = append(results, PickPerformanceResponse{
+			Symbol:                 p.Symbol,
+			MentionPrice:           adjustPriceForSplits(p.MentionPrice, p.SplitRatio),
+			CurrentPrice:           p.CurrentPrice,
+			CurrentPriceDate:       p.CurrentPriceDate,
+			PercentChange:          p.PercentChange,
+			BenchmarkPercentChange: p.BenchmarkPercentChange,
+			ExcessReturn:           p.ExcessReturn,
+			SplitRatio:             p.SplitRatio,
+			MentionedAt:            p.MentionedAt,
+			Stance:                 p.Stance,
+		})
+	}
+	return results, generatedAt, nil
+}
+
+// rankPicks scores the mentions of a key computed live and returns the best
+// or worst of them.
+func rankPicks(mentions []db.GetAllMentionsCompleteRow, horizon int32, sortKey string, topPerformers bool) []PickPerformanceResponse {
+	excludedMap := make(map[string]bool)
+	for _, u := range excludedUsernames {
+		excludedMap[u] = true
+	}
+
 	results := make([]PickPerformanceResponse, 0)
 	for _, m := range mentions {
 		if excludedMap[m.Username] {
 			continue
 		}
 
-		mentionPrice := fmt.Sprintf("%v", m.MentionPrice)
-		currentPrice := fmt.Sprintf("%v", m.CurrentPrice)
-		adjustedMentionPrice := adjustPriceForSplits(mentionPrice, m.SplitRatio)
-		pctChange := calculatePercentChangeFloat(adjustedMentionPrice, currentPrice)
+		// A pick is only scored once it has an entry price and its horizon has elapsed with a price
+		if !isScorable(m.MentionPrice, m.HasCurrentPrice, horizon) {
+			continue
+		}
+
+		r := computePickReturn(m.MentionPrice, m.CurrentPrice, m.SplitRatio, m.BenchmarkStartPrice, m.BenchmarkEndPrice, m.Stance)
 
 		results = append(results, PickPerformanceResponse{
-			Symbol:           m.Symbol,
-			MentionPrice:     adjustedMentionPrice,
-			CurrentPrice:     currentPrice,
-			CurrentPriceDate: m.CurrentPriceDate,
-			PercentChange:    pctChange,
-			SplitRatio:       m.SplitRatio,
-			MentionedAt:      m.MentionedAt,
+			Symbol:                 m.Symbol,
+			MentionPrice:           r.MentionPrice,
+			CurrentPrice:           r.CurrentPrice,
+			CurrentPriceDate:       m.CurrentPriceDate,
+			PercentChange:          r.PercentChange,
+			BenchmarkPercentChange: r.BenchmarkPercentChange,
+			ExcessReturn:           r.ExcessReturn,
+			SplitRatio:             m.SplitRatio,
+			MentionedAt:            m.MentionedAt,
+			Stance:                 r.Stance,
 		})
 	}
 
+	score := func(p PickPerformanceResponse) float64 {
+		if sortKey == sortByExcessReturn {
+			return p.ExcessReturn
+		}
+		return p.PercentChange
+	}
+
 	if topPerformers {
 		sort.Slice(results, func(i, j int) bool {
-			return results[i].PercentChange > results[j].PercentChange
+			return score(results[i]) > score(results[j])
 		})
 	} else {
 		sort.Slice(results, func(i, j int) bool {
-			return results[i].PercentChange < results[j].PercentChange
+			return score(results[i]) < score(results[j])
 		})
 	}
 
-	if len(results) > 10 {
-		results = results[:10]
+	if len(results) > leaderboardSize {
+		results = results[:leaderboardSize]
 	}
-
-	ctx.JSON(http.StatusOK, results)
+	return results
 }
 
-func (server *Server) getTopPerformingUsers(ctx *gin.Context) {
-	cutoffTime := parsePeriodCutoff(ctx.Query("period"))
+// loadUsersLeaderboard returns the best ranked users of a key with their
+// picks, best first. Snapshots are aggregated, ranked and limited in SQL;
+// keys without one are scored from every mention of the period.
+func (server *Server) loadUsersLeaderboard(ctx *gin.Context, period string, horizon int32, benchmark, subreddit, sortKey, scoreMode string, minPicks int) ([]TopUserResponse, time.Time, error) {
+	generatedAt, ok, err := server.leaderboardGeneration(ctx, period, horizon, benchmark)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if !ok {
+		mentions, err := server.store.GetAllMentionsComplete(ctx, db.GetAllMentionsCompleteParams{
+			HorizonDays: horizon,
+			Benchmark:   benchmark,
+			MentionedAt: parsePeriodCutoff(period),
+			Subreddit:   subreddit,
+		})
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		return rankUsers(mentions, horizon, sortKey, scoreMode, minPicks), time.Now(), nil
+	}
 
-	excludedMap := make(map[string]bool)
-	for _, u := range excludedUsernames {
-		excludedMap[u] = true
+	users, err := server.store.GetLeaderboardUsers(ctx, db.GetLeaderboardUsersParams{
+		ByExcessReturn:      sortKey == sortByExcessReturn,
+		Period:              period,
+		HorizonDays:         horizon,
+		Benchmark:           benchmark,
+		GeneratedAt:         generatedAt,
+		ExcludedUsernames:   excludedUsernames,
+		Subreddit:           subreddit,
+		WilsonZ:             wilsonZ,
+		ShrinkagePriorPicks: shrinkagePriorPicks,
+		ScoreMode:           scoreMode,
+		MinPicks:            int32(minPicks),
+		RowLimit:            leaderboardSize,
+	})
+	if err != nil {
+		return nil, time.Time{}, err
 	}
 
-	mentions, err := server.store.GetAllMentionsComplete(ctx, cutoffTime)
+	results := make([]TopUserResponse, 0, len(users))
+	usernames := make([]string, 0, len(users))
+	for _, u := range users {
+		results = append(results, TopUserResponse{
+			Username:               u.Username,
+			TotalPercentGain:       u.TotalPercentGain,
+			BenchmarkPercentChange: u.BenchmarkPercentChange,
+			ExcessReturn:           u.ExcessReturn,
+			Score:                  u.Score,
+			Stats: UserStats{
+				PickCount:        int(u.PickCount),
+				Wins:             int(u.Wins),
+				Total:            u.Total,
+				Mean:             u.Mean,
+				Median:           u.Median,
+				WinRate:          u.WinRate,
+				WilsonLowerBound: u.WilsonLowerBound,
+				ShrunkMean:       u.ShrunkMean,
+			},
+			Picks: []PickDetail{},
+		})
+		usernames = append(usernames, u.Username)
+	}
+	if len(results) == 0 {
+		return results, generatedAt, nil
+	}
+
+	picks, err := server.store.GetLeaderboardUserPicks(ctx, db.GetLeaderboardUserPicksParams{
+		Period:      period,
+		HorizonDays: horizon,
+		Benchmark:   benchmark,
+		GeneratedAt: generatedAt,
+		Usernames:   usernames,
+		Subreddit:   subreddit,
+	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return nil, time.Time{}, err
+	}
+
+	byUsername := make(map[string]*TopUserResponse, len(results))
+	for i := range results {
+		byUsername[results[i].Username] = &results[i]
+	}
+	for _, p := range picks {
+		user, exists := byUsername[p.Username]
+		if !exists {
+			continue
+		}
+		user.Picks = append(user.Picks, PickDetail{
+			Symbol:                 p.Symbol,
+			PickPrice:              adjustPriceForSplits(p.MentionPrice, p.SplitRatio),
+			CurrentPrice:           p.CurrentPrice,
+			PercentGain:            p.PercentChange,
+			BenchmarkPercentChange: p.BenchmarkPercentChange,
+			ExcessReturn:           p.ExcessReturn,
+			SplitRatio:             p.SplitRatio,
+			Stance:                 p.Stance,
+		})
+	}
+	return results, generatedAt, nil
+}
+
+// rankUsers scores the mentions of a key computed live per user and returns
+// the best ranked users with their picks, best first.
+func rankUsers(mentions []db.GetAllMentionsCompleteRow, horizon int32, sortKey, scoreMode string, minPicks int) []TopUserResponse {
+	excludedMap := make(map[string]bool)
+	for _, u := range excludedUsernames {
+		excludedMap[u] = true
 	}
 
 	users := make(map[string]*TopUserResponse)
+	userReturns := make(map[string][]float64)
+	var populationTotal float64
+	var populationCount int
 	for _, m := range mentions {
 		if excludedMap[m.Username] {
 			continue
 		}
 
-		mentionPrice := fmt.Sprintf("%v", m.MentionPrice)
-		currentPrice := fmt.Sprintf("%v", m.CurrentPrice)
-		adjustedMentionPrice := adjustPriceForSplits(mentionPrice, m.SplitRatio)
-		pctChange := calculatePercentChangeFloat(adjustedMentionPrice, currentPrice)
+		// A pick is only scored once it has an entry price and its horizon has elapsed with a price
+		if !isScorable(m.MentionPrice, m.HasCurrentPrice, horizon) {
+			continue
+		}
+
+		r := computePickReturn(m.MentionPrice, m.CurrentPrice, m.SplitRatio, m.BenchmarkStartPrice, m.BenchmarkEndPrice, m.Stance)
 
 		user, exists := users[m.Username]
 		if !exists {
@@ -259,35 +869,49 @@ func (server *Server) getTopPerformingUsers(ctx *gin.Context) {
 			users[m.Username] = user
 		}
 
-		user.TotalPercentGain += pctChange
+		user.TotalPercentGain += r.PercentChange
+		user.BenchmarkPercentChange += r.BenchmarkPercentChange
+		user.ExcessReturn += r.ExcessReturn
+
+		pickReturn := r.PercentChange
+		if sortKey == sortByExcessReturn {
+			pickReturn = r.ExcessReturn
+		}
+		userReturns[m.Username] = append(userReturns[m.Username], pickReturn)
+		populationTotal += pickReturn
+		populationCount++
 		user.Picks = append(user.Picks, PickDetail{
-			Symbol:       m.Symbol,
-			PickPrice:    adjustedMentionPrice,
-			CurrentPrice: currentPrice,
-			PercentGain:  pctChange,
-			SplitRatio:   m.SplitRatio,
+			Symbol:                 m.Symbol,
+			PickPrice:              r.MentionPrice,
+			CurrentPrice:           r.CurrentPrice,
+			PercentGain:            r.PercentChange,
+			BenchmarkPercentChange: r.BenchmarkPercentChange,
+			ExcessReturn:           r.ExcessReturn,
+			SplitRatio:             m.SplitRatio,
+			Stance:                 r.Stance,
 		})
 	}
 
-	results := make([]TopUserResponse, 0, len(users))
-	for _, u := range users {
-		results = append(results, *u)
+	var populationMean float64
+	if populationCount > 0 {
+		populationMean = populationTotal / float64(populationCount)
 	}
 
-	filtered := make([]TopUserResponse, 0, len(results))
-	for _, r := range results {
-		if r.TotalPercentGain >= 0 {
-			filtered = append(filtered, r)
+	results := make([]TopUserResponse, 0, len(users))
+	for username, u := range users {
+		u.Stats = computeUserStats(userReturns[username], populationMean)
+		u.Score = u.Stats.value(scoreMode)
+		if u.Stats.PickCount >= minPicks && u.Score >= 0 {
+			results = append(results, *u)
 		}
 	}
-	results = filtered
 
 	sort.Slice(results, func(i, j int) bool {
-		return results[i].TotalPercentGain > results[j].TotalPercentGain
+		return results[i].Score > results[j].Score
 	})
 
-	if len(results) > 10 {
-		results = results[:10]
+	if len(results) > leaderboardSize {
+		results = results[:leaderboardSize]
 	}
 
 	// Sort picks within each user from highest to lowest percent gain
@@ -296,6 +920,5 @@ func (server *Server) getTopPerformingUsers(ctx *gin.Context) {
 			return results[i].Picks[a].PercentGain > results[i].Picks[b].PercentGain
 		})
 	}
-
-	ctx.JSON(http.StatusOK, results)
+	return results
 }
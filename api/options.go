@@ -0,0 +1,148 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	external_api "github.com/stuneak/sopeko/cron/external_api"
+	"github.com/stuneak/sopeko/pkg/source"
+)
+
+type OptionPositionResponse struct {
+	Symbol                  string     `json:"symbol"`
+	Type                    string     `json:"type"`
+	Strike                  string     `json:"strike"`
+	ExpiresAt               *time.Time `json:"expires_at"`
+	Written                 bool       `json:"written"`
+	Direction               string     `json:"direction"`
+	Expired                 bool       `json:"expired"`
+	EntryPrice              string     `json:"entry_price"`
+	UnderlyingPrice         string     `json:"underlying_price"`
+	UnderlyingPriceDate     *time.Time `json:"underlying_price_date"`
+	UnderlyingPercentChange string     `json:"underlying_percent_change"`
+	SplitRatio              float64    `json:"split_ratio"`
+	DirectionCorrect        *bool      `json:"direction_correct"`
+	InTheMoney              *bool      `json:"in_the_money"`
+	MentionedAt             time.Time  `json:"mentioned_at"`
+	SourceURL               string     `json:"source_url"`
+}
+
+// OptionsSummary scores a user's option positions by their expired ones:
+// whether the underlying moved the way the position needed by expiry, and
+// whether the contract finished in the money.
+type OptionsSummary struct {
+	Positions           int     `json:"positions"`
+	Expired             int     `json:"expired"`
+	Scored              int     `json:"scored"`
+	DirectionCorrect    int     `json:"direction_correct"`
+	DirectionalAccuracy float64 `json:"directional_accuracy"`
+	InTheMoney          int     `json:"in_the_money"`
+}
+
+type UserOptionsResponse struct {
+	Summary   OptionsSummary           `json:"summary"`
+	Positions []OptionPositionResponse `json:"positions"`
+}
+
+// getUserOptions lists the option contracts a user has written about. Option
+// mentions are left out of stock performance; instead each position is
+// judged by the underlying's move from the mention's entry to expiry (to the
+// latest price while it runs). Premiums are not known, so being in the money
+// is reported rather than a profit.
+func (server *Server) getUserOptions(ctx *gin.Context) {
+	src, err := parseSource(ctx.Query("source"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	username := source.Username(src, ctx.Param("username"))
+
+	response := UserOptionsResponse{Positions: []OptionPositionResponse{}}
+	for _, u := range excludedUsernames {
+		if u == username {
+			ctx.JSON(http.StatusOK, response)
+			return
+		}
+	}
+
+	rows, err := server.store.GetUserOptionPositions(ctx, username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	summary := &response.Summary
+	for _, row := range rows {
+		contract := external_api.OptionContract{Type: row.OptionType, Written: row.Written}
+		p := OptionPositionResponse{
+			Symbol:      row.Symbol,
+			Type:        row.OptionType,
+			Strike:      row.Strike,
+			Written:     row.Written,
+			Direction:   external_api.StanceBearish,
+			Expired:     row.ExpiresAt.Valid && !row.ExpiresAt.Time.After(now),
+			SplitRatio:  row.SplitRatio,
+			MentionedAt: row.MentionedAt,
+			SourceURL:   sourceURL(row.Permalink),
+		}
+		if contract.Bullish() {
+			p.Direction = external_api.StanceBullish
+		}
+		if row.ExpiresAt.Valid {
+			p.ExpiresAt = &row.ExpiresAt.Time
+		}
+
+		summary.Positions++
+		if p.Expired {
+			summary.Expired++
+		}
+
+		// Entry and strike were stated before any splits since, so both are
+		// scaled by the split ratio to compare with today's underlying price.
+		// They are compared unrounded; only the reported entry is rounded
+		if isPositivePrice(row.EntryPrice) && row.HasUnderlyingPrice {
+			entryPrice, _ := strconv.ParseFloat(row.EntryPrice, 64)
+			strikePrice, _ := strconv.ParseFloat(row.Strike, 64)
+			underlying, _ := strconv.ParseFloat(row.UnderlyingPrice, 64)
+			entryPrice *= row.SplitRatio
+			strikePrice *= row.SplitRatio
+			change := (underlying - entryPrice) / entryPrice * 100
+
+			correct := change < 0
+			inTheMoney := underlying < strikePrice
+			if contract.Bullish() {
+				correct = change > 0
+			}
+			if row.OptionType == external_api.OptionCall {
+				inTheMoney = underlying > strikePrice
+			}
+
+			p.EntryPrice = adjustPriceForSplits(row.EntryPrice, row.SplitRatio)
+			p.UnderlyingPrice = row.UnderlyingPrice
+			p.UnderlyingPriceDate = &row.UnderlyingPriceDate.Time
+			p.UnderlyingPercentChange = formatPercentChange(change)
+			p.DirectionCorrect = &correct
+			p.InTheMoney = &inTheMoney
+
+			if p.Expired {
+				summary.Scored++
+				if correct {
+					summary.DirectionCorrect++
+				}
+				if inTheMoney {
+					summary.InTheMoney++
+				}
+			}
+		}
+		response.Positions = append(response.Positions, p)
+	}
+
+	if summary.Scored > 0 {
+		summary.DirectionalAccuracy = float64(summary.DirectionCorrect) / float64(summary.Scored)
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stuneak/sopeko/pkg/source"
+)
+
+type PredictionResponse struct {
+	Symbol      string     `json:"symbol"`
+	Claim       string     `json:"claim"`
+	TargetPrice string     `json:"target_price"` // empty until a multiple's entry is priced
+	Multiple    float64    `json:"multiple,omitempty"`
+	EntryPrice  string     `json:"entry_price"`
+	Deadline    time.Time  `json:"deadline"`
+	Status      string     `json:"status"`
+	Progress    float64    `json:"progress"`
+	ResolvedAt  *time.Time `json:"resolved_at"`
+	Stance      string     `json:"stance"`
+	MentionedAt time.Time  `json:"mentioned_at"`
+	SourceURL   string     `json:"source_url"`
+}
+
+// CalibrationSummary measures how well a user's predictions held up. Only
+// resolved (hit or missed) predictions count.
+type CalibrationSummary struct {
+	Predictions      int     `json:"predictions"`
+	Open             int     `json:"open"`
+	Hits             int     `json:"hits"`
+	Misses           int     `json:"misses"`
+	HitRate          float64 `json:"hit_rate"`
+	WilsonLowerBound float64 `json:"wilson_lower_bound"`
+	CalibrationScore float64 `json:"calibration_score"`
+}
+
+type UserPredictionsResponse struct {
+	Calibration CalibrationSummary   `json:"calibration"`
+	Predictions []PredictionResponse `json:"predictions"`
+}
+
+// getUserPredictions lists the price targets a user has claimed and how they
+// turned out. The calibration score is the mean progress of the resolved
+// ones: the share of each promised move that was delivered by its deadline,
+// so a user whose targets all fall halfway short scores 0.5.
+func (server *Server) getUserPredictions(ctx *gin.Context) {
+	src, err := parseSource(ctx.Query("source"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	username := source.Username(src, ctx.Param("username"))
+
+	response := UserPredictionsResponse{Predictions: []PredictionResponse{}}
+	for _, u := range excludedUsernames {
+		if u == username {
+			ctx.JSON(http.StatusOK, response)
+			return
+		}
+	}
+
+	rows, err := server.store.GetUserPredictions(ctx, username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var progressSum float64
+	summary := &response.Calibration
+	for _, row := range rows {
+		p := PredictionResponse{
+			Symbol:      row.Symbol,
+			Claim:       row.Claim,
+			TargetPrice: row.TargetPrice.String,
+			Multiple:    row.Multiple.Float64,
+			EntryPrice:  row.EntryPrice.String,
+			Deadline:    row.Deadline,
+			Status:      row.Status,
+			Progress:    row.Progress,
+			Stance:      row.Stance,
+			MentionedAt: row.MentionedAt,
+			SourceURL:   sourceURL(row.Permalink),
+		}
+		if row.ResolvedAt.Valid {
+			p.ResolvedAt = &row.ResolvedAt.Time
+		}
+		response.Predictions = append(response.Predictions, p)
+
+		summary.Predictions++
+		switch row.Status {
+		case "hit":
+			summary.Hits++
+			progressSum += 1
+		case "missed":
+			summary.Misses++
+			progressSum += row.Progress
+		default:
+			summary.Open++
+		}
+	}
+
+	if resolved := summary.Hits + summary.Misses; resolved > 0 {
+		summary.HitRate = float64(summary.Hits) / float64(resolved)
+		summary.WilsonLowerBound = wilsonLowerBound(summary.Hits, resolved)
+		summary.CalibrationScore = progressSum / float64(resolved)
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
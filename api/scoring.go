@@ -0,0 +1,117 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	scoreTotal     = "total"
+	scoreMean      = "mean"
+	scoreMedian    = "median"
+	scoreWinRate   = "win_rate"
+	scoreWilson    = "wilson"
+	scoreShrinkage = "shrinkage"
+)
+
+// wilsonZ is the z-score for a 95% confidence interval.
+const wilsonZ = 1.96
+
+// shrinkagePriorPicks is how many "virtual" picks at the population mean are
+// blended into every user's average. Users with few picks are pulled strongly
+// toward the population mean, users with many picks barely move.
+const shrinkagePriorPicks = 10
+
+func parseScoreMode(score string) (string, error) {
+	switch score {
+	case "":
+		return scoreTotal, nil
+	case scoreTotal, scoreMean, scoreMedian, scoreWinRate, scoreWilson, scoreShrinkage:
+		return score, nil
+	default:
+		return "", fmt.Errorf("invalid score %q, expected one of total, mean, median, win_rate, wilson, shrinkage", score)
+	}
+}
+
+// UserStats summarizes the returns of all picks of a single user.
+type UserStats struct {
+	PickCount        int     `json:"pick_count"`
+	Wins             int     `json:"wins"`
+	Total            float64 `json:"total"`
+	Mean             float64 `json:"mean"`
+	Median           float64 `json:"median"`
+	WinRate          float64 `json:"win_rate"`
+	WilsonLowerBound float64 `json:"wilson_lower_bound"`
+	ShrunkMean       float64 `json:"shrunk_mean"`
+}
+
+// computeUserStats computes the per-user statistics for a list of pick
+// returns. populationMean is the mean return of all picks across all users and
+// is used as the prior for the shrinkage estimate.
+func computeUserStats(returns []float64, populationMean float64) UserStats {
+	stats := UserStats{PickCount: len(returns)}
+	if len(returns) == 0 {
+		return stats
+	}
+
+	for _, r := range returns {
+		stats.Total += r
+		if r > 0 {
+			stats.Wins++
+		}
+	}
+
+	n := float64(len(returns))
+	stats.Mean = stats.Total / n
+	stats.Median = median(returns)
+	stats.WinRate = float64(stats.Wins) / n
+	stats.WilsonLowerBound = wilsonLowerBound(stats.Wins, len(returns))
+	stats.ShrunkMean = (stats.Total + shrinkagePriorPicks*populationMean) / (n + shrinkagePriorPicks)
+
+	return stats
+}
+
+// value returns the statistic used for ranking in the given score mode.
+func (s UserStats) value(mode string) float64 {
+	switch mode {
+	case scoreMean:
+		return s.Mean
+	case scoreMedian:
+		return s.Median
+	case scoreWinRate:
+		return s.WinRate
+	case scoreWilson:
+		return s.WilsonLowerBound
+	case scoreShrinkage:
+		return s.ShrunkMean
+	default:
+		return s.Total
+	}
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// wilsonLowerBound returns the lower bound of the Wilson score interval for a
+// win rate of wins/n, so a 3/3 record ranks below a 90/100 one.
+func wilsonLowerBound(wins, n int) float64 {
+	if n == 0 {
+		return 0
+	}
+	p := float64(wins) / float64(n)
+	nf := float64(n)
+	z2 := wilsonZ * wilsonZ
+
+	center := p + z2/(2*nf)
+	margin := wilsonZ * math.Sqrt((p*(1-p)+z2/(4*nf))/nf)
+	return (center - margin) / (1 + z2/nf)
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComputeUserStats(t *testing.T) {
+	tests := []struct {
+		name           string
+		returns        []float64
+		populationMean float64
+		want           map[string]float64
+	}{
+		{"empty", nil, 3, map[string]float64{
+			scoreTotal: 0, scoreMean: 0, scoreMedian: 0, scoreWinRate: 0, scoreWilson: 0, scoreShrinkage: 0,
+		}},
+		{"single pick", []float64{12}, 2, map[string]float64{
+			// wilson: (1 + 1.96²/2 - 1.96·√(1.96²/4)) / (1 + 1.96²) = 1 / 4.8416
+			// shrinkage: (12 + 10·2) / (1 + 10)
+			scoreTotal: 12, scoreMean: 12, scoreMedian: 12, scoreWinRate: 1, scoreWilson: 0.2065433, scoreShrinkage: 2.9090909,
+		}},
+		{"all losses", []float64{-5, -10, -15, -2}, 1, map[string]float64{
+			// shrinkage: (-32 + 10·1) / (4 + 10)
+			scoreTotal: -32, scoreMean: -8, scoreMedian: -7.5, scoreWinRate: 0, scoreWilson: 0, scoreShrinkage: -1.5714286,
+		}},
+		{"half wins", []float64{10, -4, 6, -2}, 0, map[string]float64{
+			// wilson: (0.5 + 0.4802 - 1.96·√(0.0625 + 0.0600250)) / (1 + 0.9604)
+			scoreTotal: 10, scoreMean: 2.5, scoreMedian: 2, scoreWinRate: 0.5, scoreWilson: 0.1500357, scoreShrinkage: 0.7142857,
+		}},
+		{"flat pick is not a win", []float64{0, 4, -1}, 1, map[string]float64{
+			scoreTotal: 3, scoreMean: 1, scoreMedian: 0, scoreWinRate: 1.0 / 3, scoreWilson: 0.0614903, scoreShrinkage: 1,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := computeUserStats(tt.returns, tt.populationMean)
+			if stats.PickCount != len(tt.returns) {
+				t.Errorf("PickCount = %d, want %d", stats.PickCount, len(tt.returns))
+			}
+			for mode, want := range tt.want {
+				if got := stats.value(mode); math.Abs(got-want) > 1e-6 {
+					t.Errorf("value(%s) = %v, want %v", mode, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWilsonLowerBound(t *testing.T) {
+	tests := []struct {
+		wins, n int
+		want    float64
+	}{
+		{0, 0, 0},
+		{0, 4, 0},
+		{3, 3, 0.4384939},
+		{90, 100, 0.8256327},
+	}
+	for _, tt := range tests {
+		if got := wilsonLowerBound(tt.wins, tt.n); math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("wilsonLowerBound(%d, %d) = %v, want %v", tt.wins, tt.n, got, tt.want)
+		}
+	}
+}
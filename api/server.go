@@ -6,25 +6,30 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/stuneak/sopeko/cron"
 	db "github.com/stuneak/sopeko/db/sqlc"
 )
 
 type Server struct {
-	store  *db.Queries
-	router *gin.Engine
+	store      *db.Queries
+	router     *gin.Engine
+	benchmarks []string
+	adminToken string
+	scheduler  *cron.Scheduler
 }
 
-func NewServer(store *db.Queries, ginMode string) *Server {
-	server := &Server{store: store}
+func NewServer(store *db.Queries, scheduler *cron.Scheduler, ginMode string, benchmarks []string, adminToken string) *Server {
+	server := &Server{store: store, scheduler: scheduler, benchmarks: benchmarks, adminToken: adminToken}
 	router := gin.Default()
 
 	gin.SetMode(ginMode)
 
-	// CORS middleware - allow requests from browser extensions
+	// CORS middleware - allow requests from browser extensions, and from
+	// browser admin clients sending the bearer token
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type"},
+		AllowMethods:     []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
 		AllowCredentials: false,
 	}))
 
@@ -38,11 +43,29 @@ func NewServer(store *db.Queries, ginMode string) *Server {
 
 	// Routes
 	router.GET("/api/mentions/:username", server.getUserMentions)
+	router.POST("/api/mentions/batch", server.getBatchMentions)
 	router.GET("/api/excluded-usernames", server.getExcludedUsernames)
 	router.GET("/api/top-performers", server.getTopPerformingUsers)
 	router.GET("/api/top-picks", server.getTopPerformingPicks)
 	router.GET("/api/worst-picks", server.getWorstPerformingPicks)
+	router.GET("/api/tickers/:symbol", server.getTicker)
+	router.GET("/api/tickers/:symbol/early-callers", server.getEarlyCallers)
+	router.GET("/api/trending", server.getTrending)
+	router.GET("/api/subreddits", server.getSubreddits)
+	router.GET("/api/predictions/:username", server.getUserPredictions)
+	router.GET("/api/options/:username", server.getUserOptions)
 	// router.GET("/api/visitors", server.getVisitorStats)
+
+	// Admin routes - require ADMIN_TOKEN
+	admin := router.Group("/api/admin", server.adminAuthMiddleware())
+	admin.GET("/subreddits", server.listSubreddits)
+	admin.POST("/subreddits", server.createSubreddit)
+	admin.PATCH("/subreddits/:name", server.updateSubreddit)
+	admin.GET("/aliases", server.listAliases)
+	admin.POST("/aliases", server.createAlias)
+	admin.DELETE("/aliases/:alias", server.deleteAlias)
+	admin.GET("/jobs", server.getJobStatus)
+
 	server.router = router
 	return server
 }
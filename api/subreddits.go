@@ -0,0 +1,155 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/stuneak/sopeko/db/sqlc"
+)
+
+type SubredditSummary struct {
+	Subreddit            string  `json:"subreddit"`
+	Mentions             int     `json:"mentions"`
+	UniqueUsers          int     `json:"unique_users"`
+	UniqueTickers        int     `json:"unique_tickers"`
+	AveragePercentChange float64 `json:"average_percent_change"`
+	AverageExcessReturn  float64 `json:"average_excess_return"`
+	WinRate              float64 `json:"win_rate"`
+}
+
+type SubredditsResponse struct {
+	GeneratedAt time.Time          `json:"generated_at"`
+	Results     []SubredditSummary `json:"results"`
+}
+
+// getSubreddits summarizes mention volume and pick performance per subreddit,
+// from the same snapshot as the leaderboards.
+func (server *Server) getSubreddits(ctx *gin.Context) {
+	period := parsePeriod(ctx.Query("period"))
+
+	horizon, err := parseHorizon(ctx.Query("horizon"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	benchmark, err := server.parseBenchmark(ctx.Query("benchmark"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results, generatedAt, err := server.loadSubredditSummaries(ctx, period, horizon, benchmark)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, SubredditsResponse{
+		GeneratedAt: generatedAt,
+		Results:     results,
+	})
+}
+
+// loadSubredditSummaries aggregates snapshots in SQL; keys without one are
+// summarized from every mention of the period.
+func (server *Server) loadSubredditSummaries(ctx *gin.Context, period string, horizon int32, benchmark string) ([]SubredditSummary, time.Time, error) {
+	generatedAt, ok, err := server.leaderboardGeneration(ctx, period, horizon, benchmark)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if !ok {
+		mentions, err := server.store.GetAllMentionsComplete(ctx, db.GetAllMentionsCompleteParams{
+			HorizonDays: horizon,
+			Benchmark:   benchmark,
+			MentionedAt: parsePeriodCutoff(period),
+		})
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		return summarizeSubreddits(mentions, horizon), time.Now(), nil
+	}
+
+	rows, err := server.store.GetLeaderboardSubreddits(ctx, db.GetLeaderboardSubredditsParams{
+		Period:            period,
+		HorizonDays:       horizon,
+		Benchmark:         benchmark,
+		GeneratedAt:       generatedAt,
+		ExcludedUsernames: excludedUsernames,
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	results := make([]SubredditSummary, 0, len(rows))
+	for _, r := range rows {
+		results = append(results, SubredditSummary{
+			Subreddit:            r.Subreddit,
+			Mentions:             int(r.Mentions),
+			UniqueUsers:          int(r.UniqueUsers),
+			UniqueTickers:        int(r.UniqueTickers),
+			AveragePercentChange: r.AveragePercentChange,
+			AverageExcessReturn:  r.AverageExcessReturn,
+			WinRate:              r.WinRate,
+		})
+	}
+	return results, generatedAt, nil
+}
+
+// summarizeSubreddits aggregates the mentions of a key computed live per
+// subreddit.
+func summarizeSubreddits(mentions []db.GetAllMentionsCompleteRow, horizon int32) []SubredditSummary {
+	excludedMap := make(map[string]bool)
+	for _, u := range excludedUsernames {
+		excludedMap[u] = true
+	}
+
+	summaries := make(map[string]*SubredditSummary)
+	users := make(map[string]map[string]bool)
+	tickers := make(map[string]map[string]bool)
+	for _, m := range mentions {
+		if excludedMap[m.Username] || m.Subreddit == "" {
+			continue
+		}
+
+		if !isScorable(m.MentionPrice, m.HasCurrentPrice, horizon) {
+			continue
+		}
+
+		summary, exists := summaries[m.Subreddit]
+		if !exists {
+			summary = &SubredditSummary{Subreddit: m.Subreddit}
+			summaries[m.Subreddit] = summary
+			users[m.Subreddit] = make(map[string]bool)
+			tickers[m.Subreddit] = make(map[string]bool)
+		}
+
+		r := computePickReturn(m.MentionPrice, m.CurrentPrice, m.SplitRatio, m.BenchmarkStartPrice, m.BenchmarkEndPrice, m.Stance)
+		summary.Mentions++
+		summary.AveragePercentChange += r.PercentChange
+		summary.AverageExcessReturn += r.ExcessReturn
+		if r.PercentChange > 0 {
+			summary.WinRate++
+		}
+		users[m.Subreddit][m.Username] = true
+		tickers[m.Subreddit][m.Symbol] = true
+	}
+
+	results := make([]SubredditSummary, 0, len(summaries))
+	for name, summary := range summaries {
+		n := float64(summary.Mentions)
+		summary.AveragePercentChange /= n
+		summary.AverageExcessReturn /= n
+		summary.WinRate /= n
+		summary.UniqueUsers = len(users[name])
+		summary.UniqueTickers = len(tickers[name])
+		results = append(results, *summary)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Mentions > results[j].Mentions
+	})
+	return results
+}
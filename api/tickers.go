@@ -0,0 +1,242 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/stuneak/sopeko/db/sqlc"
+)
+
+type TickerSplitResponse struct {
+	Ratio         float64   `json:"ratio"`
+	EffectiveDate time.Time `json:"effective_date"`
+}
+
+type TickerPricePoint struct {
+	Date   time.Time `json:"date"`
+	Open   string    `json:"open"`
+	High   string    `json:"high"`
+	Low    string    `json:"low"`
+	Price  string    `json:"price"` // close
+	Volume int64     `json:"volume"`
+}
+
+type TickerMentionBucket struct {
+	Date        time.Time `json:"date"`
+	Mentions    int64     `json:"mentions"`
+	UniqueUsers int64     `json:"unique_users"`
+}
+
+type TickerMentionerResponse struct {
+	Username      string    `json:"username"`
+	MentionPrice  string    `json:"mention_price"`
+	PercentChange string    `json:"percent_change"`
+	SplitRatio    float64   `json:"split_ratio"`
+	MentionedAt   time.Time `json:"mentioned_at"`
+}
+
+type TickerDetailResponse struct {
+	Symbol       string                    `json:"symbol"`
+	CompanyName  string                    `json:"company_name"`
+	Exchange     string                    `json:"exchange"`
+	Currency     string                    `json:"currency"`
+	CurrentPrice string                    `json:"current_price"`
+	Splits       []TickerSplitResponse     `json:"splits"`
+	Prices       []TickerPricePoint        `json:"prices"`
+	Mentions     []TickerMentionBucket     `json:"mentions"`
+	Mentioners   []TickerMentionerResponse `json:"mentioners"`
+}
+
+// getTicker returns everything the website needs to chart mentions against
+// price for a single ticker.
+func (server *Server) getTicker(ctx *gin.Context) {
+	symbol := strings.ToUpper(ctx.Param("symbol"))
+
+	ticker, err := server.store.GetTickerBySymbol(ctx, symbol)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("ticker %s not found", symbol)})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	splits, err := server.store.GetSplitsByTicker(ctx, ticker.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	prices, err := server.store.GetDailyTickerPrices(ctx, ticker.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	counts, err := server.store.GetDailyMentionCounts(ctx, db.GetDailyMentionCountsParams{
+		TickerID:          ticker.ID,
+		ExcludedUsernames: excludedUsernames,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	mentioners, err := server.store.GetTickerMentioners(ctx, db.GetTickerMentionersParams{
+		TickerID:          ticker.ID,
+		ExcludedUsernames: excludedUsernames,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := TickerDetailResponse{
+		Symbol:      ticker.Symbol,
+		CompanyName: ticker.CompanyName,
+		Exchange:    ticker.Exchange,
+		Currency:    ticker.Currency,
+		Splits:      make([]TickerSplitResponse, 0, len(splits)),
+		Prices:      make([]TickerPricePoint, 0, len(prices)),
+		Mentions:    make([]TickerMentionBucket, 0, len(counts)),
+		Mentioners:  make([]TickerMentionerResponse, 0, len(mentioners)),
+	}
+
+	for _, s := range splits {
+		ratio, _ := strconv.ParseFloat(s.Ratio, 64)
+		result.Splits = append(result.Splits, TickerSplitResponse{
+			Ratio:         ratio,
+			EffectiveDate: s.EffectiveDate,
+		})
+	}
+
+	for _, p := range prices {
+		result.Prices = append(result.Prices, TickerPricePoint{
+			Date:   p.Day,
+			Open:   p.Open,
+			High:   p.High,
+			Low:    p.Low,
+			Price:  p.Price,
+			Volume: p.Volume,
+		})
+	}
+	if len(prices) > 0 {
+		result.CurrentPrice = prices[len(prices)-1].Price
+	}
+
+	for _, c := range counts {
+		result.Mentions = append(result.Mentions, TickerMentionBucket{
+			Date:        c.Day,
+			Mentions:    c.MentionCount,
+			UniqueUsers: c.UniqueUsers,
+		})
+	}
+
+	for _, m := range mentioners {
+		adjustedMentionPrice := adjustPriceForSplits(fmt.Sprintf("%v", m.MentionPrice), m.SplitRatio)
+		result.Mentioners = append(result.Mentioners, TickerMentionerResponse{
+			Username:      m.Username,
+			MentionPrice:  adjustedMentionPrice,
+			PercentChange: calculatePercentChange(adjustedMentionPrice, result.CurrentPrice),
+			SplitRatio:    m.SplitRatio,
+			MentionedAt:   m.MentionedAt,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+type EarlyCallerResponse struct {
+	Username      string    `json:"username"`
+	MentionPrice  string    `json:"mention_price"`
+	PercentChange string    `json:"percent_change"`
+	SplitRatio    float64   `json:"split_ratio"`
+	MentionedAt   time.Time `json:"mentioned_at"`
+	SpikeDate     time.Time `json:"spike_date"`
+	SpikeUsers    int32     `json:"spike_users"`
+}
+
+// getEarlyCallers lists the users who first mentioned a ticker in the days
+// before one of its mention spikes, as computed by the early-calls job.
+func (server *Server) getEarlyCallers(ctx *gin.Context) {
+	symbol := strings.ToUpper(ctx.Param("symbol"))
+
+	ticker, err := server.store.GetTickerBySymbol(ctx, symbol)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("ticker %s not found", symbol)})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	callers, err := server.store.GetEarlyCallersByTicker(ctx, ticker.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var currentPrice string
+	latest, err := server.store.GetTickerPriceBeforeDate(ctx, db.GetTickerPriceBeforeDateParams{
+		TickerID:   ticker.ID,
+		RecordedAt: time.Now(),
+	})
+	if err == nil {
+		currentPrice = latest.Price
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	excludedMap := make(map[string]bool)
+	for _, u := range excludedUsernames {
+		excludedMap[u] = true
+	}
+
+	results := make([]EarlyCallerResponse, 0, len(callers))
+	for _, c := range callers {
+		if excludedMap[c.Username] {
+			continue
+		}
+
+		adjustedMentionPrice := adjustPriceForSplits(fmt.Sprintf("%v", c.MentionPrice), c.SplitRatio)
+		results = append(results, EarlyCallerResponse{
+			Username:      c.Username,
+			MentionPrice:  adjustedMentionPrice,
+			PercentChange: calculatePercentChange(adjustedMentionPrice, currentPrice),
+			SplitRatio:    c.SplitRatio,
+			MentionedAt:   c.MentionedAt,
+			SpikeDate:     c.SpikeDate,
+			SpikeUsers:    c.SpikeUsers,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, results)
+}
+
+// loadEarlyCallCounts returns how many tickers each user called early. Users
+// without early calls are absent from the map.
+func (server *Server) loadEarlyCallCounts(ctx context.Context, usernames []string) (map[string]int64, error) {
+	counts := make(map[string]int64, len(usernames))
+	if len(usernames) == 0 {
+		return counts, nil
+	}
+
+	rows, err := server.store.GetEarlyCallCountsByUsernames(ctx, usernames)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		counts[r.Username] = r.EarlyCalls
+	}
+	return counts, nil
+}
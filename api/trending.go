@@ -0,0 +1,107 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/stuneak/sopeko/db/sqlc"
+)
+
+const (
+	trendingBaseline       = 30 * 24 * time.Hour
+	trendingMinUniqueUsers = 2
+	trendingLimit          = 25
+)
+
+var trendingWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"6h":  6 * time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+type TrendingTickerResponse struct {
+	Symbol        string  `json:"symbol"`
+	Mentions      int64   `json:"mentions"`
+	UniqueUsers   int64   `json:"unique_users"`
+	BaselineMean  float64 `json:"baseline_mean"`
+	ZScore        float64 `json:"z_score"`
+	CurrentPrice  string  `json:"current_price"`
+	PercentChange float64 `json:"percent_change"`
+}
+
+type TrendingResponse struct {
+	Window  string                   `json:"window"`
+	Results []TrendingTickerResponse `json:"results"`
+}
+
+// getTrending ranks tickers by how far the number of distinct users mentioning
+// them in the window exceeds their own trailing baseline. Counting users rather
+// than mentions keeps one account repeating a symbol from making it trend.
+func (server *Server) getTrending(ctx *gin.Context) {
+	window := ctx.DefaultQuery("window", "24h")
+	windowLength, ok := trendingWindows[window]
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid window %q", window)})
+		return
+	}
+
+	windowStart := time.Now().Add(-windowLength)
+	rows, err := server.store.GetTrendingTickers(ctx, db.GetTrendingTickersParams{
+		WindowStart:       windowStart,
+		ExcludedUsernames: excludedUsernames,
+		WindowSeconds:     windowLength.Seconds(),
+		BaselineStart:     windowStart.Add(-trendingBaseline),
+		MinUniqueUsers:    trendingMinUniqueUsers,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	buckets := float64(trendingBaseline / windowLength)
+	results := make([]TrendingTickerResponse, 0, len(rows))
+	for _, r := range rows {
+		mean, stddev := bucketStats(r.BaselineUserSum, r.BaselineUserSumSquares, buckets)
+
+		startPrice := adjustPriceForSplits(fmt.Sprintf("%v", r.WindowStartPrice), r.SplitRatio)
+		currentPrice := fmt.Sprintf("%v", r.CurrentPrice)
+		var percentChange float64
+		if isPositivePrice(startPrice) {
+			percentChange = calculatePercentChangeFloat(startPrice, currentPrice)
+		}
+
+		results = append(results, TrendingTickerResponse{
+			Symbol:        r.Symbol,
+			Mentions:      r.MentionCount,
+			UniqueUsers:   r.UniqueUsers,
+			BaselineMean:  mean,
+			ZScore:        (float64(r.UniqueUsers) - mean) / stddev,
+			CurrentPrice:  currentPrice,
+			PercentChange: percentChange,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ZScore > results[j].ZScore
+	})
+	if len(results) > trendingLimit {
+		results = results[:trendingLimit]
+	}
+
+	ctx.JSON(http.StatusOK, TrendingResponse{Window: window, Results: results})
+}
+
+// bucketStats returns the mean and standard deviation of per-window counts
+// from their sum and sum of squares over n windows, where windows without any
+// mentions count as zero. The deviation is floored at 1 so a ticker that was
+// never mentioned before doesn't get an infinite score.
+func bucketStats(sum, sumSquares, n float64) (float64, float64) {
+	mean := sum / n
+	variance := sumSquares/n - mean*mean
+	return mean, math.Max(math.Sqrt(math.Max(variance, 0)), 1)
+}
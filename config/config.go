@@ -2,25 +2,82 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	GINMode       string
-	DBDriver      string
-	DBSource      string
-	ServerAddress string
+	GINMode          string
+	DBDriver         string
+	DBSource         string
+	ServerAddress    string
+	BenchmarkSymbols []string
+	AdminToken       string
+
+	RedditClientID     string
+	RedditClientSecret string
+	RedditUsername     string
+	RedditPassword     string
+	RedditUserAgent    string
+
+	StockTwitsStreams  []string
+	StockTwitsInterval time.Duration
+	RSSFeeds           []string
+	RSSInterval        time.Duration
+
+	PriceProviders []string
+	PriceCSVDir    string
+	PriceWorkers   int
+	PriceRateLimit float64 // requests per second across all workers
+
+	// Tickers mentioned in the last PriceHotDays days are refreshed every run,
+	// older mentions once per PriceWarmInterval, never-mentioned ones not at all
+	PriceHotDays      int
+	PriceWarmInterval time.Duration
+
+	// When a mention is entered: next_open, next_close or intraday
+	EntryRule string
+
+	// Extracted tickers scoring below this (0-1) are not stored as mentions
+	TickerMinConfidence float64
 }
 
 func LoadConfig() (config Config, err error) {
 	err = godotenv.Load()
 
 	config = Config{
-		GINMode:       getEnv("GIN_MODE", "debug"),
-		DBDriver:      getEnv("DB_DRIVER", "postgres"),
-		DBSource:      getEnv("DB_SOURCE", ""),
-		ServerAddress: getEnv("SERVER_ADDRESS", "0.0.0.0:8080"),
+		GINMode:          getEnv("GIN_MODE", "debug"),
+		DBDriver:         getEnv("DB_DRIVER", "postgres"),
+		DBSource:         getEnv("DB_SOURCE", ""),
+		ServerAddress:    getEnv("SERVER_ADDRESS", "0.0.0.0:8080"),
+		BenchmarkSymbols: getEnvList("BENCHMARK_SYMBOLS", "SPY,QQQ,IWM"),
+		AdminToken:       getEnv("ADMIN_TOKEN", ""),
+
+		RedditClientID:     getEnv("REDDIT_CLIENT_ID", ""),
+		RedditClientSecret: getEnv("REDDIT_CLIENT_SECRET", ""),
+		RedditUsername:     getEnv("REDDIT_USERNAME", ""),
+		RedditPassword:     getEnv("REDDIT_PASSWORD", ""),
+		RedditUserAgent:    getEnv("REDDIT_USER_AGENT", "server:sopeko:v1.0"),
+
+		StockTwitsStreams:  getEnvList("STOCKTWITS_STREAMS", ""),
+		StockTwitsInterval: getEnvDuration("STOCKTWITS_INTERVAL", 30*time.Minute),
+		RSSFeeds:           getEnvList("RSS_FEEDS", ""),
+		RSSInterval:        getEnvDuration("RSS_INTERVAL", time.Hour),
+
+		PriceProviders: getEnvList("PRICE_PROVIDERS", "yahoo,stooq"),
+		PriceCSVDir:    getEnv("PRICE_CSV_DIR", ""),
+		PriceWorkers:   getEnvInt("PRICE_WORKERS", 8),
+		PriceRateLimit: getEnvFloat("PRICE_RATE_LIMIT", 5),
+
+		PriceHotDays:      getEnvInt("PRICE_HOT_DAYS", 7),
+		PriceWarmInterval: getEnvDuration("PRICE_WARM_INTERVAL", 24*time.Hour),
+
+		EntryRule: getEnv("ENTRY_RULE", "next_open"),
+
+		TickerMinConfidence: getEnvFraction("TICKER_MIN_CONFIDENCE", 0.5),
 	}
 
 	return config, nil
@@ -33,3 +90,46 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+func getEnvList(key, defaultValue string) []string {
+	var values []string
+	for _, v := range strings.Split(getEnv(key, defaultValue), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(getEnv(key, ""))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(getEnv(key, ""))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(getEnv(key, ""), 64)
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvFraction reads a value from 0 to 1, where 0 is a valid setting.
+func getEnvFraction(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(getEnv(key, ""), 64)
+	if err != nil || value < 0 || value > 1 {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,24 @@
+package config
+
+import "testing"
+
+func TestGetEnvFraction(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"", 0.5},
+		{"0", 0},
+		{"0.7", 0.7},
+		{"1", 1},
+		{"-0.1", 0.5},
+		{"1.5", 0.5},
+		{"high", 0.5},
+	}
+	for _, tt := range tests {
+		t.Setenv("TEST_FRACTION", tt.value)
+		if got := getEnvFraction("TEST_FRACTION", 0.5); got != tt.want {
+			t.Errorf("getEnvFraction(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
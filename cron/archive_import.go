@@ -0,0 +1,363 @@
+package cron
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	external_api "github.com/stuneak/sopeko/cron/external_api"
+	db "github.com/stuneak/sopeko/db/sqlc"
+	"github.com/stuneak/sopeko/pkg/market"
+	"github.com/stuneak/sopeko/pkg/source"
+)
+
+// Archive items are committed archiveBatchSize at a time, together with the
+// progress row, so an interrupted import resumes right after the last commit.
+// Progress is also saved every archiveProgressLines lines, since most lines of
+// a monthly dump belong to subreddits we don't track.
+const (
+	archiveBatchSize     = 500
+	archiveProgressLines = 100_000
+)
+
+type ArchiveImportOptions struct {
+	File       string
+	Subreddits []string // defaults to the enabled subreddits
+}
+
+// ImportArchive backfills posts and comments from a Reddit NDJSON dump (the
+// RS_/RC_ monthly files, optionally zstd-compressed) through the same path as
+// the scrape jobs. Progress is tracked per file name in archive_imports.
+func (s *Scheduler) ImportArchive(ctx context.Context, conn *sql.DB, opts ArchiveImportOptions) error {
+	name := filepath.Base(opts.File)
+
+	tracked, err := s.trackedSubreddits(ctx, opts.Subreddits)
+	if err != nil {
+		return err
+	}
+	if len(tracked) == 0 {
+		return fmt.Errorf("no subreddits to import")
+	}
+
+	progress, err := s.store.GetArchiveImport(ctx, name)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if progress.CompletedAt.Valid {
+		clog("archive %s already imported on %s", name, progress.CompletedAt.Time.Format("2006-01-02 15:04"))
+		return nil
+	}
+	if progress.LinesProcessed > 0 {
+		clog("resuming %s after line %d (%d items imported)", name, progress.LinesProcessed, progress.ItemsImported)
+	} else {
+		clog("importing %s for %d subreddits", name, len(tracked))
+	}
+
+	started := time.Now()
+	lastLine, savedLine := progress.LinesProcessed, progress.LinesProcessed
+	imported := progress.ItemsImported
+	batch := make([]external_api.SourceItem, 0, archiveBatchSize)
+
+	listed := make(map[string]db.TickerName)
+
+	// Mentions and their entries are resolved before the transaction, which
+	// only inserts the batch and the progress row
+	flush := func() error {
+		items, err := s.prepareArchiveBatch(ctx, listed, batch)
+		if err != nil {
+			return err
+		}
+
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		q := s.store.WithTx(tx)
+		if err := insertArchiveBatch(ctx, q, items); err != nil {
+			return err
+		}
+
+		err = q.SaveArchiveImportProgress(ctx, db.SaveArchiveImportProgressParams{
+			File:           name,
+			LinesProcessed: lastLine,
+			ItemsImported:  imported + int64(len(batch)),
+		})
+		if err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+
+		imported += int64(len(batch))
+		savedLine = lastLine
+		batch = batch[:0]
+		clog("%s: line %d, %d items imported", name, lastLine, imported)
+		return nil
+	}
+
+	err = external_api.ReadRedditArchive(ctx, opts.File, progress.LinesProcessed, func(line int64, item external_api.SourceItem) error {
+		lastLine = line
+		if tracked[strings.ToLower(item.Subreddit)] && item.Author != "" && item.Author != "[deleted]" {
+			batch = append(batch, item)
+		}
+		if len(batch) >= archiveBatchSize || lastLine-savedLine >= archiveProgressLines {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("import %s stopped after line %d: %w", name, savedLine, err)
+	}
+
+	if err := flush(); err != nil {
+		return err
+	}
+	if err := s.store.CompleteArchiveImport(ctx, name); err != nil {
+		return err
+	}
+
+	clog("finished %s: %d lines, %d items imported in %s", name, lastLine, imported, time.Since(started).Round(time.Second))
+	return nil
+}
+
+// archiveItem is an archive post or comment that is not stored yet, with
+// its mentions.
+type archiveItem struct {
+	author   string
+	item     external_api.SourceItem
+	mentions []archiveMention
+}
+
+// archiveMention is a mention with its entry as resolved before the insert.
+// entryRule is empty when the entry could not be resolved, and entryPrice
+// while the entry has not been traded.
+type archiveMention struct {
+	contentMention
+	entryRule  string
+	entryAt    string
+	entryPrice string
+}
+
+// prepareArchiveBatch drops the items that are already stored, finds the
+// mentions of the others and resolves their entries, fetching the prices
+// that are missing.
+func (s *Scheduler) prepareArchiveBatch(ctx context.Context, listed map[string]db.TickerName, batch []external_api.SourceItem) ([]archiveItem, error) {
+	var usernames, externalIDs []string
+	for _, item := range batch {
+		usernames = append(usernames, source.Username(source.Reddit, item.Author))
+		externalIDs = append(externalIDs, item.ID)
+	}
+	existing, err := s.store.ListExistingComments(ctx, db.ListExistingCommentsParams{
+		Usernames:   usernames,
+		ExternalIds: externalIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	for _, c := range existing {
+		seen[c.Username+"/"+c.ExternalID] = true
+	}
+
+	var items []archiveItem
+	benchmarkDays := make(map[time.Time]bool)
+	for i, item := range batch {
+		author := usernames[i]
+		if seen[author+"/"+item.ID] {
+			continue
+		}
+		seen[author+"/"+item.ID] = true
+
+		// Postgres rejects NUL bytes in text, and one bad row would fail the batch
+		item.Body = strings.ReplaceAll(item.Body, "\x00", "")
+
+		var mentions []archiveMention
+		for _, m := range s.findMentions(ctx, listed, item.Body, item.CreatedAt, item.ID) {
+			mention := archiveMention{contentMention: m}
+			entryAt, price, err := s.resolveEntry(ctx, s.store, m.ticker.ID, m.ticker.Symbol, item.CreatedAt)
+			if err != nil {
+				clog("error setting entry for %s, left to the %s job: %v", m.ticker.Symbol, mentionEntriesJob, err)
+			} else {
+				mention.entryRule = s.entryRule
+				mention.entryAt = entryAt.Format(time.RFC3339Nano)
+				mention.entryPrice = price.String
+			}
+			mentions = append(mentions, mention)
+		}
+		if len(mentions) > 0 {
+			benchmarkDays[market.Day(entryTime(s.entryRule, item.CreatedAt))] = true
+		}
+		items = append(items, archiveItem{author: author, item: item, mentions: mentions})
+	}
+
+	// Benchmarks need a price at the entry too, so excess returns can be computed
+	for day := range benchmarkDays {
+		s.fetchBenchmarkEntries(ctx, market.SessionClose(day))
+	}
+	return items, nil
+}
+
+// insertArchiveBatch inserts the users, comments, mentions, predictions and
+// option contracts of a batch, one statement per table.
+func insertArchiveBatch(ctx context.Context, q *db.Queries, items []archiveItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	var usernames []string
+	for _, it := range items {
+		usernames = append(usernames, it.author)
+	}
+	users, err := q.CreateUsers(ctx, usernames)
+	if err != nil {
+		return fmt.Errorf("creating users: %w", err)
+	}
+	userIDs := make(map[string]int64, len(users))
+	for _, u := range users {
+		userIDs[u.Username] = u.ID
+	}
+
+	var comments db.CreateCommentsParams
+	for _, it := range items {
+		userID, ok := userIDs[it.author]
+		if !ok {
+			clog("user %s was not created, skipping externalID=%s", it.author, it.item.ID)
+			continue
+		}
+		comments.UserIds = append(comments.UserIds, userID)
+		comments.Sources = append(comments.Sources, source.Reddit)
+		comments.ExternalIds = append(comments.ExternalIds, it.item.ID)
+		comments.Contents = append(comments.Contents, it.item.Body)
+		comments.CreatedAts = append(comments.CreatedAts, it.item.CreatedAt)
+		comments.Kinds = append(comments.Kinds, it.item.Kind)
+		comments.Subreddits = append(comments.Subreddits, it.item.Subreddit)
+		comments.Permalinks = append(comments.Permalinks, it.item.URL)
+		comments.PostIds = append(comments.PostIds, it.item.PostID)
+		comments.ParentIds = append(comments.ParentIds, it.item.ParentID)
+		comments.Scores = append(comments.Scores, int32(it.item.Score))
+	}
+	created, err := q.CreateComments(ctx, comments)
+	if err != nil {
+		return fmt.Errorf("creating comments: %w", err)
+	}
+	type commentKey struct {
+		userID     int64
+		externalID string
+	}
+	commentIDs := make(map[commentKey]int64, len(created))
+	for _, c := range created {
+		commentIDs[commentKey{c.UserID, c.ExternalID}] = c.ID
+	}
+
+	var mentions db.CreateTickerMentionsParams
+	for _, it := range items {
+		userID := userIDs[it.author]
+		commentID, ok := commentIDs[commentKey{userID, it.item.ID}]
+		if !ok {
+			continue
+		}
+		for _, m := range it.mentions {
+			mentions.TickerIds = append(mentions.TickerIds, m.ticker.ID)
+			mentions.UserIds = append(mentions.UserIds, userID)
+			mentions.CommentIds = append(mentions.CommentIds, commentID)
+			mentions.MentionedAts = append(mentions.MentionedAts, it.item.CreatedAt)
+			mentions.Subreddits = append(mentions.Subreddits, strings.ToLower(it.item.Subreddit))
+			mentions.Confidences = append(mentions.Confidences, m.candidate.Confidence)
+			mentions.DetectedBys = append(mentions.DetectedBys, m.candidate.DetectedBy)
+			mentions.Stances = append(mentions.Stances, m.candidate.Stance)
+			mentions.Instruments = append(mentions.Instruments, mentionInstrument(m.candidate))
+			mentions.EntryRules = append(mentions.EntryRules, m.entryRule)
+			mentions.EntryAts = append(mentions.EntryAts, m.entryAt)
+			mentions.EntryPrices = append(mentions.EntryPrices, m.entryPrice)
+		}
+	}
+	if len(mentions.TickerIds) == 0 {
+		return nil
+	}
+	stored, err := q.CreateTickerMentions(ctx, mentions)
+	if err != nil {
+		return fmt.Errorf("creating mentions: %w", err)
+	}
+	type mentionKey struct {
+		commentID, tickerID int64
+	}
+	mentionIDs := make(map[mentionKey]int64, len(stored))
+	for _, m := range stored {
+		mentionIDs[mentionKey{m.CommentID, m.TickerID}] = m.ID
+	}
+
+	var predictions db.CreateMentionPredictionsParams
+	var options db.CreateMentionOptionsParams
+	for _, it := range items {
+		commentID := commentIDs[commentKey{userIDs[it.author], it.item.ID}]
+		for _, m := range it.mentions {
+			mentionID, ok := mentionIDs[mentionKey{commentID, m.ticker.ID}]
+			if !ok {
+				continue
+			}
+			for _, p := range m.candidate.Predictions {
+				var target string
+				if p.Multiple <= 0 {
+					target = fmt.Sprintf("%.4f", p.TargetPrice)
+				}
+				predictions.MentionIds = append(predictions.MentionIds, mentionID)
+				predictions.TargetPrices = append(predictions.TargetPrices, target)
+				predictions.Multiples = append(predictions.Multiples, max(p.Multiple, 0))
+				predictions.Deadlines = append(predictions.Deadlines, p.Deadline)
+				predictions.Claims = append(predictions.Claims, p.Claim)
+			}
+			for _, c := range m.candidate.Options {
+				var expiresAt string
+				if !c.ExpiresAt.IsZero() {
+					expiresAt = c.ExpiresAt.Format(time.RFC3339Nano)
+				}
+				options.MentionIds = append(options.MentionIds, mentionID)
+				options.OptionTypes = append(options.OptionTypes, c.Type)
+				options.Strikes = append(options.Strikes, fmt.Sprintf("%.4f", c.Strike))
+				options.ExpiresAts = append(options.ExpiresAts, expiresAt)
+				options.Written = append(options.Written, c.Written)
+			}
+		}
+	}
+	if len(predictions.MentionIds) > 0 {
+		if err := q.CreateMentionPredictions(ctx, predictions); err != nil {
+			return fmt.Errorf("creating predictions: %w", err)
+		}
+	}
+	if len(options.MentionIds) > 0 {
+		if err := q.CreateMentionOptions(ctx, options); err != nil {
+			return fmt.Errorf("creating option contracts: %w", err)
+		}
+	}
+	return nil
+}
+
+// trackedSubreddits returns the lowercase names to import, from the given
+// list or the enabled rows of the subreddits table.
+func (s *Scheduler) trackedSubreddits(ctx context.Context, names []string) (map[string]bool, error) {
+	tracked := make(map[string]bool)
+	if len(names) == 0 {
+		subs, err := s.store.ListEnabledSubreddits(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, sub := range subs {
+			names = append(names, sub.Name)
+		}
+	}
+
+	for _, name := range names {
+		name = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(name)), "r/")
+		if name != "" {
+			tracked[name] = true
+		}
+	}
+	return tracked, nil
+}
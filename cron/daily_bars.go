@@ -0,0 +1,184 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	external_api "github.com/stuneak/sopeko/cron/external_api"
+	db "github.com/stuneak/sopeko/db/sqlc"
+)
+
+// Daily bars are kept for benchmarks and every mentioned ticker. A ticker is
+// backfilled from a week before its first mention, so its chart shows the
+// run-up, and after that only the days since its newest bar are fetched.
+// Benchmarks start at the earliest mention of any ticker.
+const (
+	dailyBarsJob      = "ticker-daily-bars"
+	dailyBarsTimeout  = 3 * time.Hour
+	dailyBarsLeadDays = 7
+)
+
+func (s *Scheduler) syncDailyBars() {
+	ctx, cancel := context.WithTimeout(context.Background(), dailyBarsTimeout)
+	defer cancel()
+
+	startedAt := time.Now()
+	clog("starting daily bars sync")
+
+	targets, err := s.store.ListDailyBarTargets(ctx)
+	if err != nil {
+		clog("error fetching tickers from DB: %v", err)
+		return
+	}
+
+	var earliestMention time.Time
+	for _, t := range targets {
+		if t.FirstMentionedAt.Valid && (earliestMention.IsZero() || t.FirstMentionedAt.Time.Before(earliestMention)) {
+			earliestMention = t.FirstMentionedAt.Time
+		}
+	}
+	if earliestMention.IsZero() {
+		clog("no mentions yet, nothing to backfill")
+		return
+	}
+
+	var fetched, failed, skipped, stored int
+	for i, t := range targets {
+		if strings.Contains(t.Symbol, "^") || strings.Contains(t.Symbol, "/") {
+			skipped++
+			continue
+		}
+		if ctx.Err() != nil {
+			skipped += len(targets) - i
+			break
+		}
+		if i > 0 && i%100 == 0 {
+			clog("progress %d/%d (%d updated, %d errors)", i, len(targets), fetched, failed)
+		}
+
+		var from time.Time
+		switch {
+		case t.LastBarDay.Valid:
+			from = t.LastBarDay.Time.AddDate(0, 0, 1)
+		case t.FirstMentionedAt.Valid:
+			from = t.FirstMentionedAt.Time.AddDate(0, 0, -dailyBarsLeadDays)
+		default:
+			from = earliestMention.AddDate(0, 0, -dailyBarsLeadDays)
+		}
+		if from.After(startedAt) {
+			skipped++
+			continue
+		}
+
+		n, err := s.syncTickerBars(ctx, s.store, t.ID, t.Symbol, from, startedAt)
+		if err != nil {
+			if ctx.Err() != nil {
+				skipped += len(targets) - i
+				break
+			}
+			failed++
+			if failed <= 10 {
+				clog("error for %s: %v", t.Symbol, err)
+			}
+			continue
+		}
+		fetched++
+		stored += n
+	}
+
+	finishedAt := time.Now()
+	clog("done in %s - %d tickers updated (%d bars), %d errors, %d skipped",
+		finishedAt.Sub(startedAt).Round(time.Second), fetched, stored, failed, skipped)
+
+	saveCtx, saveCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer saveCancel()
+	err = s.store.CreateJobRun(saveCtx, db.CreateJobRunParams{
+		Job:        dailyBarsJob,
+		StartedAt:  startedAt,
+		FinishedAt: finishedAt,
+		DurationMs: finishedAt.Sub(startedAt).Milliseconds(),
+		Total:      int32(len(targets)),
+		Fetched:    int32(fetched),
+		Failed:     int32(failed),
+		Skipped:    int32(skipped),
+	})
+	if err != nil {
+		clog("error saving run summary: %v", err)
+	}
+}
+
+// syncTickerBars stores the finished sessions between from and now. Providers
+// adjust old bars for every split up to today, while the mention queries
+// expect prices as traded and adjust them with ticker_splits themselves, so
+// the adjustment is undone with the splits already stored. Splits are read
+// and bars written through store, so a caller inside a transaction sees and
+// rolls back its own rows.
+func (s *Scheduler) syncTickerBars(ctx context.Context, store *db.Queries, tickerID int64, symbol string, from, now time.Time) (int, error) {
+	if err := s.priceLimiter.Wait(ctx); err != nil {
+		return 0, err
+	}
+	bars, err := s.prices.FetchDailyBars(ctx, symbol, from, now)
+	if errors.Is(err, external_api.ErrNoData) {
+		// Nothing traded since the last bar, e.g. over a weekend
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	splits, err := store.GetSplitsByTicker(ctx, tickerID)
+	if err != nil {
+		return 0, err
+	}
+
+	var stored int
+	for _, bar := range bars {
+		// Today's session is still running, the price job covers it
+		if bar.ClosedAt.After(now) {
+			continue
+		}
+
+		if err := store.UpsertDailyBar(ctx, dailyBarParams(tickerID, bar, splits)); err != nil {
+			return stored, fmt.Errorf("storing bar for %s: %w", bar.Date.Format("2006-01-02"), err)
+		}
+		stored++
+	}
+	return stored, nil
+}
+
+// dailyBarParams is a provider bar as traded, with the split adjustment
+// undone.
+func dailyBarParams(tickerID int64, bar external_api.DailyBar, splits []db.TickerSplit) db.UpsertDailyBarParams {
+	factor := splitFactor(splits, bar.Date)
+	return db.UpsertDailyBarParams{
+		TickerID: tickerID,
+		Day:      bar.Date,
+		Open:     fmt.Sprintf("%.4f", bar.Open/factor),
+		High:     fmt.Sprintf("%.4f", bar.High/factor),
+		Low:      fmt.Sprintf("%.4f", bar.Low/factor),
+		Close:    fmt.Sprintf("%.4f", bar.Close/factor),
+		Volume:   int64(float64(bar.Volume) * factor),
+		OpenedAt: bar.OpenedAt,
+		ClosedAt: bar.ClosedAt,
+	}
+}
+
+// splitFactor is the factor a provider price of day was divided by for the
+// splits since: sessions before a split's ex-date were traded at pre-split
+// prices.
+func splitFactor(splits []db.TickerSplit, day time.Time) float64 {
+	factor := 1.0
+	for _, split := range splits {
+		y, m, d := split.EffectiveDate.Date()
+		exDate := time.Date(y, m, d, 0, 0, 0, 0, day.Location())
+		ratio, err := strconv.ParseFloat(split.Ratio, 64)
+		if err == nil && ratio > 0 && day.Before(exDate) {
+			factor *= ratio
+		}
+	}
+	return factor
+}
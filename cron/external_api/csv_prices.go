@@ -0,0 +1,120 @@
+package external_api
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVPriceProvider serves prices from a local directory, for tests and
+// offline runs. Each symbol has a daily OHLCV file <dir>/<SYMBOL>.csv as
+// downloaded from Stooq or Yahoo, and optionally <dir>/<SYMBOL>.splits.csv
+// with Date,Numerator,Denominator rows (2-for-1 is 2,1).
+type CSVPriceProvider struct {
+	dir string
+}
+
+func NewCSVPriceProvider(dir string) *CSVPriceProvider {
+	return &CSVPriceProvider{dir: dir}
+}
+
+func (c *CSVPriceProvider) Name() string { return "csv" }
+
+func (c *CSVPriceProvider) FetchCurrentPriceAndVolume(ctx context.Context, symbol string) (float64, int64, time.Time, error) {
+	bars, err := c.readBars(symbol)
+	if err != nil {
+		return 0, 0, time.Time{}, err
+	}
+	return latestClose(bars)
+}
+
+func (c *CSVPriceProvider) FetchHistoricalPrice(ctx context.Context, symbol string, date time.Time) (float64, int64, time.Time, error) {
+	bars, err := c.readBars(symbol)
+	if err != nil {
+		return 0, 0, time.Time{}, err
+	}
+	return lastCloseAtOrBefore(bars, symbol, date)
+}
+
+func (c *CSVPriceProvider) FetchDailyBars(ctx context.Context, symbol string, from, to time.Time) ([]DailyBar, error) {
+	bars, err := c.readBars(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return barsBetween(bars, from, to), nil
+}
+
+func (c *CSVPriceProvider) FetchIntradayPrice(ctx context.Context, symbol string, at time.Time) (float64, time.Time, error) {
+	return 0, time.Time{}, fmt.Errorf("csv intraday prices: %w", ErrNotSupported)
+}
+
+func (c *CSVPriceProvider) FetchSplits(ctx context.Context, symbol string) ([]SplitEvent, error) {
+	file, err := os.Open(filepath.Join(c.dir, strings.ToUpper(symbol)+".splits.csv"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Malformed rows are skipped rather than failing the whole file
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	if _, err := reader.Read(); err != nil {
+		return nil, err
+	}
+
+	var splits []SplitEvent
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 3 {
+			continue
+		}
+		date, err := time.ParseInLocation("2006-01-02", record[0], nyLoc)
+		if err != nil {
+			continue
+		}
+		numerator, err1 := strconv.ParseFloat(record[1], 64)
+		denominator, err2 := strconv.ParseFloat(record[2], 64)
+		if err1 != nil || err2 != nil || numerator <= 0 || denominator <= 0 {
+			continue
+		}
+		// Same convention as Yahoo: the factor historical prices are multiplied by
+		splits = append(splits, SplitEvent{Ratio: denominator / numerator, EffectiveDate: date})
+	}
+	return splits, nil
+}
+
+func (c *CSVPriceProvider) readBars(symbol string) ([]DailyBar, error) {
+	file, err := os.Open(filepath.Join(c.dir, strings.ToUpper(symbol)+".csv"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no csv file for %s: %w", symbol, ErrNoData)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	bars, err := parseDailyBars(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s.csv: %w", symbol, err)
+	}
+	if len(bars) == 0 {
+		return nil, fmt.Errorf("no rows in csv for %s: %w", symbol, ErrNoData)
+	}
+	return bars, nil
+}
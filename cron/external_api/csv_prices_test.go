@@ -0,0 +1,44 @@
+package external_api
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCSVFetchSplitsSkipsMalformedRows(t *testing.T) {
+	dir := t.TempDir()
+	splits := "Date,Numerator,Denominator\n" +
+		"2024-06-10,10,1\n" +
+		"2022-07-18\n" +
+		"2021-01-04,4\n" +
+		"2020-08-31,4,0\n" +
+		"2019-01-02,0,1\n" +
+		"not a date,2,1\n" +
+		"2018-03-01,1,8\n"
+	if err := os.WriteFile(filepath.Join(dir, "ABC.splits.csv"), []byte(splits), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewCSVPriceProvider(dir).FetchSplits(context.Background(), "abc")
+	if err != nil {
+		t.Fatalf("FetchSplits: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d splits %+v, want the 2 well-formed ones", len(got), got)
+	}
+	if got[0].Ratio != 0.1 || got[0].EffectiveDate.Format("2006-01-02") != "2024-06-10" {
+		t.Errorf("got %+v, want a 10:1 split with ratio 0.1 on 2024-06-10", got[0])
+	}
+	if got[1].Ratio != 8 {
+		t.Errorf("got ratio %v for a 1:8 reverse split, want 8", got[1].Ratio)
+	}
+}
+
+func TestCSVFetchSplitsWithoutFile(t *testing.T) {
+	got, err := NewCSVPriceProvider(t.TempDir()).FetchSplits(context.Background(), "ABC")
+	if err != nil || len(got) != 0 {
+		t.Errorf("FetchSplits = %v, %v, want no splits", got, err)
+	}
+}
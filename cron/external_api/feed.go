@@ -0,0 +1,191 @@
+package external_api
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stuneak/sopeko/pkg/logger"
+	"github.com/stuneak/sopeko/pkg/source"
+)
+
+var flog = logger.NewLogger("FEED")
+
+// feedDateLayouts covers RFC 822 dates in RSS (with and without seconds or a
+// numeric zone) and RFC 3339 dates in Atom.
+var feedDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC3339,
+	time.RFC3339Nano,
+}
+
+var htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
+
+// FeedSource reads an RSS 2.0 or Atom feed. Feeds are not paged, so each
+// fetch only sees the entries currently in the document.
+type FeedSource struct {
+	client *http.Client
+	url    string
+}
+
+// feedDocument decodes both formats: RSS puts items under <channel>, Atom
+// puts entries directly under <feed>.
+type feedDocument struct {
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	GUID        string `xml:"guid"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	Author      string `xml:"author"`
+	Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	PubDate     string `xml:"pubDate"`
+}
+
+type atomEntry struct {
+	ID    string `xml:"id"`
+	Title string `xml:"title"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+	Author    string `xml:"author>name"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+}
+
+func NewFeedSource(feedURL string) *FeedSource {
+	return &FeedSource{
+		client: &http.Client{Timeout: 30 * time.Second},
+		url:    feedURL,
+	}
+}
+
+func (f *FeedSource) Name() string { return source.RSS }
+
+func (f *FeedSource) Fetch(ctx context.Context, since Checkpoint, cutoff time.Time) ([]SourceItem, Checkpoint, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", f.url, nil)
+	if err != nil {
+		return nil, since, err
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, since, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, since, fmt.Errorf("feed %s returned status %d", f.url, resp.StatusCode)
+	}
+
+	var doc feedDocument
+	if err := xml.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, since, err
+	}
+
+	// Entries without an author are attributed to the site
+	var fallbackAuthor string
+	if u, err := url.Parse(f.url); err == nil {
+		fallbackAuthor = u.Host
+	}
+
+	var entries []SourceItem
+	for _, item := range doc.Channel.Items {
+		entries = append(entries, SourceItem{
+			ID:        firstNonEmpty(item.GUID, item.Link),
+			Author:    firstNonEmpty(item.Creator, item.Author, fallbackAuthor),
+			Body:      item.Title + " " + stripHTML(item.Description),
+			CreatedAt: parseFeedDate(item.PubDate),
+			URL:       strings.TrimSpace(item.Link),
+			Kind:      "post",
+		})
+	}
+	for _, entry := range doc.Entries {
+		entries = append(entries, SourceItem{
+			ID:        firstNonEmpty(entry.ID, entry.link()),
+			Author:    firstNonEmpty(entry.Author, fallbackAuthor),
+			Body:      entry.Title + " " + stripHTML(firstNonEmpty(entry.Content, entry.Summary)),
+			CreatedAt: parseFeedDate(firstNonEmpty(entry.Published, entry.Updated)),
+			URL:       entry.link(),
+			Kind:      "post",
+		})
+	}
+
+	// Feeds are usually newest first, but nothing guarantees it
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+
+	var items []SourceItem
+	for _, entry := range entries {
+		if entry.ID == "" || entry.CreatedAt.IsZero() {
+			continue
+		}
+		if entry.CreatedAt.Before(cutoff) || since.reached(entry.ID, entry.CreatedAt) {
+			break
+		}
+		items = append(items, entry)
+	}
+
+	flog("%s: %d entries, %d new", f.url, len(entries), len(items))
+	if len(items) == 0 {
+		return nil, since, nil
+	}
+	return items, Checkpoint{ID: items[0].ID, CreatedAt: items[0].CreatedAt}, nil
+}
+
+// link prefers the alternate (HTML) link of an Atom entry.
+func (e atomEntry) link() string {
+	for _, l := range e.Links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return strings.TrimSpace(l.Href)
+		}
+	}
+	if len(e.Links) > 0 {
+		return strings.TrimSpace(e.Links[0].Href)
+	}
+	return ""
+}
+
+func parseFeedDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func stripHTML(s string) string {
+	return strings.TrimSpace(html.UnescapeString(htmlTagRegex.ReplaceAllString(s, " ")))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,252 @@
+package external_api
+
+import (
+	"sort"
+	"strings"
+)
+
+// nameDescriptors are words a company name is often written without, e.g.
+// "Palantir" for Palantir Technologies or "Ford" for Ford Motor. They are
+// stripped from the end of a normalized name as long as a word is left.
+var nameDescriptors = map[string]bool{
+	"technologies": true, "technology": true, "holdings": true, "holding": true,
+	"group": true, "platforms": true, "systems": true, "therapeutics": true,
+	"pharmaceuticals": true, "pharmaceutical": true, "motor": true, "motors": true,
+	"industries": true, "enterprises": true, "brands": true, "entertainment": true,
+	"software": true, "semiconductor": true, "semiconductors": true,
+	"communications": true, "networks": true, "bancorp": true, "financial": true,
+	"resources": true, "biosciences": true, "sciences": true, "labs": true,
+	"interactive": true, "solutions": true, "services": true, "worldwide": true,
+	"global": true, "markets": true, "international": true,
+}
+
+// commonNameWords are company names that are everyday words. Written on
+// their own they rarely mean the company, so they are not matched; the
+// symbol or an alias still finds it.
+var commonNameWords = map[string]bool{
+	"target": true, "block": true, "match": true, "snap": true, "rocket": true,
+	"unity": true, "genius": true, "progressive": true, "southern": true,
+	"dominion": true, "duke": true, "edison": true, "travelers": true,
+	"marathon": true, "carnival": true, "booking": true, "grab": true,
+	"affirm": true, "upstart": true, "riot": true, "steel": true, "coherent": true,
+	"elastic": true, "confluent": true, "workday": true, "cadence": true,
+	"toast": true, "discover": true, "ally": true, "citizens": true,
+	"regions": true, "popular": true, "lemonade": true, "root": true,
+	"hippo": true, "exact": true, "waters": true, "quest": true, "vertex": true,
+	"arch": true, "crown": true, "ball": true, "amber": true, "lumen": true,
+	"public": true, "realty": true, "energy": true, "bill": true,
+	"open": true, "live": true, "fresh": true, "home": true, "trust": true,
+}
+
+// minNameLength keeps short names like "ge" or "box" from matching inside
+// ordinary sentences.
+const minNameLength = 4
+
+// NameMatch is a company name or alias found in a text.
+type NameMatch struct {
+	Symbol     string
+	DetectedBy string // DetectedName or DetectedAlias
+}
+
+type matcherName struct {
+	text       string
+	symbol     string
+	detectedBy string
+}
+
+type acNode struct {
+	fail     int32
+	children []acEdge
+	outputs  []int32 // names ending here, including those of the fail chain
+}
+
+type acEdge struct {
+	b  byte
+	to int32
+}
+
+type acKey struct {
+	node int32
+	b    byte
+}
+
+// NameMatcher finds company names and aliases in text with an Aho–Corasick
+// automaton, so a text is scanned once however many names are indexed.
+type NameMatcher struct {
+	names []matcherName
+	nodes []acNode
+	edges map[acKey]int32
+}
+
+// NewNameMatcher indexes the companies (symbol to ticker_names.company_name)
+// by their normalized names, and the aliases (alias to symbol). When several
+// companies share a name, the shortest symbol wins (GOOG over GOOGL, a stock
+// over its warrants); aliases win over company names.
+func NewNameMatcher(companies map[string]string, aliases map[string]string) *NameMatcher {
+	symbols := make(map[string]string)
+	claim := func(name, symbol string) {
+		if current, ok := symbols[name]; ok {
+			if len(current) < len(symbol) || len(current) == len(symbol) && current < symbol {
+				return
+			}
+		}
+		symbols[name] = symbol
+	}
+	for symbol, companyName := range companies {
+		words := strings.Fields(NormalizeCompanyName(companyName))
+		if len(words) == 0 {
+			continue
+		}
+		if indexableName(words) {
+			claim(strings.Join(words, " "), symbol)
+		}
+		for len(words) > 1 && nameDescriptors[words[len(words)-1]] {
+			words = words[:len(words)-1]
+			if indexableName(words) {
+				claim(strings.Join(words, " "), symbol)
+			}
+		}
+	}
+
+	aliasSymbols := make(map[string]string, len(aliases))
+	for alias, symbol := range aliases {
+		if alias = NormalizeAlias(alias); alias != "" {
+			aliasSymbols[alias] = symbol
+		}
+	}
+
+	var names []matcherName
+	for name, symbol := range symbols {
+		if _, alias := aliasSymbols[name]; !alias {
+			names = append(names, matcherName{text: name, symbol: symbol, detectedBy: DetectedName})
+		}
+	}
+	for alias, symbol := range aliasSymbols {
+		names = append(names, matcherName{text: alias, symbol: symbol, detectedBy: DetectedAlias})
+	}
+	// Sorted so the automaton is the same for the same input
+	sort.Slice(names, func(i, j int) bool { return names[i].text < names[j].text })
+
+	m := &NameMatcher{
+		names: names,
+		nodes: []acNode{{}},
+		edges: make(map[acKey]int32),
+	}
+	for i, name := range names {
+		m.insert(name.text, int32(i))
+	}
+	m.link()
+	return m
+}
+
+// indexableName reports whether a normalized name is specific enough to be
+// matched in free text.
+func indexableName(words []string) bool {
+	if len(words) > 1 {
+		return true
+	}
+	word := words[0]
+	if len(word) < minNameLength || commonNameWords[word] || genericNameWords[word] {
+		return false
+	}
+	_, finance := financeWords[word]
+	return !finance
+}
+
+// Len is the number of names and aliases indexed.
+func (m *NameMatcher) Len() int {
+	return len(m.names)
+}
+
+// Find returns the names in text as whole words. Overlapping names resolve to
+// the leftmost, then longest one, so "bank of america" is not also "america".
+func (m *NameMatcher) Find(text string) []NameMatch {
+	normalized := NormalizeAlias(text)
+
+	type span struct {
+		start, end int
+		name       int32
+	}
+	var spans []span
+	state := int32(0)
+	for i := 0; i < len(normalized); i++ {
+		b := normalized[i]
+		next, ok := m.edges[acKey{state, b}]
+		for !ok && state != 0 {
+			state = m.nodes[state].fail
+			next, ok = m.edges[acKey{state, b}]
+		}
+		if ok {
+			state = next
+		}
+		end := i + 1
+		if end < len(normalized) && normalized[end] != ' ' {
+			continue
+		}
+		for _, name := range m.nodes[state].outputs {
+			start := end - len(m.names[name].text)
+			if start == 0 || normalized[start-1] == ' ' {
+				spans = append(spans, span{start: start, end: end, name: name})
+			}
+		}
+	}
+
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end > spans[j].end
+	})
+	var matches []NameMatch
+	covered := 0
+	for _, sp := range spans {
+		if sp.start < covered {
+			continue
+		}
+		name := m.names[sp.name]
+		matches = append(matches, NameMatch{Symbol: name.symbol, DetectedBy: name.detectedBy})
+		covered = sp.end
+	}
+	return matches
+}
+
+func (m *NameMatcher) insert(text string, name int32) {
+	node := int32(0)
+	for i := 0; i < len(text); i++ {
+		next, ok := m.edges[acKey{node, text[i]}]
+		if !ok {
+			next = int32(len(m.nodes))
+			m.nodes = append(m.nodes, acNode{})
+			m.edges[acKey{node, text[i]}] = next
+			m.nodes[node].children = append(m.nodes[node].children, acEdge{b: text[i], to: next})
+		}
+		node = next
+	}
+	m.nodes[node].outputs = append(m.nodes[node].outputs, name)
+}
+
+// link sets the fail links breadth first: a node's fail link is the longest
+// proper suffix of its path that is also a path from the root.
+func (m *NameMatcher) link() {
+	queue := make([]int32, 0, len(m.nodes))
+	for _, edge := range m.nodes[0].children {
+		queue = append(queue, edge.to)
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, edge := range m.nodes[node].children {
+			fail := m.nodes[node].fail
+			next, ok := m.edges[acKey{fail, edge.b}]
+			for !ok && fail != 0 {
+				fail = m.nodes[fail].fail
+				next, ok = m.edges[acKey{fail, edge.b}]
+			}
+			if ok {
+				m.nodes[edge.to].fail = next
+			}
+			m.nodes[edge.to].outputs = append(m.nodes[edge.to].outputs, m.nodes[m.nodes[edge.to].fail].outputs...)
+			queue = append(queue, edge.to)
+		}
+	}
+}
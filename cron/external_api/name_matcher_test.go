@@ -0,0 +1,59 @@
+package external_api
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testCompanies = map[string]string{
+	"BAC":   "Bank of America Corporation Common Stock",
+	"USA":   "America Holdings Inc.",
+	"PLTR":  "Palantir Technologies Inc. Class A Common Stock",
+	"GOOG":  "Alphabet Inc. Class C Capital Stock",
+	"GOOGL": "Alphabet Inc. Class A Common Stock",
+	"F":     "Ford Motor Company Common Stock",
+	"TGT":   "Target Corporation Common Stock",
+	"GE":    "GE Aerospace Common Stock",
+}
+
+var testAliases = map[string]string{
+	"Papa Musk": "TSLA",
+	"The Mouse": "DIS",
+	"Ford":      "F",
+}
+
+func TestNameMatcherFind(t *testing.T) {
+	m := NewNameMatcher(testCompanies, testAliases)
+
+	tests := []struct {
+		name string
+		text string
+		want []NameMatch
+	}{
+		{"full name", "Bought Bank of America today", []NameMatch{{"BAC", DetectedName}}},
+		{"name without descriptor", "Palantir to the moon", []NameMatch{{"PLTR", DetectedName}}},
+		{"shared name goes to the shortest symbol", "Alphabet beat earnings", []NameMatch{{"GOOG", DetectedName}}},
+		{"alias", "papa musk delivers", []NameMatch{{"TSLA", DetectedAlias}}},
+		{"alias wins over the company name", "sold my Ford", []NameMatch{{"F", DetectedAlias}}},
+		{"longest name at the same start", "Ford Motor guidance", []NameMatch{{"F", DetectedName}}},
+		{"leftmost longest hides the name inside", "bank of america", []NameMatch{{"BAC", DetectedName}}},
+		{"overlapping names in order", "bank of america and america holdings", []NameMatch{
+			{"BAC", DetectedName},
+			{"USA", DetectedName},
+		}},
+		{"punctuation between names", "Palantir, Alphabet.", []NameMatch{
+			{"PLTR", DetectedName},
+			{"GOOG", DetectedName},
+		}},
+		{"name inside a longer word", "Fordham grads love Palantirs", nil},
+		{"common word name", "Target is cheap", nil},
+		{"short name", "GE is up", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Find(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package external_api
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stuneak/sopeko/pkg/market"
+)
+
+// Option types, stored as mention_options.option_type.
+const (
+	OptionCall = "call"
+	OptionPut  = "put"
+)
+
+// OptionContract is an options position written in a comment, e.g. "AAPL 200c
+// 6/21" or "SPY 450p 0DTE".
+type OptionContract struct {
+	Type      string    // OptionCall or OptionPut
+	Strike    float64   // as stated, at the prices of the mention
+	ExpiresAt time.Time // close of the expiry session; zero when not given
+	Written   bool      // sold rather than bought, e.g. "sold 40p"
+
+	sentence int
+}
+
+// Bullish reports whether the position profits from the underlying rising:
+// a bought call or a written put.
+func (c OptionContract) Bullish() bool {
+	return (c.Type == OptionCall) != c.Written
+}
+
+var (
+	// "200c", "$450p", "2.5c"
+	compactOptionRegex = regexp.MustCompile(`(?i)(\$?)\b(\d+(?:\.\d+)?)(c|p)\b`)
+	// "200 calls", "$450 puts", "200 strike calls"
+	wordOptionRegex = regexp.MustCompile(`(?i)(\$?)\b(\d+(?:\.\d+)?)\s?(strike\s+)?(calls?|puts?)\b`)
+	// A ticker or an expiry right before the strike anchors "200 calls" as
+	// a contract rather than a count of them
+	optionAnchorRegex = regexp.MustCompile(`(?:\$[A-Za-z]{1,7}|\b[A-Z]{1,7}|\d{1,2}/\d{1,2}(?:/\d{2,4})?|(?i:\d{1,3}\s?dte))\s+$`)
+
+	slashExpiryRegex = regexp.MustCompile(`\b(\d{1,2})/(\d{1,2})(?:/(\d{4}|\d{2}))?\b`)
+	dteExpiryRegex   = regexp.MustCompile(`(?i)\b(\d{1,3})\s?dte\b`)
+	monthExpiryRegex = regexp.MustCompile(`(?i)\b(jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|sept?(?:ember)?|oct(?:ober)?|nov(?:ember)?|dec(?:ember)?)\s+('?\d{4}|'\d{2}|\d{1,2}(?:st|nd|rd|th)?)\b`)
+)
+
+// optionWriteWords before a contract in its sentence mean it was sold.
+var optionWriteWords = map[string]bool{
+	"sold": true, "selling": true, "sell": true, "wrote": true, "writing": true,
+	"write": true, "short": true, "shorted": true,
+}
+
+// ExtractOptions finds the option contracts in text. A compact strike like
+// "200c" needs an expiry in the same sentence, since "30c" is as often a
+// price in cents; "200 calls" needs a $, "strike", or a ticker or expiry
+// right before it, since "10 calls" is as often a number of contracts. The
+// expiry is read relative to postedAt and applies to every contract of its
+// sentence.
+func ExtractOptions(text string, postedAt time.Time) []OptionContract {
+	var contracts []OptionContract
+	for i, sentence := range sentenceRegex.FindAllString(text, -1) {
+		expiresAt, hasExpiry := parseExpiry(sentence, postedAt)
+
+		type strike struct {
+			start  int
+			value  float64
+			option string
+		}
+		var strikes []strike
+		seen := make(map[int]bool)
+		for _, m := range wordOptionRegex.FindAllStringSubmatchIndex(sentence, -1) {
+			dollar, named := m[3] > m[2], m[7] > m[6]
+			if !dollar && !named && !optionAnchorRegex.MatchString(sentence[:m[0]]) {
+				continue
+			}
+			value, _ := strconv.ParseFloat(sentence[m[4]:m[5]], 64)
+			strikes = append(strikes, strike{start: m[0], value: value, option: sentence[m[8]:m[9]]})
+			seen[m[0]] = true
+		}
+		if hasExpiry {
+			for _, m := range compactOptionRegex.FindAllStringSubmatchIndex(sentence, -1) {
+				if seen[m[0]] {
+					continue
+				}
+				value, _ := strconv.ParseFloat(sentence[m[4]:m[5]], 64)
+				strikes = append(strikes, strike{start: m[0], value: value, option: sentence[m[6]:m[7]]})
+			}
+		}
+
+		for _, s := range strikes {
+			if s.value <= 0 {
+				continue
+			}
+			contract := OptionContract{
+				Type:     OptionPut,
+				Strike:   s.value,
+				Written:  writtenBefore(sentence[:s.start]),
+				sentence: i,
+			}
+			if strings.HasPrefix(strings.ToLower(s.option), "c") {
+				contract.Type = OptionCall
+			}
+			if hasExpiry {
+				contract.ExpiresAt = expiresAt
+			}
+			contracts = append(contracts, contract)
+		}
+	}
+	return contracts
+}
+
+// writtenBefore reports whether the words leading up to a contract say it
+// was sold, unless a buy comes later ("sold my shares and bought 40c").
+func writtenBefore(s string) bool {
+	written := false
+	for _, word := range normalizeWords(s) {
+		switch {
+		case optionWriteWords[word]:
+			written = true
+		case word == "bought" || word == "buying" || word == "buy" || word == "long":
+			written = false
+		}
+	}
+	return written
+}
+
+// parseExpiry reads the first expiry in a sentence: "6/21", "6/21/26", "0DTE",
+// "1dte", "Jun 21", "Jan 2026" (the monthly, on the third Friday). Dates
+// without a year that have passed are next year's. Options expiring on a
+// holiday expire the trading day before, at its close.
+func parseExpiry(sentence string, postedAt time.Time) (time.Time, bool) {
+	day := market.Day(postedAt)
+	loc := day.Location()
+
+	var expiry time.Time
+	first := -1
+	consider := func(start int, t time.Time) {
+		if first < 0 || start < first {
+			expiry, first = t, start
+		}
+	}
+
+	if m := slashExpiryRegex.FindStringSubmatchIndex(sentence); m != nil {
+		month, _ := strconv.Atoi(sentence[m[2]:m[3]])
+		d, _ := strconv.Atoi(sentence[m[4]:m[5]])
+		year := -1
+		if m[6] >= 0 {
+			year, _ = strconv.Atoi(sentence[m[6]:m[7]])
+		}
+		if t, ok := expiryDate(day, year, month, d); ok {
+			consider(m[0], t)
+		}
+	}
+	if m := dteExpiryRegex.FindStringSubmatchIndex(sentence); m != nil {
+		n, _ := strconv.Atoi(sentence[m[2]:m[3]])
+		// 0DTE expires with the session running at the mention, or the next
+		base := postedAt
+		if n > 0 {
+			base = day.AddDate(0, 0, n)
+		}
+		consider(m[0], market.Day(market.NextClose(base)))
+	}
+	if m := monthExpiryRegex.FindStringSubmatchIndex(sentence); m != nil {
+		month := int(monthNames[strings.ToLower(sentence[m[2]:m[2]+3])])
+		rest := sentence[m[4]:m[5]]
+		if year, err := strconv.Atoi(strings.TrimPrefix(rest, "'")); err == nil && (len(rest) == 4 || rest[0] == '\'') {
+			// A month and year is that month's regular expiry
+			if year < 100 {
+				year += 2000
+			}
+			consider(m[0], thirdFriday(year, time.Month(month), loc))
+		} else {
+			d, _ := strconv.Atoi(strings.TrimRight(rest, "stndrh"))
+			if t, ok := expiryDate(day, -1, month, d); ok {
+				consider(m[0], t)
+			}
+		}
+	}
+
+	if first < 0 {
+		return time.Time{}, false
+	}
+	for !market.IsTradingDay(expiry) {
+		expiry = expiry.AddDate(0, 0, -1)
+	}
+	return market.SessionClose(expiry), true
+}
+
+// expiryDate is month/d of year, or of the first year it is not before day
+// when year is -1. Two-digit years are this century's.
+func expiryDate(day time.Time, year, month, d int) (time.Time, bool) {
+	if month < 1 || month > 12 || d < 1 || d > 31 {
+		return time.Time{}, false
+	}
+	switch {
+	case year < 0:
+		year = day.Year()
+		if time.Date(year, time.Month(month), d, 0, 0, 0, 0, day.Location()).Before(day) {
+			year++
+		}
+	case year < 100:
+		year += 2000
+	}
+	t := time.Date(year, time.Month(month), d, 0, 0, 0, 0, day.Location())
+	// 2/30 rolls over into March
+	if t.Day() != d {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func thirdFriday(year int, month time.Month, loc *time.Location) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	toFriday := (int(time.Friday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, toFriday+14)
+}
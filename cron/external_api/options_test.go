@@ -0,0 +1,94 @@
+package external_api
+
+import (
+	"testing"
+	"time"
+)
+
+func nyClose(year int, month time.Month, day, hour int) time.Time {
+	return time.Date(year, month, day, hour, 0, 0, 0, testNY)
+}
+
+func TestExtractOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []OptionContract
+	}{
+		{"compact call with date", "AAPL 200c 6/21", []OptionContract{
+			{Type: OptionCall, Strike: 200, ExpiresAt: nyClose(2024, time.June, 21, 16)},
+		}},
+		{"0DTE expires with the running session", "SPY 450p 0DTE", []OptionContract{
+			{Type: OptionPut, Strike: 450, ExpiresAt: nyClose(2024, time.June, 12, 16)},
+		}},
+		{"1dte", "NVDA 120 calls 1dte", []OptionContract{
+			{Type: OptionCall, Strike: 120, ExpiresAt: nyClose(2024, time.June, 13, 16)},
+		}},
+		{"month and year is the monthly", "SPY 500c Jan 2025", []OptionContract{
+			{Type: OptionCall, Strike: 500, ExpiresAt: nyClose(2025, time.January, 17, 16)},
+		}},
+		{"month and day", "SPY 500c Jun 28th", []OptionContract{
+			{Type: OptionCall, Strike: 500, ExpiresAt: nyClose(2024, time.June, 28, 16)},
+		}},
+		{"holiday expiry moves to the day before", "TSLA 250p 6/19", []OptionContract{
+			{Type: OptionPut, Strike: 250, ExpiresAt: nyClose(2024, time.June, 18, 16)},
+		}},
+		{"early close", "AAPL 200c 7/3", []OptionContract{
+			{Type: OptionCall, Strike: 200, ExpiresAt: nyClose(2024, time.July, 3, 13)},
+		}},
+		{"past date is next year's", "AAPL 200c 3/15", []OptionContract{
+			{Type: OptionCall, Strike: 200, ExpiresAt: nyClose(2025, time.March, 14, 16)},
+		}},
+		{"expiry applies to the whole sentence", "AAPL 200c 210c 6/21", []OptionContract{
+			{Type: OptionCall, Strike: 200, ExpiresAt: nyClose(2024, time.June, 21, 16)},
+			{Type: OptionCall, Strike: 210, ExpiresAt: nyClose(2024, time.June, 21, 16)},
+		}},
+		{"written", "sold 40p 6/21", []OptionContract{
+			{Type: OptionPut, Strike: 40, ExpiresAt: nyClose(2024, time.June, 21, 16), Written: true},
+		}},
+		{"bought after selling", "sold my shares and bought 40c 6/21", []OptionContract{
+			{Type: OptionCall, Strike: 40, ExpiresAt: nyClose(2024, time.June, 21, 16)},
+		}},
+		{"dollar strike without expiry", "$200 calls", []OptionContract{
+			{Type: OptionCall, Strike: 200},
+		}},
+		{"compact strike needs an expiry", "AAPL 200c", nil},
+		{"invalid date is no expiry", "AAPL 200c 2/30", nil},
+		{"number of contracts", "bought 10 calls", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractOptions(tt.text, testPostedAt)
+			if len(got) != len(tt.want) {
+				t.Fatalf("ExtractOptions(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+			for i := range got {
+				if got[i].Type != tt.want[i].Type ||
+					got[i].Strike != tt.want[i].Strike ||
+					got[i].Written != tt.want[i].Written ||
+					!got[i].ExpiresAt.Equal(tt.want[i].ExpiresAt) {
+					t.Errorf("ExtractOptions(%q)[%d] = %+v, want %+v", tt.text, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestOptionContractBullish(t *testing.T) {
+	tests := []struct {
+		option  string
+		written bool
+		want    bool
+	}{
+		{OptionCall, false, true},
+		{OptionCall, true, false},
+		{OptionPut, false, false},
+		{OptionPut, true, true},
+	}
+	for _, tt := range tests {
+		c := OptionContract{Type: tt.option, Written: tt.written}
+		if got := c.Bullish(); got != tt.want {
+			t.Errorf("%+v.Bullish() = %v, want %v", c, got, tt.want)
+		}
+	}
+}
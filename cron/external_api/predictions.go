@@ -0,0 +1,313 @@
+package external_api
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stuneak/sopeko/pkg/market"
+)
+
+// Prediction is a price target or multiple claimed in a comment, e.g. "PT $15
+// by Q3" or "this 10x by EOY", with the deadline it was given.
+type Prediction struct {
+	TargetPrice float64 // stated price, 0 for multiples
+	Multiple    float64 // "10x" is 10, 0 for stated prices
+	Deadline    time.Time
+	Claim       string // the sentence the prediction was read from
+
+	sentence int
+}
+
+// A prediction without a deadline is held to defaultPredictionHorizon.
+// Claims are cut to maxClaimLength characters.
+const (
+	defaultPredictionHorizon = 365 * 24 * time.Hour
+	maxClaimLength           = 200
+	maxPredictionMultiple    = 1000
+)
+
+var (
+	// "PT $15", "price target of 15", "target: $1.5k"
+	targetRegex = regexp.MustCompile(`(?i)\b(?:pt|price target|target|tp)\b\s*(?:of|is|at|:|=)?\s*(\$?)\s?(\d+(?:\.\d+)?)(k|%)?`)
+	// "going to $15", "hits $200", "see $1k"
+	moveToRegex = regexp.MustCompile(`(?i)\b(?:going|headed|heading|run|running|rip|ripping|moon|mooning|climb|climbing|back|hit|hits|hitting|reach|reaches|reaching|see|seeing|test|break|breaks)\s+(?:to\s+)?(\$)\s?(\d+(?:\.\d+)?)(k|%)?`)
+	// "$AMD to $300", "NVDA -> $200"
+	tickerToRegex = regexp.MustCompile(`(?:\$[A-Za-z]{1,7}|\b[A-Z]{1,7})\s+(?:to|->)\s+(\$)\s?(\d+(?:\.\d+)?)(k|%)?`)
+	// "$15 PT", "$15 by Q3"
+	priceFirstRegex = regexp.MustCompile(`(?i)(\$)\s?(\d+(?:\.\d+)?)(k|%)?\s*(?:pt\b|price target\b|target\b|by\b|before\b)`)
+	// "10x", "2.5x", "10 bagger"
+	multipleRegex = regexp.MustCompile(`(?i)\b(\d+(?:\.\d+)?)\s?(?:x|-?baggers?)\b('?)`)
+	// "doubles", "triple"
+	multipleWordRegex = regexp.MustCompile(`(?i)\b(doubles?|doubling|triples?|tripling)\b`)
+)
+
+// notMultiples are words after "10x" that make it a valuation or leverage
+// figure rather than a prediction, e.g. "20x earnings" or "3x leveraged".
+var notMultiples = map[string]bool{
+	"revenue": true, "revenues": true, "sales": true, "earnings": true, "ebitda": true,
+	"pe": true, "fcf": true, "book": true, "cash": true, "leverage": true,
+	"leveraged": true, "lev": true, "margin": true, "volume": true, "long": true,
+	"short": true, "bull": true, "bear": true, "etf": true, "multiple": true,
+	"oversubscribed": true,
+}
+
+var multipleWords = map[string]float64{
+	"double": 2, "doubles": 2, "doubling": 2,
+	"triple": 3, "triples": 3, "tripling": 3,
+}
+
+// ExtractPredictions finds the price targets and multiples claimed in text,
+// with the deadline given in the same sentence, read relative to postedAt.
+func ExtractPredictions(text string, postedAt time.Time) []Prediction {
+	var predictions []Prediction
+	for i, sentence := range sentenceRegex.FindAllString(text, -1) {
+		targets, multiples := sentenceTargets(sentence), sentenceMultiples(sentence)
+		if len(targets) == 0 && len(multiples) == 0 {
+			continue
+		}
+
+		deadline, ok := parseDeadline(sentence, postedAt)
+		if !ok {
+			deadline = postedAt.Add(defaultPredictionHorizon)
+		}
+		claim := claimText(sentence)
+		for _, target := range targets {
+			predictions = append(predictions, Prediction{TargetPrice: target, Deadline: deadline, Claim: claim, sentence: i})
+		}
+		for _, multiple := range multiples {
+			predictions = append(predictions, Prediction{Multiple: multiple, Deadline: deadline, Claim: claim, sentence: i})
+		}
+	}
+	return predictions
+}
+
+// sentenceTargets returns the distinct prices a sentence names as targets.
+// Percentages are moves rather than prices, and a bare number that reads as
+// a year ("target 2026") is not taken for one.
+func sentenceTargets(sentence string) []float64 {
+	var targets []float64
+	for _, re := range []*regexp.Regexp{targetRegex, moveToRegex, tickerToRegex, priceFirstRegex} {
+		for _, match := range re.FindAllStringSubmatch(sentence, -1) {
+			dollar, number, suffix := match[1] == "$", match[2], strings.ToLower(match[3])
+			if suffix == "%" {
+				continue
+			}
+			price, err := strconv.ParseFloat(number, 64)
+			if err != nil || price <= 0 {
+				continue
+			}
+			if !dollar && !strings.Contains(number, ".") && price >= 1990 && price <= 2100 {
+				continue
+			}
+			if suffix == "k" {
+				price *= 1000
+			}
+			if !containsFloat(targets, price) {
+				targets = append(targets, price)
+			}
+		}
+	}
+	return targets
+}
+
+// sentenceMultiples returns the distinct multiples a sentence predicts.
+func sentenceMultiples(sentence string) []float64 {
+	var multiples []float64
+	for _, loc := range multipleRegex.FindAllStringSubmatchIndex(sentence, -1) {
+		// "it 10x'd" is past, not a prediction
+		if loc[5] > loc[4] {
+			continue
+		}
+		if notMultiples[firstWord(sentence[loc[1]:])] {
+			continue
+		}
+		multiple, err := strconv.ParseFloat(sentence[loc[2]:loc[3]], 64)
+		if err != nil || multiple <= 1 || multiple > maxPredictionMultiple {
+			continue
+		}
+		if !containsFloat(multiples, multiple) {
+			multiples = append(multiples, multiple)
+		}
+	}
+	for _, word := range multipleWordRegex.FindAllString(sentence, -1) {
+		multiple := multipleWords[strings.ToLower(word)]
+		if !containsFloat(multiples, multiple) {
+			multiples = append(multiples, multiple)
+		}
+	}
+	return multiples
+}
+
+func firstWord(s string) string {
+	words := normalizeWords(s)
+	if len(words) == 0 {
+		return ""
+	}
+	return words[0]
+}
+
+func containsFloat(values []float64, v float64) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func claimText(sentence string) string {
+	claim := strings.Join(strings.Fields(sentence), " ")
+	if runes := []rune(claim); len(runes) > maxClaimLength {
+		claim = string(runes[:maxClaimLength-1]) + "…"
+	}
+	return claim
+}
+
+var (
+	quarterRegex  = regexp.MustCompile(`(?i)\bq([1-4])(?:\s*'?(\d{4}|\d{2}))?\b`)
+	periodRegex   = regexp.MustCompile(`(?i)\b(?:eo([ydwm])|end of (?:the )?(year|month|week|day))\b`)
+	nextRegex     = regexp.MustCompile(`(?i)\bnext\s+(week|month|quarter|year)\b`)
+	withinRegex   = regexp.MustCompile(`(?i)\b(?:in|within)\s+(\d+|an?|one|two|three|four|six|twelve)\s+(day|week|month|year)s?\b`)
+	weekdayRegex  = regexp.MustCompile(`(?i)\b(?:by|before|until|till|til)\s+(monday|tuesday|wednesday|thursday|friday)\b`)
+	monthRegex    = regexp.MustCompile(`(?i)\b(?:by|before|until|till|til|end of)\s+(jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|sept?(?:ember)?|oct(?:ober)?|nov(?:ember)?|dec(?:ember)?)(?:\s+(\d{4}))?\b`)
+	yearRegex     = regexp.MustCompile(`(?i)\b(?:by|before|until|till|til|in|end of)\s+(20\d{2})\b`)
+	tomorrowRegex = regexp.MustCompile(`(?i)\btomorrow\b`)
+)
+
+var countWords = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "six": 6, "twelve": 12,
+}
+
+var monthNames = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+	"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+	"sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday,
+}
+
+// parseDeadline reads the first deadline in a sentence, relative to postedAt.
+// A deadline is the end of the period it names, midnight New York time after
+// its last day: "Q3" is the end of September, "EOW" the end of the Friday.
+// Periods without a year that have already ended are taken to be next year's.
+func parseDeadline(sentence string, postedAt time.Time) (time.Time, bool) {
+	day := market.Day(postedAt)
+	loc := day.Location()
+	year, month, _ := day.Date()
+
+	var deadline time.Time
+	first := -1
+	try := func(re *regexp.Regexp, resolve func(match []string) (time.Time, bool)) {
+		index := re.FindStringSubmatchIndex(sentence)
+		if index == nil || first >= 0 && index[0] >= first {
+			return
+		}
+		match := make([]string, len(index)/2)
+		for i := range match {
+			if index[2*i] >= 0 {
+				match[i] = strings.ToLower(sentence[index[2*i]:index[2*i+1]])
+			}
+		}
+		if t, ok := resolve(match); ok {
+			deadline, first = t, index[0]
+		}
+	}
+
+	try(quarterRegex, func(m []string) (time.Time, bool) {
+		q, _ := strconv.Atoi(m[1])
+		end := time.Date(year, time.Month(3*q+1), 1, 0, 0, 0, 0, loc)
+		if m[2] != "" {
+			y, _ := strconv.Atoi(m[2])
+			if y < 100 {
+				y += 2000
+			}
+			return time.Date(y, time.Month(3*q+1), 1, 0, 0, 0, 0, loc), true
+		}
+		if !end.After(postedAt) {
+			end = end.AddDate(1, 0, 0)
+		}
+		return end, true
+	})
+	try(periodRegex, func(m []string) (time.Time, bool) {
+		period := m[2]
+		if period == "" {
+			period = map[string]string{"y": "year", "m": "month", "w": "week", "d": "day"}[m[1]]
+		}
+		return periodEnd(day, period, 0), true
+	})
+	try(nextRegex, func(m []string) (time.Time, bool) {
+		return periodEnd(day, m[1], 1), true
+	})
+	try(withinRegex, func(m []string) (time.Time, bool) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			n = countWords[m[1]]
+		}
+		if n <= 0 {
+			return time.Time{}, false
+		}
+		switch m[2] {
+		case "day":
+			return postedAt.AddDate(0, 0, n), true
+		case "week":
+			return postedAt.AddDate(0, 0, 7*n), true
+		case "month":
+			return postedAt.AddDate(0, n, 0), true
+		}
+		return postedAt.AddDate(n, 0, 0), true
+	})
+	try(weekdayRegex, func(m []string) (time.Time, bool) {
+		ahead := (int(weekdayNames[m[1]]) - int(day.Weekday()) + 7) % 7
+		return day.AddDate(0, 0, ahead+1), true
+	})
+	try(monthRegex, func(m []string) (time.Time, bool) {
+		named := monthNames[m[1][:3]]
+		y := year
+		if m[2] != "" {
+			y, _ = strconv.Atoi(m[2])
+		} else if named < month {
+			y++
+		}
+		return time.Date(y, named+1, 1, 0, 0, 0, 0, loc), true
+	})
+	try(yearRegex, func(m []string) (time.Time, bool) {
+		y, _ := strconv.Atoi(m[1])
+		if y < year {
+			return time.Time{}, false
+		}
+		return time.Date(y+1, time.January, 1, 0, 0, 0, 0, loc), true
+	})
+	try(tomorrowRegex, func(m []string) (time.Time, bool) {
+		return day.AddDate(0, 0, 2), true
+	})
+
+	return deadline, first >= 0
+}
+
+// periodEnd is the end of the day, week (its Friday), month, quarter or year
+// containing day, or of the one ahead periods later.
+func periodEnd(day time.Time, period string, ahead int) time.Time {
+	year, month, d := day.Date()
+	loc := day.Location()
+	switch period {
+	case "day":
+		return time.Date(year, month, d+1+ahead, 0, 0, 0, 0, loc)
+	case "week":
+		toSaturday := (int(time.Saturday) - int(day.Weekday()) + 7) % 7
+		if toSaturday == 0 {
+			toSaturday = 7
+		}
+		return time.Date(year, month, d+toSaturday+7*ahead, 0, 0, 0, 0, loc)
+	case "month":
+		return time.Date(year, month+1+time.Month(ahead), 1, 0, 0, 0, 0, loc)
+	case "quarter":
+		q := (int(month)-1)/3 + 1 + ahead
+		return time.Date(year, time.Month(3*q+1), 1, 0, 0, 0, 0, loc)
+	}
+	return time.Date(year+1+ahead, time.January, 1, 0, 0, 0, 0, loc)
+}
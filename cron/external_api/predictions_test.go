@@ -0,0 +1,105 @@
+package external_api
+
+import (
+	"testing"
+	"time"
+)
+
+var testNY = func() *time.Location {
+	loc, _ := time.LoadLocation("America/New_York")
+	return loc
+}()
+
+// testPostedAt is a Wednesday afternoon.
+var testPostedAt = time.Date(2024, time.June, 12, 15, 0, 0, 0, testNY)
+
+func nyMidnight(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, testNY)
+}
+
+func TestExtractPredictions(t *testing.T) {
+	defaultDeadline := testPostedAt.Add(defaultPredictionHorizon)
+
+	tests := []struct {
+		name string
+		text string
+		want []Prediction
+	}{
+		{"price target by quarter", "PT $15 by Q3", []Prediction{
+			{TargetPrice: 15, Deadline: nyMidnight(2024, time.October, 1)},
+		}},
+		{"multiple by end of year", "this 10x by EOY", []Prediction{
+			{Multiple: 10, Deadline: nyMidnight(2025, time.January, 1)},
+		}},
+		{"thousands suffix without deadline", "price target of $1.5k", []Prediction{
+			{TargetPrice: 1500, Deadline: defaultDeadline},
+		}},
+		{"ticker to price", "$AMD to $300 within two weeks", []Prediction{
+			{TargetPrice: 300, Deadline: testPostedAt.AddDate(0, 0, 14)},
+		}},
+		{"move to price by weekday", "hits $200 by Friday", []Prediction{
+			{TargetPrice: 200, Deadline: nyMidnight(2024, time.June, 15)},
+		}},
+		{"multiple word", "NVDA doubles next month", []Prediction{
+			{Multiple: 2, Deadline: nyMidnight(2024, time.August, 1)},
+		}},
+		{"targets share the first deadline", "PT $15 by Q3 and $20 by EOY", []Prediction{
+			{TargetPrice: 15, Deadline: nyMidnight(2024, time.October, 1)},
+			{TargetPrice: 20, Deadline: nyMidnight(2024, time.October, 1)},
+		}},
+		{"deadline from another sentence", "PT $15. Q3 earnings will be huge", []Prediction{
+			{TargetPrice: 15, Deadline: defaultDeadline},
+		}},
+		{"percentage is not a price", "PT 20% by Friday", nil},
+		{"year is not a price", "target 2026", nil},
+		{"valuation multiple", "trades at 20x earnings", nil},
+		{"past multiple", "it 10x'd last year", nil},
+		{"no prediction", "bought more NVDA today", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractPredictions(tt.text, testPostedAt)
+			if len(got) != len(tt.want) {
+				t.Fatalf("ExtractPredictions(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+			for i := range got {
+				if got[i].TargetPrice != tt.want[i].TargetPrice ||
+					got[i].Multiple != tt.want[i].Multiple ||
+					!got[i].Deadline.Equal(tt.want[i].Deadline) {
+					t.Errorf("ExtractPredictions(%q)[%d] = %+v, want %+v", tt.text, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseDeadline(t *testing.T) {
+	tests := []struct {
+		name     string
+		sentence string
+		want     time.Time
+		wantOK   bool
+	}{
+		{"ended quarter is next year's", "by Q1", nyMidnight(2025, time.April, 1), true},
+		{"quarter with short year", "Q3 '25", nyMidnight(2025, time.October, 1), true},
+		{"end of week is after friday", "EOW", nyMidnight(2024, time.June, 15), true},
+		{"end of the month", "end of the month", nyMidnight(2024, time.July, 1), true},
+		{"next quarter", "next quarter", nyMidnight(2024, time.October, 1), true},
+		{"ended month is next year's", "by March", nyMidnight(2025, time.April, 1), true},
+		{"month with year", "before december 2025", nyMidnight(2026, time.January, 1), true},
+		{"year", "by 2025", nyMidnight(2026, time.January, 1), true},
+		{"past year", "by 2023", time.Time{}, false},
+		{"relative days", "in 3 days", testPostedAt.AddDate(0, 0, 3), true},
+		{"tomorrow", "tomorrow", nyMidnight(2024, time.June, 14), true},
+		{"first deadline wins", "by 2025 or maybe EOM", nyMidnight(2026, time.January, 1), true},
+		{"no deadline", "soon", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseDeadline(tt.sentence, testPostedAt)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("parseDeadline(%q) = %s, %v, want %s, %v", tt.sentence, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package external_api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stuneak/sopeko/pkg/logger"
+)
+
+var plog = logger.NewLogger("PRICES")
+
+// ErrNoData means a provider works but has nothing for the symbol or date,
+// e.g. a delisted ticker. It does not count against the provider's health.
+var ErrNoData = errors.New("no data")
+
+// ErrNotSupported means a provider does not offer the operation at all.
+var ErrNotSupported = errors.New("not supported")
+
+// PriceProvider is a source of market data.
+type PriceProvider interface {
+	Name() string
+	// FetchCurrentPriceAndVolume returns the latest close, its volume and time.
+	FetchCurrentPriceAndVolume(ctx context.Context, symbol string) (price float64, volume int64, recordedAt time.Time, err error)
+	// FetchHistoricalPrice returns the last close at or before date.
+	FetchHistoricalPrice(ctx context.Context, symbol string, date time.Time) (price float64, volume int64, recordedAt time.Time, err error)
+	// FetchDailyBars returns the daily bars from from's to to's calendar date
+	// (both included), oldest first, split-adjusted as of today.
+	FetchDailyBars(ctx context.Context, symbol string, from, to time.Time) ([]DailyBar, error)
+	// FetchIntradayPrice returns the price at time at during a session, from
+	// the intraday bar containing it, split-adjusted as of today.
+	FetchIntradayPrice(ctx context.Context, symbol string, at time.Time) (price float64, recordedAt time.Time, err error)
+	// FetchSplits returns every known split of the symbol.
+	FetchSplits(ctx context.Context, symbol string) ([]SplitEvent, error)
+}
+
+// DailyBar is one trading day of OHLCV data. Date is midnight New York time,
+// OpenedAt and ClosedAt the start and end of the session.
+type DailyBar struct {
+	Date     time.Time
+	Open     float64
+	High     float64
+	Low      float64
+	Close    float64
+	Volume   int64
+	OpenedAt time.Time
+	ClosedAt time.Time
+}
+
+// A provider is taken out of rotation after providerFailureThreshold failures
+// in a row. The cooldown starts at providerCooldown and doubles every time it
+// trips again without a success in between, up to providerMaxCooldown.
+const (
+	providerFailureThreshold = 3
+	providerCooldown         = 5 * time.Minute
+	providerMaxCooldown      = time.Hour
+)
+
+// ProviderStatus is the health of one provider in a PriceChain.
+type ProviderStatus struct {
+	Name                string    `json:"name"`
+	Healthy             bool      `json:"healthy"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Successes           int64     `json:"successes"`
+	Failures            int64     `json:"failures"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastSuccess         time.Time `json:"last_success"`
+	LastFailure         time.Time `json:"last_failure"`
+	DisabledUntil       time.Time `json:"disabled_until"`
+}
+
+type providerState struct {
+	provider PriceProvider
+	status   ProviderStatus
+	cooldown time.Duration
+}
+
+// PriceChain is a PriceProvider that asks its providers in order and returns
+// the first answer. Providers that keep failing are skipped until their
+// cooldown has passed; when all of them are cooling down, all are tried
+// anyway so prices never stop completely.
+type PriceChain struct {
+	mu        sync.Mutex
+	providers []*providerState
+}
+
+func NewPriceChain(providers ...PriceProvider) *PriceChain {
+	c := &PriceChain{}
+	for _, p := range providers {
+		c.providers = append(c.providers, &providerState{
+			provider: p,
+			status:   ProviderStatus{Name: p.Name(), Healthy: true},
+			cooldown: providerCooldown,
+		})
+	}
+	return c
+}
+
+// NewPriceProviders builds providers from their names (yahoo, stooq, csv), in
+// the given order. csvDir is the directory of the csv provider.
+func NewPriceProviders(names []string, csvDir string) ([]PriceProvider, error) {
+	var providers []PriceProvider
+	for _, name := range names {
+		switch strings.ToLower(name) {
+		case "yahoo":
+			providers = append(providers, NewYahooFetcher())
+		case "stooq":
+			providers = append(providers, NewStooqProvider())
+		case "csv":
+			if csvDir == "" {
+				return nil, fmt.Errorf("price provider csv needs PRICE_CSV_DIR")
+			}
+			providers = append(providers, NewCSVPriceProvider(csvDir))
+		default:
+			return nil, fmt.Errorf("unknown price provider %q", name)
+		}
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no price providers configured")
+	}
+	return providers, nil
+}
+
+func (c *PriceChain) Name() string { return "chain" }
+
+func (c *PriceChain) FetchCurrentPriceAndVolume(ctx context.Context, symbol string) (price float64, volume int64, recordedAt time.Time, err error) {
+	err = c.try(ctx, func(p PriceProvider) error {
+		var err error
+		price, volume, recordedAt, err = p.FetchCurrentPriceAndVolume(ctx, symbol)
+		return err
+	})
+	return price, volume, recordedAt, err
+}
+
+func (c *PriceChain) FetchHistoricalPrice(ctx context.Context, symbol string, date time.Time) (price float64, volume int64, recordedAt time.Time, err error) {
+	err = c.try(ctx, func(p PriceProvider) error {
+		var err error
+		price, volume, recordedAt, err = p.FetchHistoricalPrice(ctx, symbol, date)
+		return err
+	})
+	return price, volume, recordedAt, err
+}
+
+func (c *PriceChain) FetchDailyBars(ctx context.Context, symbol string, from, to time.Time) (bars []DailyBar, err error) {
+	err = c.try(ctx, func(p PriceProvider) error {
+		var err error
+		bars, err = p.FetchDailyBars(ctx, symbol, from, to)
+		return err
+	})
+	return bars, err
+}
+
+func (c *PriceChain) FetchIntradayPrice(ctx context.Context, symbol string, at time.Time) (price float64, recordedAt time.Time, err error) {
+	err = c.try(ctx, func(p PriceProvider) error {
+		var err error
+		price, recordedAt, err = p.FetchIntradayPrice(ctx, symbol, at)
+		return err
+	})
+	return price, recordedAt, err
+}
+
+func (c *PriceChain) FetchSplits(ctx context.Context, symbol string) (splits []SplitEvent, err error) {
+	err = c.try(ctx, func(p PriceProvider) error {
+		var err error
+		splits, err = p.FetchSplits(ctx, symbol)
+		return err
+	})
+	return splits, err
+}
+
+// Status returns the health of every provider, in fallback order.
+func (c *PriceChain) Status() []ProviderStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	statuses := make([]ProviderStatus, 0, len(c.providers))
+	for _, state := range c.providers {
+		status := state.status
+		status.Healthy = !now.Before(status.DisabledUntil)
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func (c *PriceChain) try(ctx context.Context, fetch func(p PriceProvider) error) error {
+	candidates := c.available()
+
+	var errs []error
+	for _, state := range candidates {
+		err := fetch(state.provider)
+		if err == nil {
+			c.recordSuccess(state)
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", state.provider.Name(), err))
+		if !errors.Is(err, ErrNoData) && !errors.Is(err, ErrNotSupported) {
+			c.recordFailure(state, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// available returns the providers to ask, in order: those not cooling down,
+// or all of them when every provider is.
+func (c *PriceChain) available() []*providerState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	var healthy []*providerState
+	for _, state := range c.providers {
+		if !now.Before(state.status.DisabledUntil) {
+			healthy = append(healthy, state)
+		}
+	}
+	if len(healthy) == 0 {
+		return c.providers
+	}
+	return healthy
+}
+
+func (c *PriceChain) recordSuccess(state *providerState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !state.status.Healthy {
+		plog("%s recovered", state.provider.Name())
+	}
+	state.status.Healthy = true
+	state.status.ConsecutiveFailures = 0
+	state.status.Successes++
+	state.status.LastSuccess = time.Now()
+	state.status.DisabledUntil = time.Time{}
+	state.cooldown = providerCooldown
+}
+
+func (c *PriceChain) recordFailure(state *providerState, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	state.status.ConsecutiveFailures++
+	state.status.Failures++
+	state.status.LastError = err.Error()
+	state.status.LastFailure = now
+
+	if state.status.ConsecutiveFailures >= providerFailureThreshold && !now.Before(state.status.DisabledUntil) {
+		state.status.Healthy = false
+		state.status.DisabledUntil = now.Add(state.cooldown)
+		plog("%s failed %d times in a row, skipping it for %s: %v", state.provider.Name(), state.status.ConsecutiveFailures, state.cooldown, err)
+		state.cooldown = min(state.cooldown*2, providerMaxCooldown)
+	}
+}
@@ -4,20 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/stuneak/sopeko/pkg/logger"
+	"github.com/stuneak/sopeko/pkg/source"
 )
 
 var rlog = logger.NewLogger("REDDIT")
 
 const (
-	redditBaseURL = "https://www.reddit.com"
-	userAgent     = "Mozilla/5.0 (compatible; StockMentionBot/1.0)"
+	redditBaseURL         = "https://www.reddit.com"
+	redditUserAgent       = "server:sopeko:v1.0"
+	redditMaxListingPages = 10
 )
 
 var nyLoc = func() *time.Location {
@@ -25,74 +25,12 @@ var nyLoc = func() *time.Location {
 	return loc
 }()
 
-var skipList = []string{
-	"YMMV", "EPS", "TAKE", "MAY", "YOUVE", "ONLY", "LOST", "MONEY", "IF", "YOU",
-	"SELL", "YOUR", "USA", "AUS", "UK", "STOCK", "DUE", "FOMO", "SOB", "NO",
-	"ETF", "POS", "PENNY", "GTFOH", "NOT", "TOTAL", "DD", "YOLO", "WSB", "RH",
-	"FOR", "THE", "MOON", "BUY", "HOLD", "OP", "GATE", "KEEP", "EV", "TRYING",
-	"TWICE", "EVERY", "YET", "MOOOON", "THREE", "MEDUSA", "ANNUAL", "MOVERS",
-	"VOLUME", "MONDAY", "TUESDAY", "WEDNESDAY", "THURSDAY", "FRIDAY", "ALASKA",
-	"FIRE", "IMHO", "PTSD", "HUGE", "GENIUS", "SCAN", "BAG", "TICKER", "THIS",
-	"WEEK", "LETS", "GOOOO", "NASA", "STILL", "OKAY", "RIGHT", "LEMME", "THICC",
-	"BEFORE", "GLOBE", "EBITDA", "LMAO", "FAFO", "GET", "LEFT", "BEHIND", "CLASS",
-	"VERY", "ADVENT", "HEALTH", "BOYS", "WHICH", "ONE", "JONES", "SODA", "OTC",
-	"AND", "RSS", "MARKET", "OF", "SAME", "SUPER", "TOXIC", "ALSO", "NEOW",
-	"NASDAQ", "EUR", "USD", "US", "NVIDIA", "IIRC", "ALONE", "WHAT", "SAID",
-	"ABOVE", "ADVICE", "DYOR", "ALWAYS", "FOOD", "NYSE", "ISA", "SPX", "BUT",
-	"MAYBE", "CALLS", "DRILL", "BABY", "TRUMP", "SHIB", "WILL", "RIP", "EXCEPT",
-	"CRYPTO", "DOE", "MIT", "RSI", "DONT", "ENTIRE", "AI", "XYZ", "IS", "LOOK",
-	"AT", "IN", "AH", "TODAY", "TIKTOK", "TSA", "CEO", "FDA", "PDUFA", "CTRL",
-	"SWOT", "BS", "REAL", "BRUH", "CANADA", "LONG", "LOL", "WAY", "WTF", "PUMP",
-	"DUMP", "NEW", "FLAGS", "BOUGHT", "PEAK", "HOLDER", "EOY", "EOW", "IPO",
-	"URANUS", "LIGMA", "HELOC", "FY", "LUL", "IT", "PT", "DC", "RS", "LOT",
-	"ALT", "PE", "VC", "IBKR", "ATH", "IMO", "NDA", "RELIEF", "COVID", "YTD",
-	"MASH", "RUG", "PULL", "PS", "TN", "CUSIP", "FTD", "UCSF", "DO", "IDK",
-	"IP", "PR", "IR", "SOME", "GAP", "KEY", "FAST", "DAY", "ANY", "AM", "CALL",
-	"PUT", "EXP", "DNN", "MAG", "ARE", "WE", "MA", "MAN", "UP", "DOWN", "AX",
-	"LSE", "AMEX", "AMS", "NEVER", "EVER", "COULD", "BE", "NEXT", "IQ", "PB",
-	"API", "III", "II", "I", "GL", "ALL", "BIO", "LOW", "BEWARE", "HERE", "INFO",
-	"TOUR", "TOP", "BACK", "HOOD", "PD", "PM", "EST", "ICE", "MAGA", "TS", "SCI",
-	"DTE", "CC", "NOW", "GO", "EOD", "TACO", "EU", "IRS", "GOOD", "BAD", "LINK",
-	"DNA", "CPU", "GPU", "RAM", "SSD", "HDD", "CTO", "ASX", "ARR", "SO",
-}
-
-var tickersToSkip = func() map[string]struct{} {
-	m := make(map[string]struct{}, len(skipList))
-	for _, s := range skipList {
-		m[s] = struct{}{}
-	}
-	return m
-}()
-
-// tickerRegex matches uppercase words (2-7 chars) that could be tickers.
-// Also matches $TICKER format.
-var tickerRegex = regexp.MustCompile(`\$?([A-Z]{2,7})\b`)
-
-// ExtractTickers extracts potential ticker symbols from text,
-// filtering out common English words and abbreviations.
-func ExtractTickers(text string) []string {
-	matches := tickerRegex.FindAllStringSubmatch(text, -1)
-	seen := make(map[string]bool)
-	var tickers []string
-
-	for _, match := range matches {
-		if len(match) < 2 {
-			continue
-		}
-		ticker := strings.ToUpper(match[1])
-		_, skip := tickersToSkip[ticker]
-		if seen[ticker] || skip {
-			continue
-		}
-		seen[ticker] = true
-		tickers = append(tickers, ticker)
-	}
-
-	return tickers
-}
-
 type RedditScraper struct {
-	client *http.Client
+	client    *http.Client
+	baseURL   string
+	userAgent string
+	auth      *redditAuth
+	limiter   *rateLimiter
 }
 
 type RedditPost struct {
@@ -102,8 +40,10 @@ type RedditPost struct {
 	Selftext    string
 	CreatedAt   time.Time
 	URL         string
+	Permalink   string
 	Subreddit   string
 	NumComments int
+	Score       int
 }
 
 type RedditComment struct {
@@ -113,6 +53,9 @@ type RedditComment struct {
 	CreatedAt time.Time
 	PostID    string
 	ParentID  string
+	Permalink string
+	Subreddit string
+	Score     int
 }
 
 type redditPostData struct {
@@ -124,6 +67,7 @@ type redditPostData struct {
 	Permalink   string  `json:"permalink"`
 	Subreddit   string  `json:"subreddit"`
 	NumComments int     `json:"num_comments"`
+	Score       int     `json:"score"`
 }
 
 type redditListingResponse struct {
@@ -141,6 +85,10 @@ type redditCommentData struct {
 	Body       string          `json:"body"`
 	CreatedUTC float64         `json:"created_utc"`
 	ParentID   string          `json:"parent_id"`
+	LinkID     string          `json:"link_id"`
+	Permalink  string          `json:"permalink"`
+	Subreddit  string          `json:"subreddit"`
+	Score      int             `json:"score"`
 	Children   []string        `json:"children"`
 	Replies    json.RawMessage `json:"replies"`
 }
@@ -150,6 +98,13 @@ type redditCommentItem struct {
 	Data redditCommentData `json:"data"`
 }
 
+type redditCommentListingResponse struct {
+	Data struct {
+		Children []redditCommentItem `json:"children"`
+		After    string              `json:"after"`
+	} `json:"data"`
+}
+
 type redditCommentsResponse []struct {
 	Data struct {
 		Children []json.RawMessage `json:"children"`
@@ -164,33 +119,36 @@ type redditMoreChildrenResponse struct {
 	} `json:"json"`
 }
 
-func NewRedditScraper() *RedditScraper {
-	return &RedditScraper{
-		client: &http.Client{Timeout: 30 * time.Second},
-	}
-}
+func NewRedditScraper(cfg RedditConfig) *RedditScraper {
+	client := &http.Client{Timeout: 30 * time.Second}
 
-func (r *RedditScraper) makeRequest(ctx context.Context, url string) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, err
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = redditUserAgent
 	}
-	req.Header.Set("User-Agent", userAgent)
-
-	resp, err := r.client.Do(req)
-	if err != nil {
-		return nil, err
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = redditTokenURL
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == 429 {
-		return nil, fmt.Errorf("rate limited (429)")
+	r := &RedditScraper{
+		client:    client,
+		baseURL:   cfg.BaseURL,
+		userAgent: cfg.UserAgent,
+		limiter:   &rateLimiter{},
 	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("status %d", resp.StatusCode)
+
+	if cfg.ClientID != "" {
+		r.auth = &redditAuth{cfg: cfg, client: client}
+		if r.baseURL == "" {
+			r.baseURL = redditOAuthURL
+		}
+	} else {
+		rlog("no REDDIT_CLIENT_ID configured, using anonymous endpoints")
+		if r.baseURL == "" {
+			r.baseURL = redditBaseURL
+		}
 	}
 
-	return io.ReadAll(resp.Body)
+	return r
 }
 
 func (r *RedditScraper) requestWithRetry(ctx context.Context, url string, retries int) ([]byte, error) {
@@ -201,18 +159,21 @@ func (r *RedditScraper) requestWithRetry(ctx context.Context, url string, retrie
 			return body, nil
 		}
 		lastErr = err
-		time.Sleep(3 * time.Second)
+		if err := SleepContext(ctx, 3*time.Second); err != nil {
+			return nil, err
+		}
 	}
 	return nil, lastErr
 }
 
-func (r *RedditScraper) FetchSubredditPosts(ctx context.Context, subreddit string) ([]RedditPost, error) {
+// FetchSubredditPosts returns posts newer than the checkpoint, newest first,
+// never going back further than cutoff.
+func (r *RedditScraper) FetchSubredditPosts(ctx context.Context, subreddit string, since Checkpoint, cutoff time.Time) ([]RedditPost, error) {
 	var posts []RedditPost
-	cutoff := time.Now().Add(-24 * time.Hour)
 	after := ""
 
-	for {
-		url := fmt.Sprintf("%s/r/%s/new.json?limit=100", redditBaseURL, subreddit)
+	for page := 0; page < redditMaxListingPages; page++ {
+		url := fmt.Sprintf("%s/r/%s/new.json?limit=100", r.baseURL, subreddit)
 		if after != "" {
 			url += "&after=" + after
 		}
@@ -230,7 +191,7 @@ func (r *RedditScraper) FetchSubredditPosts(ctx context.Context, subreddit strin
 		done := false
 		for _, c := range resp.Data.Children {
 			t := time.Unix(int64(c.Data.CreatedUTC), 0).In(nyLoc)
-			if t.Before(cutoff) {
+			if t.Before(cutoff) || since.reached("t3_"+c.Data.ID, t) {
 				done = true
 				break
 			}
@@ -240,9 +201,11 @@ func (r *RedditScraper) FetchSubredditPosts(ctx context.Context, subreddit strin
 				Author:      c.Data.Author,
 				Selftext:    c.Data.Selftext,
 				CreatedAt:   t,
-				URL:         redditBaseURL + c.Data.Permalink,
+				URL:         redditPermalinkURL(c.Data.Permalink),
+				Permalink:   c.Data.Permalink,
 				Subreddit:   c.Data.Subreddit,
 				NumComments: c.Data.NumComments,
+				Score:       c.Data.Score,
 			})
 		}
 
@@ -250,12 +213,154 @@ func (r *RedditScraper) FetchSubredditPosts(ctx context.Context, subreddit strin
 			break
 		}
 		after = resp.Data.After
-		time.Sleep(3 * time.Second)
 	}
 
 	return posts, nil
 }
 
+// FetchSubredditComments returns comments across all threads of a subreddit
+// that are newer than the checkpoint, newest first, never going back further
+// than cutoff. Reddit serves at most ~1000 items per listing, so very busy
+// subreddits still need the per-thread revisits.
+func (r *RedditScraper) FetchSubredditComments(ctx context.Context, subreddit string, since Checkpoint, cutoff time.Time) ([]RedditComment, error) {
+	var comments []RedditComment
+	after := ""
+
+	for page := 0; page < redditMaxListingPages; page++ {
+		url := fmt.Sprintf("%s/r/%s/comments.json?limit=100", r.baseURL, subreddit)
+		if after != "" {
+			url += "&after=" + after
+		}
+
+		body, err := r.makeRequest(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+
+		var resp redditCommentListingResponse
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, err
+		}
+
+		done := false
+		for _, item := range resp.Data.Children {
+			if item.Kind != "t1" {
+				continue
+			}
+			t := time.Unix(int64(item.Data.CreatedUTC), 0)
+			if t.Before(cutoff) || since.reached("t1_"+item.Data.ID, t) {
+				done = true
+				break
+			}
+			comments = append(comments, RedditComment{
+				ID:        item.Data.ID,
+				Author:    item.Data.Author,
+				Body:      item.Data.Body,
+				CreatedAt: t,
+				PostID:    strings.TrimPrefix(item.Data.LinkID, "t3_"),
+				ParentID:  item.Data.ParentID,
+				Permalink: item.Data.Permalink,
+				Subreddit: item.Data.Subreddit,
+				Score:     item.Data.Score,
+			})
+		}
+
+		if done || resp.Data.After == "" {
+			break
+		}
+		after = resp.Data.After
+	}
+
+	return comments, nil
+}
+
+// Posts is the Source for new posts of a subreddit.
+func (r *RedditScraper) Posts(subreddit string) Source {
+	return redditPostSource{scraper: r, subreddit: subreddit}
+}
+
+// Comments is the Source for new comments across all threads of a subreddit.
+func (r *RedditScraper) Comments(subreddit string) Source {
+	return redditCommentSource{scraper: r, subreddit: subreddit}
+}
+
+type redditPostSource struct {
+	scraper   *RedditScraper
+	subreddit string
+}
+
+func (s redditPostSource) Name() string { return source.Reddit }
+
+func (s redditPostSource) Fetch(ctx context.Context, since Checkpoint, cutoff time.Time) ([]SourceItem, Checkpoint, error) {
+	posts, err := s.scraper.FetchSubredditPosts(ctx, s.subreddit, since, cutoff)
+	if err != nil || len(posts) == 0 {
+		return nil, since, err
+	}
+
+	items := make([]SourceItem, 0, len(posts))
+	for _, p := range posts {
+		items = append(items, p.SourceItem())
+	}
+	return items, Checkpoint{ID: "t3_" + posts[0].ID, CreatedAt: posts[0].CreatedAt}, nil
+}
+
+type redditCommentSource struct {
+	scraper   *RedditScraper
+	subreddit string
+}
+
+func (s redditCommentSource) Name() string { return source.Reddit }
+
+func (s redditCommentSource) Fetch(ctx context.Context, since Checkpoint, cutoff time.Time) ([]SourceItem, Checkpoint, error) {
+	comments, err := s.scraper.FetchSubredditComments(ctx, s.subreddit, since, cutoff)
+	if err != nil || len(comments) == 0 {
+		return nil, since, err
+	}
+
+	items := make([]SourceItem, 0, len(comments))
+	for _, c := range comments {
+		items = append(items, c.SourceItem())
+	}
+	return items, Checkpoint{ID: "t1_" + comments[0].ID, CreatedAt: comments[0].CreatedAt}, nil
+}
+
+// SourceItem normalizes a post; the title and text are scanned together.
+func (p RedditPost) SourceItem() SourceItem {
+	return SourceItem{
+		ID:        p.ID,
+		Author:    p.Author,
+		Body:      p.Title + " " + p.Selftext,
+		CreatedAt: p.CreatedAt,
+		URL:       p.URL,
+		Kind:      "post",
+		Subreddit: p.Subreddit,
+		PostID:    p.ID,
+		Score:     p.Score,
+	}
+}
+
+func (c RedditComment) SourceItem() SourceItem {
+	return SourceItem{
+		ID:        c.ID,
+		Author:    c.Author,
+		Body:      c.Body,
+		CreatedAt: c.CreatedAt,
+		URL:       redditPermalinkURL(c.Permalink),
+		Kind:      "comment",
+		Subreddit: c.Subreddit,
+		PostID:    c.PostID,
+		ParentID:  c.ParentID,
+		Score:     c.Score,
+	}
+}
+
+func redditPermalinkURL(permalink string) string {
+	if permalink == "" {
+		return ""
+	}
+	return redditBaseURL + permalink
+}
+
 func (r *RedditScraper) FetchPostComments(ctx context.Context, subreddit, postID string) ([]RedditComment, error) {
 	rlog("fetching comments subreddit=%s postID=%s", subreddit, postID)
 
@@ -264,12 +369,12 @@ func (r *RedditScraper) FetchPostComments(ctx context.Context, subreddit, postID
 
 	for _, sort := range []string{"new"} {
 		url := fmt.Sprintf("%s/r/%s/comments/%s.json?limit=500&depth=100&sort=%s",
-			redditBaseURL, subreddit, postID, sort)
+			r.baseURL, subreddit, postID, sort)
 
 		body, err := r.makeRequest(ctx, url)
 		if err != nil {
 			rlog("request failed for postID=%s sort=%s: %v", postID, sort, err)
-			continue
+			return nil, err
 		}
 
 		newComments, moreIDs := r.parseCommentsResponse(body, postID)
@@ -287,8 +392,6 @@ func (r *RedditScraper) FetchPostComments(ctx context.Context, subreddit, postID
 			rlog("fetched %d more children for postID=%s", len(more), postID)
 			comments = append(comments, more...)
 		}
-
-		time.Sleep(1 * time.Second)
 	}
 
 	rlog("done postID=%s total_comments=%d", postID, len(comments))
@@ -330,6 +433,9 @@ func (r *RedditScraper) parseChildren(children []json.RawMessage, postID string)
 			CreatedAt: time.Unix(int64(item.Data.CreatedUTC), 0),
 			PostID:    postID,
 			ParentID:  item.Data.ParentID,
+			Permalink: item.Data.Permalink,
+			Subreddit: item.Data.Subreddit,
+			Score:     item.Data.Score,
 		})
 
 		if len(item.Data.Replies) > 0 && string(item.Data.Replies) != `""` {
@@ -370,7 +476,7 @@ func (r *RedditScraper) fetchMoreChildren(ctx context.Context, postID string, id
 		}
 
 		url := fmt.Sprintf("%s/api/morechildren.json?api_type=json&link_id=t3_%s&children=%s&limit_children=false",
-			redditBaseURL, postID, strings.Join(batch, ","))
+			r.baseURL, postID, strings.Join(batch, ","))
 
 		body, err := r.requestWithRetry(ctx, url, 3)
 		if err != nil {
@@ -398,39 +504,13 @@ func (r *RedditScraper) fetchMoreChildren(ctx context.Context, postID string, id
 					CreatedAt: time.Unix(int64(thing.Data.CreatedUTC), 0),
 					PostID:    postID,
 					ParentID:  thing.Data.ParentID,
+					Permalink: thing.Data.Permalink,
+					Subreddit: thing.Data.Subreddit,
+					Score:     thing.Data.Score,
 				})
 			}
 		}
-
-		if len(pending) > 0 {
-			time.Sleep(2 * time.Second)
-		}
 	}
 
 	return comments, nil
 }
-
-func (r *RedditScraper) ScrapeSubreddit(ctx context.Context, subreddit string) ([]RedditPost, []RedditComment, error) {
-	posts, err := r.FetchSubredditPosts(ctx, subreddit)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	var allComments []RedditComment
-
-	for _, post := range posts {
-		if post.NumComments == 0 {
-			continue
-		}
-
-		comments, err := r.FetchPostComments(ctx, subreddit, post.ID)
-		if err != nil {
-			continue
-		}
-
-		allComments = append(allComments, comments...)
-		time.Sleep(2 * time.Second)
-	}
-
-	return posts, allComments, nil
-}
@@ -0,0 +1,163 @@
+package external_api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// zstdCommand decompresses .zst dumps. The monthly Reddit dumps are written
+// with a long matching window, hence --long=31.
+var zstdCommand = []string{"zstd", "-dc", "--long=31"}
+
+// redditArchiveData covers the fields shared by the RS_ (submissions) and
+// RC_ (comments) dumps. Older dumps store created_utc as a string, which
+// json.Number accepts as well.
+type redditArchiveData struct {
+	ID         string          `json:"id"`
+	Author     string          `json:"author"`
+	Subreddit  string          `json:"subreddit"`
+	Title      *string         `json:"title"`
+	Selftext   string          `json:"selftext"`
+	Body       string          `json:"body"`
+	CreatedUTC json.Number     `json:"created_utc"`
+	Permalink  string          `json:"permalink"`
+	LinkID     string          `json:"link_id"`
+	ParentID   string          `json:"parent_id"`
+	Score      json.RawMessage `json:"score"`
+}
+
+// ReadRedditArchive streams a Reddit NDJSON dump, calling fn for every post
+// or comment after the first skip lines. Files ending in .zst are
+// decompressed through the zstd binary, anything else is read as plain
+// NDJSON. line is the 1-based line number of the item, so passing it back as
+// skip resumes after it. Lines that cannot be parsed are logged and skipped.
+func ReadRedditArchive(ctx context.Context, path string, skip int64, fn func(line int64, item SourceItem) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var input io.Reader = file
+	var cmd *exec.Cmd
+	if strings.HasSuffix(path, ".zst") {
+		cmd = exec.CommandContext(ctx, zstdCommand[0], zstdCommand[1:]...)
+		cmd.Stdin = file
+		cmd.Stderr = os.Stderr
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return err
+		}
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("start zstd (is it installed?): %w", err)
+		}
+		input = stdout
+	}
+
+	readErr := readArchiveLines(ctx, input, skip, fn)
+
+	if cmd != nil {
+		if readErr != nil {
+			// Stop the decompressor instead of letting it block on a full pipe
+			cmd.Process.Kill()
+			cmd.Wait()
+			return readErr
+		}
+		if err := cmd.Wait(); err != nil {
+			return fmt.Errorf("zstd: %w", err)
+		}
+	}
+	return readErr
+}
+
+func readArchiveLines(ctx context.Context, input io.Reader, skip int64, fn func(line int64, item SourceItem) error) error {
+	// Single comments can run past bufio.Scanner's token limit, so read whole lines
+	reader := bufio.NewReaderSize(input, 1<<20)
+
+	var line int64
+	for {
+		raw, err := reader.ReadBytes('\n')
+		if len(raw) > 0 {
+			line++
+			if line > skip {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				item, parseErr := parseArchiveLine(raw)
+				if parseErr != nil {
+					rlog("archive line %d: %v, skipping", line, parseErr)
+				} else if err := fn(line, item); err != nil {
+					return err
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func parseArchiveLine(raw []byte) (SourceItem, error) {
+	raw = bytes.TrimSpace(raw)
+	var data redditArchiveData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return SourceItem{}, err
+	}
+
+	created, err := data.CreatedUTC.Float64()
+	if err != nil {
+		return SourceItem{}, fmt.Errorf("created_utc: %w", err)
+	}
+
+	// Scores are integers, but some dumps have them as strings or null
+	var score float64
+	if err := json.Unmarshal(data.Score, &score); err != nil {
+		var s json.Number
+		if json.Unmarshal(data.Score, &s) == nil {
+			score, _ = s.Float64()
+		}
+	}
+
+	if data.Title != nil {
+		return RedditPost{
+			ID:        data.ID,
+			Title:     *data.Title,
+			Author:    data.Author,
+			Selftext:  data.Selftext,
+			CreatedAt: time.Unix(int64(created), 0).In(nyLoc),
+			URL:       redditPermalinkURL(data.Permalink),
+			Permalink: data.Permalink,
+			Subreddit: data.Subreddit,
+			Score:     int(score),
+		}.SourceItem(), nil
+	}
+
+	comment := RedditComment{
+		ID:        data.ID,
+		Author:    data.Author,
+		Body:      data.Body,
+		CreatedAt: time.Unix(int64(created), 0),
+		PostID:    strings.TrimPrefix(data.LinkID, "t3_"),
+		ParentID:  data.ParentID,
+		Permalink: data.Permalink,
+		Subreddit: data.Subreddit,
+		Score:     int(score),
+	}
+	if comment.Permalink == "" && comment.PostID != "" {
+		// Older comment dumps have no permalink, but Reddit resolves this form
+		comment.Permalink = fmt.Sprintf("/r/%s/comments/%s/_/%s/", data.Subreddit, comment.PostID, data.ID)
+	}
+	return comment.SourceItem(), nil
+}
@@ -0,0 +1,256 @@
+package external_api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	redditOAuthURL     = "https://oauth.reddit.com"
+	redditTokenURL     = "https://www.reddit.com/api/v1/access_token"
+	redditMaxAttempts  = 4
+	defaultRetryAfter  = 10 * time.Second
+	tokenRefreshMargin = time.Minute
+)
+
+// RedditConfig holds the credentials and endpoints for the Reddit API.
+//
+// With a client ID the scraper uses OAuth2 against oauth.reddit.com: the
+// password grant when Username is set (script apps), client credentials
+// otherwise (app-only). Without one it falls back to the anonymous JSON
+// endpoints on www.reddit.com. BaseURL and TokenURL can point at a local fake
+// server in tests.
+type RedditConfig struct {
+	ClientID     string
+	ClientSecret string
+	Username     string
+	Password     string
+	UserAgent    string
+	BaseURL      string
+	TokenURL     string
+}
+
+// redditAuth fetches and caches OAuth access tokens.
+type redditAuth struct {
+	cfg    RedditConfig
+	client *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+type redditTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Error       string `json:"error"`
+}
+
+// Token returns a valid access token, requesting a new one when the cached
+// token is missing or about to expire.
+func (a *redditAuth) Token(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && time.Now().Add(tokenRefreshMargin).Before(a.expires) {
+		return a.token, nil
+	}
+
+	form := url.Values{}
+	if a.cfg.Username != "" {
+		form.Set("grant_type", "password")
+		form.Set("username", a.cfg.Username)
+		form.Set("password", a.cfg.Password)
+	} else {
+		form.Set("grant_type", "client_credentials")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", a.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(a.cfg.ClientID, a.cfg.ClientSecret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", a.cfg.UserAgent)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request: status %d", resp.StatusCode)
+	}
+
+	var token redditTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	if token.Error != "" {
+		return "", fmt.Errorf("token request: %s", token.Error)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("token request: empty access token")
+	}
+
+	a.token = token.AccessToken
+	a.expires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	rlog("obtained access token, expires in %ds", token.ExpiresIn)
+	return a.token, nil
+}
+
+// Invalidate drops the cached token so the next request fetches a new one.
+func (a *redditAuth) Invalidate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = ""
+}
+
+// rateLimiter paces requests using the X-Ratelimit-* headers Reddit sends
+// with every response: the remaining requests are spread evenly over the time
+// left until the window resets.
+type rateLimiter struct {
+	mu        sync.Mutex
+	remaining float64
+	reset     time.Time
+	next      time.Time
+	known     bool
+}
+
+// Wait blocks until the next request may be sent.
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	var wait time.Duration
+	if l.next.After(now) {
+		wait = l.next.Sub(now)
+	}
+	if l.known && l.reset.After(now) {
+		untilReset := l.reset.Sub(now)
+		if l.remaining < 1 {
+			wait = max(wait, untilReset)
+		} else {
+			wait = max(wait, time.Duration(float64(untilReset)/l.remaining))
+		}
+	}
+	l.mu.Unlock()
+
+	if wait > 0 {
+		rlog("rate limit: waiting %s", wait.Round(time.Millisecond))
+	}
+	return SleepContext(ctx, wait)
+}
+
+// Update records the rate limit headers of a response.
+func (l *rateLimiter) Update(h http.Header) {
+	remaining, err1 := strconv.ParseFloat(h.Get("X-Ratelimit-Remaining"), 64)
+	reset, err2 := strconv.ParseFloat(h.Get("X-Ratelimit-Reset"), 64)
+	if err1 != nil || err2 != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.remaining = remaining
+	l.reset = time.Now().Add(time.Duration(reset * float64(time.Second)))
+	l.known = true
+}
+
+// Block holds back every request until the given time, e.g. after a 429.
+func (l *rateLimiter) Block(until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until.After(l.next) {
+		l.next = until
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an
+// HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return defaultRetryAfter
+	}
+	if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return defaultRetryAfter
+}
+
+// SleepContext waits for d, returning early with the context's error when ctx
+// is done first.
+func SleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (r *RedditScraper) makeRequest(ctx context.Context, url string) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		if err := r.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", r.userAgent)
+
+		if r.auth != nil {
+			token, err := r.auth.Token(ctx)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Authorization", "bearer "+token)
+		}
+
+		resp, err := r.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		r.limiter.Update(resp.Header)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			return body, nil
+		case resp.StatusCode == http.StatusUnauthorized && r.auth != nil && attempt < redditMaxAttempts:
+			rlog("access token rejected, refreshing")
+			r.auth.Invalidate()
+		case resp.StatusCode == http.StatusTooManyRequests && attempt < redditMaxAttempts:
+			retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			rlog("rate limited (429), retrying in %s", retryAfter)
+			r.limiter.Block(time.Now().Add(retryAfter))
+		case resp.StatusCode == http.StatusTooManyRequests:
+			return nil, fmt.Errorf("rate limited (429)")
+		default:
+			return nil, fmt.Errorf("status %d", resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package external_api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeReddit is a local stand-in for oauth.reddit.com and its token endpoint.
+// Each listing request is answered by the next of responses, then with 200.
+type fakeReddit struct {
+	t         *testing.T
+	responses []func(w http.ResponseWriter)
+
+	mu          sync.Mutex
+	tokens      int
+	listings    []time.Time
+	authHeaders []string
+}
+
+func (f *fakeReddit) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/api/v1/access_token":
+		id, secret, ok := r.BasicAuth()
+		if r.Method != http.MethodPost || !ok || id != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+			f.t.Errorf("unexpected token request form %v", r.PostForm)
+		}
+		f.tokens++
+		fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "bearer", "expires_in": 3600}`, f.tokens)
+
+	case "/r/stocks/new.json":
+		f.listings = append(f.listings, time.Now())
+		f.authHeaders = append(f.authHeaders, r.Header.Get("Authorization"))
+		if n := len(f.listings); n <= len(f.responses) {
+			f.responses[n-1](w)
+			return
+		}
+		fmt.Fprint(w, `{"data": {"children": [{"data": {"id": "abc", "title": "NVDA", "author": "someone", "created_utc": 1718000000, "permalink": "/r/stocks/comments/abc/nvda/", "subreddit": "stocks"}}], "after": ""}}`)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newFakeReddit(t *testing.T, responses ...func(w http.ResponseWriter)) (*fakeReddit, *RedditScraper) {
+	fake := &fakeReddit{t: t, responses: responses}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	scraper := NewRedditScraper(RedditConfig{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		BaseURL:      server.URL,
+		TokenURL:     server.URL + "/api/v1/access_token",
+	})
+	return fake, scraper
+}
+
+func fetchPosts(t *testing.T, scraper *RedditScraper) []RedditPost {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	posts, err := scraper.FetchSubredditPosts(ctx, "stocks", Checkpoint{}, time.Time{})
+	if err != nil {
+		t.Fatalf("FetchSubredditPosts: %v", err)
+	}
+	return posts
+}
+
+func TestRedditFetchesAndReusesToken(t *testing.T) {
+	fake, scraper := newFakeReddit(t)
+
+	posts := fetchPosts(t, scraper)
+	fetchPosts(t, scraper)
+
+	if len(posts) != 1 || posts[0].ID != "abc" || posts[0].Subreddit != "stocks" {
+		t.Errorf("got posts %+v", posts)
+	}
+	if fake.tokens != 1 {
+		t.Errorf("requested %d tokens, want 1 for both listings", fake.tokens)
+	}
+	for _, header := range fake.authHeaders {
+		if header != "bearer token-1" {
+			t.Errorf("Authorization = %q, want bearer token-1", header)
+		}
+	}
+}
+
+func TestRedditPacesByRateLimitHeaders(t *testing.T) {
+	// 2 requests left in the next 0.6s: the second waits ~0.3s
+	fake, scraper := newFakeReddit(t, func(w http.ResponseWriter) {
+		w.Header().Set("X-Ratelimit-Remaining", "2")
+		w.Header().Set("X-Ratelimit-Reset", "0.6")
+		fmt.Fprint(w, `{"data": {"children": [], "after": ""}}`)
+	})
+
+	fetchPosts(t, scraper)
+	fetchPosts(t, scraper)
+
+	if len(fake.listings) != 2 {
+		t.Fatalf("got %d listing requests, want 2", len(fake.listings))
+	}
+	if gap := fake.listings[1].Sub(fake.listings[0]); gap < 250*time.Millisecond {
+		t.Errorf("second request after %s, want it paced to ~300ms", gap)
+	}
+}
+
+func TestRedditRetriesAfter429(t *testing.T) {
+	fake, scraper := newFakeReddit(t, func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	posts := fetchPosts(t, scraper)
+
+	if len(posts) != 1 {
+		t.Errorf("got %d posts, want the listing of the retry", len(posts))
+	}
+	if len(fake.listings) != 2 {
+		t.Fatalf("got %d listing requests, want 2", len(fake.listings))
+	}
+	if gap := fake.listings[1].Sub(fake.listings[0]); gap < 900*time.Millisecond {
+		t.Errorf("retried after %s, want Retry-After's 1s", gap)
+	}
+}
+
+func TestRedditFetchPostCommentsReturnsRequestErrors(t *testing.T) {
+	// The fake serves no comment trees, so the request fails with a 404
+	_, scraper := newFakeReddit(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	comments, err := scraper.FetchPostComments(ctx, "stocks", "abc")
+	if err == nil {
+		t.Errorf("got %d comments and no error, want the failed request's error", len(comments))
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", defaultRetryAfter},
+		{"30", 30 * time.Second},
+		{now.Add(time.Minute).Format(http.TimeFormat), time.Minute},
+		{"soon", defaultRetryAfter},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package external_api
+
+import (
+	"context"
+	"time"
+)
+
+// Source is a feed of posts or comments for the mention pipeline.
+type Source interface {
+	// Name is stored in comments.source and namespaces usernames, see
+	// pkg/source.
+	Name() string
+	// Fetch returns the items newer than since, newest first, going back no
+	// further than cutoff, along with the checkpoint to save once they have
+	// been processed. Without new items the checkpoint is since.
+	Fetch(ctx context.Context, since Checkpoint, cutoff time.Time) ([]SourceItem, Checkpoint, error)
+}
+
+// SourceItem is a post or comment normalized across sources.
+type SourceItem struct {
+	ID        string
+	Author    string
+	Body      string
+	CreatedAt time.Time
+	URL       string
+	Kind      string // post | comment
+
+	// Reddit only
+	Subreddit string
+	PostID    string
+	ParentID  string
+	Score     int
+}
+
+// Checkpoint marks the newest item seen in a feed. The zero value means
+// nothing has been seen yet.
+type Checkpoint struct {
+	ID        string
+	CreatedAt time.Time
+}
+
+// reached reports whether an item is at or behind the checkpoint. Feeds are
+// walked from the newest item down to the checkpoint instead of asking for
+// items after its ID: Reddit, for one, answers before=<fullname> with an
+// empty listing once that item has been deleted or removed.
+func (c Checkpoint) reached(id string, createdAt time.Time) bool {
+	if c.ID == "" {
+		return false
+	}
+	return id == c.ID || createdAt.Before(c.CreatedAt)
+}
@@ -0,0 +1,124 @@
+package external_api
+
+import "strings"
+
+// Stances of a mention, stored as ticker_mentions.stance. Bearish mentions
+// are scored as short positions.
+const (
+	StanceBullish = "bullish"
+	StanceBearish = "bearish"
+	StanceNeutral = "neutral"
+)
+
+// stancePhrases are cues of several words. They are matched before single
+// words and use up their words, so "short squeeze" does not also count as
+// "short", and "sold puts" (a bet the price holds) not as "puts".
+var stancePhrases = []struct {
+	words  []string
+	weight int
+}{
+	{[]string{"pump", "and", "dump"}, -2},
+	{[]string{"stay", "away"}, -2},
+	{[]string{"stay", "clear"}, -2},
+	{[]string{"puts", "on"}, -2},
+	{[]string{"short", "squeeze"}, 1},
+	{[]string{"sold", "puts"}, 2},
+	{[]string{"selling", "puts"}, 2},
+	{[]string{"sell", "puts"}, 2},
+	{[]string{"covered", "calls"}, 0},
+	{[]string{"took", "profits"}, 0},
+	{[]string{"taking", "profits"}, 0},
+	{[]string{"to", "the", "moon"}, 1},
+	{[]string{"all", "in"}, 2},
+	{[]string{"loading", "up"}, 2},
+	{[]string{"loaded", "up"}, 2},
+	{[]string{"going", "up"}, 1},
+	{[]string{"going", "down"}, -1},
+}
+
+// stanceWords is the single-word lexicon. Positions taken or closed weigh 2,
+// opinions 1.
+var stanceWords = map[string]int{
+	// Positions
+	"bought": 2, "buying": 2, "buy": 2, "long": 2, "calls": 2, "call": 1,
+	"holding": 1, "hodl": 2, "accumulating": 2, "accumulate": 2, "adding": 2,
+	"added": 2, "averaged": 1, "dca": 1, "leaps": 2,
+	"sold": -2, "selling": -2, "sell": -2, "short": -2, "shorting": -2,
+	"shorted": -2, "shorts": -1, "puts": -2, "put": -1, "exited": -1,
+
+	// Opinions
+	"bullish": 1, "moon": 1, "mooning": 1, "undervalued": 1, "breakout": 1,
+	"squeeze": 1, "tendies": 1, "ripping": 1, "upside": 1, "cheap": 1,
+	"oversold": 1, "gem": 1, "winner": 1, "strong": 1, "beat": 1,
+	"bearish": -1, "overvalued": -1, "dump": -1, "dumping": -1, "scam": -2,
+	"avoid": -2, "bagholder": -1, "bagholders": -1, "bankrupt": -2,
+	"bankruptcy": -2, "dilution": -1, "diluting": -1, "offering": -1,
+	"crash": -1, "crashing": -1, "tank": -1, "tanking": -1, "fraud": -2,
+	"overbought": -1, "downside": -1, "garbage": -1, "trash": -1, "dead": -1,
+	"worthless": -2, "rug": -2, "overhyped": -1, "sinking": -1,
+}
+
+// stanceNegations flip the next cue within stanceNegationReach words, e.g.
+// "not selling" or "never buying".
+var stanceNegations = map[string]bool{
+	"not": true, "no": true, "never": true, "dont": true, "didnt": true,
+	"doesnt": true, "wont": true, "wouldnt": true, "isnt": true, "arent": true,
+	"cant": true, "cannot": true, "stop": true, "without": true, "nobody": true,
+}
+
+const stanceNegationReach = 3
+
+// ClassifyStance sums the stance cues of the sentences a ticker appears in:
+// a positive sum is bullish, a negative one bearish.
+func ClassifyStance(sentences []string) string {
+	var score int
+	for _, sentence := range sentences {
+		score += stanceScore(normalizeWords(sentence))
+	}
+	switch {
+	case score > 0:
+		return StanceBullish
+	case score < 0:
+		return StanceBearish
+	}
+	return StanceNeutral
+}
+
+func stanceScore(words []string) int {
+	var score int
+	negatedUntil := -1
+	for i := 0; i < len(words); {
+		if stanceNegations[words[i]] {
+			negatedUntil = i + stanceNegationReach
+			i++
+			continue
+		}
+
+		weight, length := stanceCue(words[i:])
+		if length == 0 {
+			i++
+			continue
+		}
+		if i <= negatedUntil {
+			weight = -weight
+			negatedUntil = -1
+		}
+		score += weight
+		i += length
+	}
+	return score
+}
+
+// stanceCue returns the weight and word count of the cue words start with,
+// or a length of 0 when they do not start with one.
+func stanceCue(words []string) (int, int) {
+	for _, phrase := range stancePhrases {
+		if len(words) >= len(phrase.words) && strings.Join(words[:len(phrase.words)], " ") == strings.Join(phrase.words, " ") {
+			return phrase.weight, len(phrase.words)
+		}
+	}
+	if weight, ok := stanceWords[words[0]]; ok {
+		return weight, 1
+	}
+	return 0, 0
+}
@@ -0,0 +1,33 @@
+package external_api
+
+import "testing"
+
+func TestClassifyStance(t *testing.T) {
+	tests := []struct {
+		name      string
+		sentences []string
+		want      string
+	}{
+		{"position taken", []string{"bought more NVDA"}, StanceBullish},
+		{"phrase", []string{"loading up on NVDA"}, StanceBullish},
+		{"opinion and exit", []string{"NVDA is overvalued, sold everything"}, StanceBearish},
+		{"puts", []string{"puts on NVDA"}, StanceBearish},
+		{"no cues", []string{"NVDA earnings tomorrow"}, StanceNeutral},
+		{"negated sell", []string{"not selling NVDA"}, StanceBullish},
+		{"negation with apostrophe", []string{"don't sell NVDA"}, StanceBullish},
+		{"negation a few words before", []string{"I would never buy NVDA"}, StanceBearish},
+		{"negation out of reach", []string{"not sure about this one but bought NVDA"}, StanceBullish},
+		{"negation flips only the next cue", []string{"not bullish, bearish on NVDA"}, StanceBearish},
+		{"short squeeze is not a short", []string{"short squeeze incoming on GME"}, StanceBullish},
+		{"sold puts is not puts", []string{"sold puts on NVDA"}, StanceBullish},
+		{"covered calls are not calls", []string{"wrote covered calls on NVDA"}, StanceNeutral},
+		{"sentences add up", []string{"bought NVDA", "NVDA is a scam"}, StanceNeutral},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyStance(tt.sentences); got != tt.want {
+				t.Errorf("ClassifyStance(%q) = %s, want %s", tt.sentences, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package external_api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/stuneak/sopeko/pkg/logger"
+	"github.com/stuneak/sopeko/pkg/source"
+)
+
+var stlog = logger.NewLogger("STOCKTWITS")
+
+const (
+	stockTwitsBaseURL  = "https://api.stocktwits.com/api/2"
+	stockTwitsWebURL   = "https://stocktwits.com"
+	stockTwitsMaxPages = 10
+)
+
+// StockTwitsSource reads one StockTwits stream, e.g. "trending" or
+// "symbol/AAPL". Streams return 30 messages per page, newest first, and are
+// paged backwards with max=<message id>.
+type StockTwitsSource struct {
+	client  *http.Client
+	baseURL string
+	stream  string
+}
+
+type stockTwitsResponse struct {
+	Cursor struct {
+		More bool  `json:"more"`
+		Max  int64 `json:"max"`
+	} `json:"cursor"`
+	Messages []struct {
+		ID        int64  `json:"id"`
+		Body      string `json:"body"`
+		CreatedAt string `json:"created_at"`
+		User      struct {
+			Username string `json:"username"`
+		} `json:"user"`
+	} `json:"messages"`
+}
+
+// NewStockTwitsSource reads stream from the public API, or from baseURL when
+// set (a fake server in tests).
+func NewStockTwitsSource(stream, baseURL string) *StockTwitsSource {
+	if baseURL == "" {
+		baseURL = stockTwitsBaseURL
+	}
+	return &StockTwitsSource{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		baseURL: baseURL,
+		stream:  stream,
+	}
+}
+
+func (s *StockTwitsSource) Name() string { return source.StockTwits }
+
+func (s *StockTwitsSource) Fetch(ctx context.Context, since Checkpoint, cutoff time.Time) ([]SourceItem, Checkpoint, error) {
+	var items []SourceItem
+	var maxID int64
+
+	for page := 0; page < stockTwitsMaxPages; page++ {
+		url := fmt.Sprintf("%s/streams/%s.json", s.baseURL, s.stream)
+		if maxID > 0 {
+			url += "?max=" + strconv.FormatInt(maxID, 10)
+		}
+
+		var resp stockTwitsResponse
+		if err := s.get(ctx, url, &resp); err != nil {
+			return nil, since, err
+		}
+
+		done := false
+		for _, m := range resp.Messages {
+			createdAt, err := time.Parse(time.RFC3339, m.CreatedAt)
+			if err != nil {
+				stlog("message %d: bad created_at %q, skipping", m.ID, m.CreatedAt)
+				continue
+			}
+			id := strconv.FormatInt(m.ID, 10)
+			if createdAt.Before(cutoff) || since.reached(id, createdAt) {
+				done = true
+				break
+			}
+			items = append(items, SourceItem{
+				ID:        id,
+				Author:    m.User.Username,
+				Body:      m.Body,
+				CreatedAt: createdAt,
+				URL:       fmt.Sprintf("%s/%s/message/%d", stockTwitsWebURL, m.User.Username, m.ID),
+				Kind:      "post",
+			})
+		}
+
+		if done || !resp.Cursor.More || resp.Cursor.Max == 0 {
+			break
+		}
+		maxID = resp.Cursor.Max
+	}
+
+	stlog("stream %s: %d new messages", s.stream, len(items))
+	if len(items) == 0 {
+		return nil, since, nil
+	}
+	return items, Checkpoint{ID: items[0].ID, CreatedAt: items[0].CreatedAt}, nil
+}
+
+func (s *StockTwitsSource) get(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("rate limited (429)")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
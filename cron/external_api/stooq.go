@@ -0,0 +1,217 @@
+package external_api
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stuneak/sopeko/pkg/market"
+)
+
+const stooqBaseURL = "https://stooq.com"
+
+// StooqProvider reads daily bars from Stooq's CSV downloads. Stooq has no
+// split or intraday data, so those are left to the next provider.
+type StooqProvider struct {
+	client  *http.Client
+	baseURL string
+}
+
+func NewStooqProvider() *StooqProvider {
+	return &StooqProvider{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		baseURL: stooqBaseURL,
+	}
+}
+
+func (s *StooqProvider) Name() string { return "stooq" }
+
+func (s *StooqProvider) FetchCurrentPriceAndVolume(ctx context.Context, symbol string) (float64, int64, time.Time, error) {
+	now := time.Now()
+	bars, err := s.fetchBars(ctx, symbol, now.AddDate(0, 0, -10), now)
+	if err != nil {
+		return 0, 0, time.Time{}, err
+	}
+	return latestClose(bars)
+}
+
+func (s *StooqProvider) FetchHistoricalPrice(ctx context.Context, symbol string, date time.Time) (float64, int64, time.Time, error) {
+	bars, err := s.fetchBars(ctx, symbol, date.AddDate(0, 0, -10), date)
+	if err != nil {
+		return 0, 0, time.Time{}, err
+	}
+	return lastCloseAtOrBefore(bars, symbol, date)
+}
+
+func (s *StooqProvider) FetchDailyBars(ctx context.Context, symbol string, from, to time.Time) ([]DailyBar, error) {
+	return s.fetchBars(ctx, symbol, from, to)
+}
+
+func (s *StooqProvider) FetchIntradayPrice(ctx context.Context, symbol string, at time.Time) (float64, time.Time, error) {
+	return 0, time.Time{}, fmt.Errorf("stooq intraday prices: %w", ErrNotSupported)
+}
+
+func (s *StooqProvider) FetchSplits(ctx context.Context, symbol string) ([]SplitEvent, error) {
+	return nil, fmt.Errorf("stooq splits: %w", ErrNotSupported)
+}
+
+func (s *StooqProvider) fetchBars(ctx context.Context, symbol string, from, to time.Time) ([]DailyBar, error) {
+	url := fmt.Sprintf("%s/q/d/l/?s=%s&i=d&d1=%s&d2=%s",
+		s.baseURL, stooqSymbol(symbol), from.Format("20060102"), to.Format("20060102"))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("stooq returned status %d for %s", resp.StatusCode, symbol)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unknown symbols and empty ranges come back as a plain "No data" body
+	if !strings.HasPrefix(string(body), "Date,") {
+		if strings.Contains(strings.ToLower(string(body)), "no data") {
+			return nil, fmt.Errorf("no stooq data for %s: %w", symbol, ErrNoData)
+		}
+		return nil, fmt.Errorf("unexpected stooq response for %s: %.80q", symbol, body)
+	}
+
+	bars, err := parseDailyBars(strings.NewReader(string(body)))
+	if err != nil {
+		return nil, err
+	}
+	if len(bars) == 0 {
+		return nil, fmt.Errorf("no stooq data for %s: %w", symbol, ErrNoData)
+	}
+	return bars, nil
+}
+
+// stooqSymbol maps a US ticker to Stooq's notation, e.g. BRK.B -> brk-b.us.
+func stooqSymbol(symbol string) string {
+	return strings.ToLower(strings.ReplaceAll(symbol, ".", "-")) + ".us"
+}
+
+// parseDailyBars reads a daily OHLCV CSV with a header row
+// (Date,Open,High,Low,Close,Volume, the format of both Stooq and Yahoo
+// downloads), oldest first. Columns are found by name, so extra columns
+// (e.g. Adj Close) are ignored; missing open, high or low default to the close.
+func parseDailyBars(r io.Reader) ([]DailyBar, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	dateCol, okDate := columns["date"]
+	closeCol, okClose := columns["close"]
+	volumeCol, okVolume := columns["volume"]
+	if !okDate || !okClose {
+		return nil, fmt.Errorf("csv needs Date and Close columns, got %v", header)
+	}
+
+	var bars []DailyBar
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if dateCol >= len(record) || closeCol >= len(record) {
+			continue
+		}
+
+		date, err := time.ParseInLocation("2006-01-02", record[dateCol], nyLoc)
+		if err != nil {
+			continue
+		}
+		closePrice, err := strconv.ParseFloat(record[closeCol], 64)
+		if err != nil || closePrice <= 0 {
+			continue
+		}
+		bar := DailyBar{
+			Date:     date,
+			Open:     closePrice,
+			High:     closePrice,
+			Low:      closePrice,
+			Close:    closePrice,
+			OpenedAt: market.SessionOpen(date),
+			ClosedAt: market.SessionClose(date),
+		}
+		for name, field := range map[string]*float64{"open": &bar.Open, "high": &bar.High, "low": &bar.Low} {
+			if col, ok := columns[name]; ok && col < len(record) {
+				if v, err := strconv.ParseFloat(record[col], 64); err == nil && v > 0 {
+					*field = v
+				}
+			}
+		}
+		if okVolume && volumeCol < len(record) {
+			v, _ := strconv.ParseFloat(record[volumeCol], 64)
+			bar.Volume = int64(v)
+		}
+		bars = append(bars, bar)
+	}
+	return bars, nil
+}
+
+// lastCloseAtOrBefore picks the newest bar whose session had closed by date,
+// so the price is one the poster could have seen.
+func lastCloseAtOrBefore(bars []DailyBar, symbol string, date time.Time) (float64, int64, time.Time, error) {
+	for i := len(bars) - 1; i >= 0; i-- {
+		if !bars[i].ClosedAt.After(date) {
+			return bars[i].Close, bars[i].Volume, bars[i].ClosedAt, nil
+		}
+	}
+	return 0, 0, time.Time{}, fmt.Errorf("no close for %s at or before %s: %w", symbol, date.Format("2006-01-02"), ErrNoData)
+}
+
+// latestClose returns the newest bar. During a session its close is the last
+// trade so far, so it is timestamped now rather than at the coming close.
+func latestClose(bars []DailyBar) (float64, int64, time.Time, error) {
+	bar := bars[len(bars)-1]
+	recordedAt := bar.ClosedAt
+	if now := time.Now(); recordedAt.After(now) {
+		recordedAt = now
+	}
+	return bar.Close, bar.Volume, recordedAt, nil
+}
+
+// barsBetween keeps the bars from from's to to's calendar date.
+func barsBetween(bars []DailyBar, from, to time.Time) []DailyBar {
+	first, last := nyDate(from), nyDate(to)
+	var kept []DailyBar
+	for _, bar := range bars {
+		if !bar.Date.Before(first) && !bar.Date.After(last) {
+			kept = append(kept, bar)
+		}
+	}
+	return kept
+}
+
+// nyDate is midnight New York time on t's calendar date.
+func nyDate(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, nyLoc)
+}
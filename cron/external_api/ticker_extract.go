@@ -0,0 +1,442 @@
+package external_api
+
+import (
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Common words and abbreviations that look like tickers when written in
+// uppercase. They count against a candidate rather than ruling it out, so a
+// cashtag like $ALL still gets through.
+var skipList = []string{
+	"YMMV", "EPS", "TAKE", "MAY", "YOUVE", "ONLY", "LOST", "MONEY", "IF", "YOU",
+	"SELL", "YOUR", "USA", "AUS", "UK", "STOCK", "DUE", "FOMO", "SOB", "NO",
+	"ETF", "POS", "PENNY", "GTFOH", "NOT", "TOTAL", "DD", "YOLO", "WSB", "RH",
+	"FOR", "THE", "MOON", "BUY", "HOLD", "OP", "GATE", "KEEP", "EV", "TRYING",
+	"TWICE", "EVERY", "YET", "MOOOON", "THREE", "MEDUSA", "ANNUAL", "MOVERS",
+	"VOLUME", "MONDAY", "TUESDAY", "WEDNESDAY", "THURSDAY", "FRIDAY", "ALASKA",
+	"FIRE", "IMHO", "PTSD", "HUGE", "GENIUS", "SCAN", "BAG", "TICKER", "THIS",
+	"WEEK", "LETS", "GOOOO", "NASA", "STILL", "OKAY", "RIGHT", "LEMME", "THICC",
+	"BEFORE", "GLOBE", "EBITDA", "LMAO", "FAFO", "GET", "LEFT", "BEHIND", "CLASS",
+	"VERY", "ADVENT", "HEALTH", "BOYS", "WHICH", "ONE", "JONES", "SODA", "OTC",
+	"AND", "RSS", "MARKET", "OF", "SAME", "SUPER", "TOXIC", "ALSO", "NEOW",
+	"NASDAQ", "EUR", "USD", "US", "NVIDIA", "IIRC", "ALONE", "WHAT", "SAID",
+	"ABOVE", "ADVICE", "DYOR", "ALWAYS", "FOOD", "NYSE", "ISA", "SPX", "BUT",
+	"MAYBE", "CALLS", "DRILL", "BABY", "TRUMP", "SHIB", "WILL", "RIP", "EXCEPT",
+	"CRYPTO", "DOE", "MIT", "RSI", "DONT", "ENTIRE", "AI", "XYZ", "IS", "LOOK",
+	"AT", "IN", "AH", "TODAY", "TIKTOK", "TSA", "CEO", "FDA", "PDUFA", "CTRL",
+	"SWOT", "BS", "REAL", "BRUH", "CANADA", "LONG", "LOL", "WAY", "WTF", "PUMP",
+	"DUMP", "NEW", "FLAGS", "BOUGHT", "PEAK", "HOLDER", "EOY", "EOW", "IPO",
+	"URANUS", "LIGMA", "HELOC", "FY", "LUL", "IT", "PT", "DC", "RS", "LOT",
+	"ALT", "PE", "VC", "IBKR", "ATH", "IMO", "NDA", "RELIEF", "COVID", "YTD",
+	"MASH", "RUG", "PULL", "PS", "TN", "CUSIP", "FTD", "UCSF", "DO", "IDK",
+	"IP", "PR", "IR", "SOME", "GAP", "KEY", "FAST", "DAY", "ANY", "AM", "CALL",
+	"PUT", "EXP", "DNN", "MAG", "ARE", "WE", "MA", "MAN", "UP", "DOWN", "AX",
+	"LSE", "AMEX", "AMS", "NEVER", "EVER", "COULD", "BE", "NEXT", "IQ", "PB",
+	"API", "III", "II", "I", "GL", "ALL", "BIO", "LOW", "BEWARE", "HERE", "INFO",
+	"TOUR", "TOP", "BACK", "HOOD", "PD", "PM", "EST", "ICE", "MAGA", "TS", "SCI",
+	"DTE", "CC", "NOW", "GO", "EOD", "TACO", "EU", "IRS", "GOOD", "BAD", "LINK",
+	"DNA", "CPU", "GPU", "RAM", "SSD", "HDD", "CTO", "ASX", "ARR", "SO", "CAN",
+	"OPEN",
+}
+
+var tickersToSkip = func() map[string]struct{} {
+	m := make(map[string]struct{}, len(skipList))
+	for _, s := range skipList {
+		m[s] = struct{}{}
+	}
+	return m
+}()
+
+// financeWords are words that suggest an uppercase word next to them is meant
+// as a ticker.
+var financeWords = func() map[string]struct{} {
+	words := []string{
+		"stock", "stocks", "share", "shares", "ticker", "calls", "call", "puts", "put",
+		"options", "option", "strike", "leaps", "otm", "itm", "expiry", "buy", "buying",
+		"bought", "sell", "selling", "sold", "short", "shorts", "shorted", "long",
+		"position", "positions", "holding", "holdings", "bagholder", "bag", "earnings",
+		"er", "eps", "revenue", "guidance", "dividend", "dividends", "ipo", "float",
+		"squeeze", "dip", "moon", "tendies", "yolo", "rally", "pump", "dump", "bullish",
+		"bearish", "chart", "support", "resistance", "price", "pt", "target", "premarket",
+		"afterhours", "gains", "loss", "losses", "portfolio", "invest", "invested",
+		"investing", "entry", "exit", "dca", "averaged", "undervalued", "overvalued",
+	}
+	m := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		m[w] = struct{}{}
+	}
+	return m
+}()
+
+// Confidence signals. A listed symbol written in uppercase starts at
+// baseTickerConfidence, the signals of its most convincing use are added and
+// the result is clamped to [0, 1]. commonWordPenalty outweighs the finance
+// context, so a skipList word only passes as a cashtag or next to its
+// company's name: "sold ALL my shares" is no mention of Allstate.
+const (
+	baseTickerConfidence = 0.5
+	cashtagBoost         = 0.5 // written as $TICKER
+	financeContextBoost  = 0.2 // a finance word in the same sentence
+	companyNameBoost     = 0.3 // the company's name appears in the text
+	commonWordPenalty    = 0.4 // the symbol is in skipList
+	shoutingPenalty      = 0.3 // the whole sentence is uppercase, capitals say nothing
+	baseNameConfidence   = 0.4 // a company name or alias, see ExtractTickerNames
+)
+
+// TickerLookup returns the company name of a symbol listed in ticker_names,
+// and false for symbols that are not listed.
+type TickerLookup func(symbol string) (companyName string, ok bool)
+
+// How a ticker was found in a text, stored as ticker_mentions.detected_by.
+const (
+	DetectedCashtag = "cashtag" // $NVDA
+	DetectedSymbol  = "symbol"  // NVDA
+	DetectedName    = "name"    // Nvidia, from ticker_names.company_name
+	DetectedAlias   = "alias"   // a nickname or former name from ticker_aliases
+)
+
+// TickerCandidate is a listed symbol found in a text, with how confident the
+// extractor is that it was meant as a ticker, from 0 to 1, how the most
+// convincing use was found and, from ExtractMentions, the stance taken on it,
+// the predictions made for it and the option contracts written on it.
+type TickerCandidate struct {
+	Symbol      string
+	Confidence  float64
+	DetectedBy  string
+	Stance      string
+	Predictions []Prediction
+	Options     []OptionContract
+
+	sentences []int // indexes of the sentences the symbol was seen in
+}
+
+// candidateRegex matches cashtags in any case ($nvda, $F) and words of up to
+// 7 letters; words without a $ only count when uppercase and 2+ letters.
+var candidateRegex = regexp.MustCompile(`(\$?)\b([A-Za-z]{1,7})\b`)
+
+var (
+	// A period before a digit is a decimal point ($2.50), not a sentence end
+	sentenceRegex = regexp.MustCompile(`(?:[^.!?\n]|\.[0-9])+`)
+	wordRegex     = regexp.MustCompile(`[A-Za-z]+`)
+)
+
+// ExtractTickers finds the listed symbols in text and scores each one by its
+// most convincing use. Candidates come back in order of first use; callers
+// drop the ones below their confidence threshold.
+func ExtractTickers(text string, lookup TickerLookup) []TickerCandidate {
+	normalizedText := NormalizeAlias(text)
+
+	// Lookups are done once per symbol; unlisted symbols are remembered as such
+	nameBoosts := make(map[string]float64)
+	listed := make(map[string]bool)
+	found := newCandidateSet()
+
+	for i, sentence := range sentenceRegex.FindAllString(text, -1) {
+		shouting := isShouting(sentence)
+		financeContext := hasFinanceWord(sentence)
+
+		for _, match := range candidateRegex.FindAllStringSubmatch(sentence, -1) {
+			cashtag, word := match[1] == "$", match[2]
+			if !cashtag && (len(word) < 2 || strings.ToUpper(word) != word) {
+				continue
+			}
+			symbol := strings.ToUpper(word)
+
+			isListed, looked := listed[symbol]
+			if !looked {
+				companyName, ok := lookup(symbol)
+				listed[symbol], isListed = ok, ok
+				if ok {
+					if mentionsCompany(normalizedText, companyName) {
+						nameBoosts[symbol] = companyNameBoost
+					}
+				}
+			}
+			if !isListed {
+				continue
+			}
+
+			score := baseTickerConfidence + nameBoosts[symbol]
+			if cashtag {
+				score += cashtagBoost
+			}
+			if financeContext {
+				score += financeContextBoost
+			}
+			if _, common := tickersToSkip[symbol]; common {
+				score -= commonWordPenalty
+			}
+			if shouting && !cashtag {
+				score -= shoutingPenalty
+			}
+			detectedBy := DetectedSymbol
+			if cashtag {
+				detectedBy = DetectedCashtag
+			}
+			found.add(TickerCandidate{Symbol: symbol, Confidence: score, DetectedBy: detectedBy, sentences: []int{i}})
+		}
+	}
+
+	return found.ranked()
+}
+
+// ExtractTickerNames finds tickers written by company name or alias, e.g.
+// "Nvidia" or "Coke". Names are not ambiguous the way uppercase words are, so
+// only the finance context adds to their confidence, but a name starts below
+// the default threshold: "Ford is a great car" is no mention of F.
+func ExtractTickerNames(text string, names *NameMatcher) []TickerCandidate {
+	if names == nil {
+		return nil
+	}
+
+	found := newCandidateSet()
+	for i, sentence := range sentenceRegex.FindAllString(text, -1) {
+		financeContext := hasFinanceWord(sentence)
+
+		for _, match := range names.Find(sentence) {
+			score := baseNameConfidence
+			if financeContext {
+				score += financeContextBoost
+			}
+			found.add(TickerCandidate{Symbol: match.Symbol, Confidence: score, DetectedBy: match.DetectedBy, sentences: []int{i}})
+		}
+	}
+	return found.ranked()
+}
+
+// ExtractMentions finds tickers by symbol and by name, keeping the most
+// confident detection of each, and classifies the stance taken on every one
+// from the sentences it appears in. Predictions and option contracts go to
+// the only ticker in their sentence, or to the only ticker of the text when
+// their sentence has none; ones that could mean several tickers are dropped.
+// A ticker traded through options takes the direction of its contracts as
+// its stance.
+func ExtractMentions(text string, postedAt time.Time, lookup TickerLookup, names *NameMatcher) []TickerCandidate {
+	found := newCandidateSet()
+	for _, candidate := range ExtractTickers(text, lookup) {
+		found.add(candidate)
+	}
+	for _, candidate := range ExtractTickerNames(text, names) {
+		found.add(candidate)
+	}
+
+	sentences := sentenceRegex.FindAllString(text, -1)
+	candidates := found.ranked()
+	for i := range candidates {
+		var mentioning []string
+		for _, index := range candidates[i].sentences {
+			mentioning = append(mentioning, sentences[index])
+		}
+		candidates[i].Stance = ClassifyStance(mentioning)
+	}
+
+	for _, prediction := range ExtractPredictions(text, postedAt) {
+		if owner := sentenceOwner(candidates, prediction.sentence); owner >= 0 {
+			candidates[owner].Predictions = append(candidates[owner].Predictions, prediction)
+		}
+	}
+	for _, contract := range ExtractOptions(text, postedAt) {
+		if owner := sentenceOwner(candidates, contract.sentence); owner >= 0 {
+			candidates[owner].Options = append(candidates[owner].Options, contract)
+		}
+	}
+	for i := range candidates {
+		if stance := optionsStance(candidates[i].Options); stance != "" {
+			candidates[i].Stance = stance
+		}
+	}
+	return candidates
+}
+
+// sentenceOwner returns the index of the only candidate seen in a sentence,
+// or of the only candidate of the text when the sentence has none. It
+// returns -1 when the sentence could be about several tickers.
+func sentenceOwner(candidates []TickerCandidate, sentence int) int {
+	owner := -1
+	for i, candidate := range candidates {
+		if slices.Contains(candidate.sentences, sentence) {
+			if owner >= 0 {
+				return -1
+			}
+			owner = i
+		}
+	}
+	if owner < 0 && len(candidates) == 1 {
+		owner = 0
+	}
+	return owner
+}
+
+// optionsStance is the direction of a ticker's option contracts when they
+// all agree, so "SPY 450p 0DTE" is bearish whatever the wording around it.
+func optionsStance(contracts []OptionContract) string {
+	if len(contracts) == 0 {
+		return ""
+	}
+	bullish := contracts[0].Bullish()
+	for _, c := range contracts[1:] {
+		if c.Bullish() != bullish {
+			return ""
+		}
+	}
+	if bullish {
+		return StanceBullish
+	}
+	return StanceBearish
+}
+
+// candidateSet collects the detections of symbols in order of first use.
+type candidateSet struct {
+	best  map[string]TickerCandidate
+	order []string
+}
+
+func newCandidateSet() *candidateSet {
+	return &candidateSet{best: make(map[string]TickerCandidate)}
+}
+
+// add keeps the most confident detection of a symbol, along with every
+// sentence any detection was seen in.
+func (s *candidateSet) add(candidate TickerCandidate) {
+	previous, seen := s.best[candidate.Symbol]
+	if !seen {
+		s.order = append(s.order, candidate.Symbol)
+		s.best[candidate.Symbol] = candidate
+		return
+	}
+
+	sentences := previous.sentences
+	for _, index := range candidate.sentences {
+		if !slices.Contains(sentences, index) {
+			sentences = append(sentences, index)
+		}
+	}
+	slices.Sort(sentences)
+	if candidate.Confidence > previous.Confidence {
+		previous = candidate
+	}
+	previous.sentences = sentences
+	s.best[candidate.Symbol] = previous
+}
+
+// ranked lists the best candidate of every symbol, with the confidence
+// clamped to [0, 1]. It is rounded to two decimals so sums like 0.5+0.2-0.2
+// compare equal to the threshold.
+func (s *candidateSet) ranked() []TickerCandidate {
+	candidates := make([]TickerCandidate, 0, len(s.order))
+	for _, symbol := range s.order {
+		candidate := s.best[symbol]
+		candidate.Confidence = math.Round(min(max(candidate.Confidence, 0), 1)*100) / 100
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+// isShouting reports whether a sentence of several words has no lowercase
+// letters at all.
+func isShouting(sentence string) bool {
+	words := wordRegex.FindAllString(sentence, -1)
+	if len(words) < 3 {
+		return false
+	}
+	return strings.ToUpper(sentence) == sentence
+}
+
+func hasFinanceWord(sentence string) bool {
+	for _, word := range wordRegex.FindAllString(sentence, -1) {
+		if _, ok := financeWords[strings.ToLower(word)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// genericNameWords are first words of company names too common to say which
+// company is meant.
+var genericNameWords = map[string]bool{
+	"first": true, "american": true, "united": true, "general": true, "national": true,
+	"global": true, "international": true, "new": true, "great": true, "select": true,
+}
+
+// mentionsCompany reports whether normalizedText contains the first word of
+// the company's normalized name, e.g. "palantir" for "Palantir Technologies
+// Inc. Class A Common Stock".
+func mentionsCompany(normalizedText, companyName string) bool {
+	words := strings.Fields(NormalizeCompanyName(companyName))
+	if len(words) == 0 || len(words[0]) < 4 || genericNameWords[words[0]] {
+		return false
+	}
+	return containsWord(normalizedText, words[0])
+}
+
+// containsWord reports whether word occurs in text with no letters or digits
+// directly around it.
+func containsWord(text, word string) bool {
+	for i := 0; ; {
+		j := strings.Index(text[i:], word)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(word)
+		if (start == 0 || !isWordByte(text[start-1])) && (end == len(text) || !isWordByte(text[end])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isWordByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
+
+// companySuffixes end the name part of a ticker_names.company_name: legal
+// forms and the security description that follows them on NASDAQ listings.
+var companySuffixes = map[string]bool{
+	"inc": true, "incorporated": true, "corp": true, "corporation": true, "co": true,
+	"company": true, "ltd": true, "limited": true, "plc": true, "llc": true, "lp": true,
+	"sa": true, "nv": true, "ag": true, "se": true, "class": true, "common": true,
+	"ordinary": true, "american": true, "depositary": true, "series": true,
+	"warrant": true, "warrants": true, "units": true, "unit": true, "rights": true,
+}
+
+// NormalizeCompanyName lowercases a company name, turns punctuation into
+// spaces and cuts it at the first legal form or security description, so
+// "Tesla, Inc. Common Stock" becomes "tesla" and "Ford Motor Company Common
+// Stock" becomes "ford motor". A leading "the" is dropped.
+func NormalizeCompanyName(name string) string {
+	words := normalizeWords(name)
+	if len(words) > 0 && words[0] == "the" {
+		words = words[1:]
+	}
+	for i, word := range words {
+		// A name never starts with its suffix, e.g. "American Express"
+		if i > 0 && companySuffixes[word] {
+			words = words[:i]
+			break
+		}
+	}
+	// "JPMorgan Chase & Co." is cut after the ampersand
+	for len(words) > 0 && words[len(words)-1] == "&" {
+		words = words[:len(words)-1]
+	}
+	return strings.Join(words, " ")
+}
+
+// NormalizeAlias brings an alias into the form names are matched in: lowercase
+// words separated by single spaces, without punctuation.
+func NormalizeAlias(alias string) string {
+	return strings.Join(normalizeWords(alias), " ")
+}
+
+// normalizeWords lowercases s and splits it into words of letters, digits and
+// ampersands. Apostrophes are dropped, so "McDonald's" is one word.
+func normalizeWords(s string) []string {
+	s = strings.NewReplacer("'", "", "’", "").Replace(strings.ToLower(s))
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '&')
+	})
+}
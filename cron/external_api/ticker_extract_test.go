@@ -0,0 +1,109 @@
+package external_api
+
+import (
+	"testing"
+)
+
+var testListed = map[string]string{
+	"ALL":  "Allstate Corporation (The) Common Stock",
+	"CAN":  "Canaan Inc. American Depositary Shares",
+	"REAL": "The RealReal, Inc. Common Stock",
+	"OPEN": "Opendoor Technologies Inc Common Stock",
+	"NVDA": "NVIDIA Corporation Common Stock",
+	"PLTR": "Palantir Technologies Inc. Class A Common Stock",
+	"AMD":  "Advanced Micro Devices, Inc. Common Stock",
+}
+
+func testLookup(symbol string) (string, bool) {
+	name, ok := testListed[symbol]
+	return name, ok
+}
+
+func TestExtractTickersConfidence(t *testing.T) {
+	const minConfidence = 0.5
+
+	tests := []struct {
+		name string
+		text string
+		want map[string]bool // symbol -> stored at the default threshold
+	}{
+		{"common word with finance word", "I sold ALL my shares", map[string]bool{"ALL": false}},
+		{"common word with option words", "CAN you buy calls", map[string]bool{"CAN": false}},
+		{"common word alone", "this is REAL", map[string]bool{"REAL": false}},
+		{"common word as cashtag", "loading up on $ALL", map[string]bool{"ALL": true}},
+		{"common word lowercase cashtag", "$open to $10", map[string]bool{"OPEN": true}},
+		{"common word next to company name", "Allstate (ALL) beat on earnings", map[string]bool{"ALL": true}},
+		{"symbol with finance word", "bought NVDA calls", map[string]bool{"NVDA": true}},
+		{"bare symbol", "NVDA is fine", map[string]bool{"NVDA": true}},
+		{"shouting sentence", "I CAN NOT BELIEVE IT", map[string]bool{"CAN": false}},
+		{"shouting with cashtag", "BUY $PLTR NOW GUYS", map[string]bool{"PLTR": true}},
+		{"lowercase word", "nvda and amd", map[string]bool{}},
+		{"unlisted symbol", "XYZW to the moon", map[string]bool{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(map[string]bool)
+			for _, c := range ExtractTickers(tt.text, testLookup) {
+				if c.Confidence < 0 || c.Confidence > 1 {
+					t.Errorf("%s confidence %v out of [0, 1]", c.Symbol, c.Confidence)
+				}
+				got[c.Symbol] = c.Confidence >= minConfidence
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ExtractTickers(%q) found %v, want %v", tt.text, got, tt.want)
+			}
+			for symbol, stored := range tt.want {
+				if got[symbol] != stored {
+					t.Errorf("ExtractTickers(%q): %s stored = %v, want %v", tt.text, symbol, got[symbol], stored)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractTickerNamesConfidence(t *testing.T) {
+	const minConfidence = 0.5
+	names := NewNameMatcher(testCompanies, testAliases)
+
+	tests := []struct {
+		name string
+		text string
+		want map[string]bool // symbol -> stored at the default threshold
+	}{
+		{"bare name", "Palantir is everywhere", map[string]bool{"PLTR": false}},
+		{"bare common word name", "Ford is a great car", map[string]bool{"F": false}},
+		{"bare alias", "papa musk delivers", map[string]bool{"TSLA": false}},
+		{"name with finance word", "bought more Palantir shares", map[string]bool{"PLTR": true}},
+		{"common word name with finance word", "sold my Ford position", map[string]bool{"F": true}},
+		{"finance word in another sentence", "Ford is a great car. Bought calls today", map[string]bool{"F": false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(map[string]bool)
+			for _, c := range ExtractTickerNames(tt.text, names) {
+				got[c.Symbol] = c.Confidence >= minConfidence
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ExtractTickerNames(%q) found %v, want %v", tt.text, got, tt.want)
+			}
+			for symbol, stored := range tt.want {
+				if got[symbol] != stored {
+					t.Errorf("ExtractTickerNames(%q): %s stored = %v, want %v", tt.text, symbol, got[symbol], stored)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractTickersDetectedBy(t *testing.T) {
+	candidates := ExtractTickers("$AMD over NVDA. Sold NVDA, kept $amd", testLookup)
+	if len(candidates) != 2 {
+		t.Fatalf("got %d candidates, want 2", len(candidates))
+	}
+	want := map[string]string{"AMD": DetectedCashtag, "NVDA": DetectedSymbol}
+	for _, c := range candidates {
+		if c.DetectedBy != want[c.Symbol] {
+			t.Errorf("%s detected by %s, want %s", c.Symbol, c.DetectedBy, want[c.Symbol])
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/stuneak/sopeko/pkg/logger"
+	"github.com/stuneak/sopeko/pkg/market"
 )
 
 var ylog = logger.NewLogger("YAHOO")
@@ -33,6 +34,9 @@ type yahooChartResponse struct {
 			Timestamp  []int64 `json:"timestamp"`
 			Indicators struct {
 				Quote []struct {
+					Open   []*float64 `json:"open"`
+					High   []*float64 `json:"high"`
+					Low    []*float64 `json:"low"`
 					Close  []*float64 `json:"close"`
 					Volume []*int64   `json:"volume"`
 				} `json:"quote"`
@@ -60,6 +64,17 @@ func NewYahooFetcher() *YahooFetcher {
 	}
 }
 
+func (y *YahooFetcher) Name() string { return "yahoo" }
+
+// yahooStatusError reports a non-200 response. Yahoo answers unknown symbols
+// with 404, which is a missing symbol rather than a failing provider.
+func yahooStatusError(status int, symbol string) error {
+	if status == http.StatusNotFound {
+		return fmt.Errorf("yahoo finance returned status %d for %s: %w", status, symbol, ErrNoData)
+	}
+	return fmt.Errorf("yahoo finance returned status %d for %s", status, symbol)
+}
+
 // FetchCurrentPriceAndVolume fetches the previous day's closing price, volume, and timestamp for a symbol.
 func (y *YahooFetcher) FetchCurrentPriceAndVolume(ctx context.Context, symbol string) (price float64, volume int64, recordedAt time.Time, err error) {
 	ylog("fetching symbol=%s", symbol)
@@ -85,7 +100,7 @@ func (y *YahooFetcher) FetchCurrentPriceAndVolume(ctx context.Context, symbol st
 
 	if resp.StatusCode != http.StatusOK {
 		ylog("non-200 status=%d for %s", resp.StatusCode, symbol)
-		return 0, 0, time.Time{}, fmt.Errorf("yahoo finance returned status %d for %s", resp.StatusCode, symbol)
+		return 0, 0, time.Time{}, yahooStatusError(resp.StatusCode, symbol)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -107,13 +122,13 @@ func (y *YahooFetcher) FetchCurrentPriceAndVolume(ctx context.Context, symbol st
 
 	if len(chartResp.Chart.Result) == 0 {
 		ylog("no chart data for %s", symbol)
-		return 0, 0, time.Time{}, fmt.Errorf("no chart data for %s", symbol)
+		return 0, 0, time.Time{}, fmt.Errorf("no chart data for %s: %w", symbol, ErrNoData)
 	}
 
 	meta := chartResp.Chart.Result[0].Meta
 	if meta.RegularMarketPrice == 0 {
 		ylog("no market price for %s", symbol)
-		return 0, 0, time.Time{}, fmt.Errorf("no market price for %s", symbol)
+		return 0, 0, time.Time{}, fmt.Errorf("no market price for %s: %w", symbol, ErrNoData)
 	}
 
 	ylog("success symbol=%s price=%.4f volume=%d", symbol, meta.RegularMarketPrice, meta.RegularMarketVolume)
@@ -147,7 +162,7 @@ func (y *YahooFetcher) FetchHistoricalPrice(ctx context.Context, symbol string,
 
 	if resp.StatusCode != http.StatusOK {
 		ylog("non-200 status=%d for %s", resp.StatusCode, symbol)
-		return 0, 0, time.Time{}, fmt.Errorf("yahoo finance returned status %d for %s", resp.StatusCode, symbol)
+		return 0, 0, time.Time{}, yahooStatusError(resp.StatusCode, symbol)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -169,19 +184,19 @@ func (y *YahooFetcher) FetchHistoricalPrice(ctx context.Context, symbol string,
 
 	if len(chartResp.Chart.Result) == 0 {
 		ylog("no chart data for %s on %s", symbol, date.Format("2006-01-02"))
-		return 0, 0, time.Time{}, fmt.Errorf("no chart data for %s on %s", symbol, date.Format("2006-01-02"))
+		return 0, 0, time.Time{}, fmt.Errorf("no chart data for %s on %s: %w", symbol, date.Format("2006-01-02"), ErrNoData)
 	}
 
 	result := chartResp.Chart.Result[0]
 	if len(result.Indicators.Quote) == 0 || len(result.Indicators.Quote[0].Close) == 0 {
 		ylog("no price data for %s on %s", symbol, date.Format("2006-01-02"))
-		return 0, 0, time.Time{}, fmt.Errorf("no price data for %s on %s", symbol, date.Format("2006-01-02"))
+		return 0, 0, time.Time{}, fmt.Errorf("no price data for %s on %s: %w", symbol, date.Format("2006-01-02"), ErrNoData)
 	}
 
 	closePrice := result.Indicators.Quote[0].Close[0]
 	if closePrice == nil {
 		ylog("nil close price for %s on %s", symbol, date.Format("2006-01-02"))
-		return 0, 0, time.Time{}, fmt.Errorf("nil close price for %s on %s", symbol, date.Format("2006-01-02"))
+		return 0, 0, time.Time{}, fmt.Errorf("nil close price for %s on %s: %w", symbol, date.Format("2006-01-02"), ErrNoData)
 	}
 
 	var vol int64
@@ -198,6 +213,183 @@ func (y *YahooFetcher) FetchHistoricalPrice(ctx context.Context, symbol string,
 	return *closePrice, vol, ts, nil
 }
 
+// FetchDailyBars fetches the daily bars between two dates with the chart
+// API's period1/period2 range. Yahoo's OHLC values are split-adjusted.
+func (y *YahooFetcher) FetchDailyBars(ctx context.Context, symbol string, from, to time.Time) ([]DailyBar, error) {
+	ylog("fetching daily bars symbol=%s from=%s to=%s", symbol, from.Format("2006-01-02"), to.Format("2006-01-02"))
+
+	first, last := nyDate(from), nyDate(to)
+	url := fmt.Sprintf(
+		"https://query1.finance.yahoo.com/v8/finance/chart/%s?period1=%d&period2=%d&interval=1d",
+		symbol, first.Unix(), last.AddDate(0, 0, 1).Unix(),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; StockMentionBot/1.0)")
+
+	resp, err := y.client.Do(req)
+	if err != nil {
+		ylog("HTTP request failed for %s: %v", symbol, err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		ylog("non-200 status=%d for %s", resp.StatusCode, symbol)
+		return nil, yahooStatusError(resp.StatusCode, symbol)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var chartResp yahooChartResponse
+	if err := json.Unmarshal(body, &chartResp); err != nil {
+		ylog("JSON unmarshal error for %s: %v", symbol, err)
+		return nil, err
+	}
+
+	if chartResp.Chart.Error != nil {
+		ylog("API error for %s: %s", symbol, chartResp.Chart.Error.Description)
+		return nil, fmt.Errorf("yahoo API error for %s: %s", symbol, chartResp.Chart.Error.Description)
+	}
+
+	if len(chartResp.Chart.Result) == 0 || len(chartResp.Chart.Result[0].Indicators.Quote) == 0 {
+		return nil, fmt.Errorf("no chart data for %s: %w", symbol, ErrNoData)
+	}
+
+	result := chartResp.Chart.Result[0]
+	quote := result.Indicators.Quote[0]
+	value := func(values []*float64, i int, fallback float64) float64 {
+		if i < len(values) && values[i] != nil && *values[i] > 0 {
+			return *values[i]
+		}
+		return fallback
+	}
+
+	var bars []DailyBar
+	for i, ts := range result.Timestamp {
+		closePrice := value(quote.Close, i, 0)
+		if closePrice <= 0 {
+			continue
+		}
+		// Daily timestamps are the session open, the date is what matters
+		day := nyDate(time.Unix(ts, 0).In(nyLoc))
+		if day.Before(first) || day.After(last) {
+			continue
+		}
+		bar := DailyBar{
+			Date:     day,
+			Open:     value(quote.Open, i, closePrice),
+			High:     value(quote.High, i, closePrice),
+			Low:      value(quote.Low, i, closePrice),
+			Close:    closePrice,
+			OpenedAt: market.SessionOpen(day),
+			ClosedAt: market.SessionClose(day),
+		}
+		if i < len(quote.Volume) && quote.Volume[i] != nil {
+			bar.Volume = *quote.Volume[i]
+		}
+		bars = append(bars, bar)
+	}
+
+	if len(bars) == 0 {
+		return nil, fmt.Errorf("no daily bars for %s: %w", symbol, ErrNoData)
+	}
+	ylog("found %d daily bars for %s", len(bars), symbol)
+	return bars, nil
+}
+
+// Yahoo keeps 1-minute bars for a week, 5-minute bars for 60 days and hourly
+// bars for two years, so older mentions get coarser intraday prices.
+var yahooIntradayIntervals = []struct {
+	maxAge   time.Duration
+	interval string
+	step     time.Duration
+}{
+	{7 * 24 * time.Hour, "1m", time.Minute},
+	{60 * 24 * time.Hour, "5m", 5 * time.Minute},
+	{730 * 24 * time.Hour, "60m", time.Hour},
+}
+
+// FetchIntradayPrice fetches the open of the finest intraday bar Yahoo still
+// has that contains at.
+func (y *YahooFetcher) FetchIntradayPrice(ctx context.Context, symbol string, at time.Time) (float64, time.Time, error) {
+	age := time.Since(at)
+	var interval string
+	var step time.Duration
+	for _, i := range yahooIntradayIntervals {
+		if age < i.maxAge {
+			interval, step = i.interval, i.step
+			break
+		}
+	}
+	if interval == "" {
+		return 0, time.Time{}, fmt.Errorf("no intraday data for %s this far back: %w", symbol, ErrNoData)
+	}
+	ylog("fetching intraday symbol=%s at=%s interval=%s", symbol, at.Format("2006-01-02 15:04"), interval)
+
+	url := fmt.Sprintf(
+		"https://query1.finance.yahoo.com/v8/finance/chart/%s?period1=%d&period2=%d&interval=%s",
+		symbol, at.Add(-step).Unix(), at.Add(step).Unix(), interval,
+	)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; StockMentionBot/1.0)")
+
+	resp, err := y.client.Do(req)
+	if err != nil {
+		ylog("HTTP request failed for %s: %v", symbol, err)
+		return 0, time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		ylog("non-200 status=%d for %s", resp.StatusCode, symbol)
+		return 0, time.Time{}, yahooStatusError(resp.StatusCode, symbol)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	var chartResp yahooChartResponse
+	if err := json.Unmarshal(body, &chartResp); err != nil {
+		ylog("JSON unmarshal error for %s: %v", symbol, err)
+		return 0, time.Time{}, err
+	}
+
+	if chartResp.Chart.Error != nil {
+		ylog("API error for %s: %s", symbol, chartResp.Chart.Error.Description)
+		return 0, time.Time{}, fmt.Errorf("yahoo API error for %s: %s", symbol, chartResp.Chart.Error.Description)
+	}
+
+	if len(chartResp.Chart.Result) == 0 || len(chartResp.Chart.Result[0].Indicators.Quote) == 0 {
+		return 0, time.Time{}, fmt.Errorf("no intraday data for %s: %w", symbol, ErrNoData)
+	}
+
+	result := chartResp.Chart.Result[0]
+	quote := result.Indicators.Quote[0]
+	for i, ts := range result.Timestamp {
+		start := time.Unix(ts, 0)
+		if start.After(at) || !start.Add(step).After(at) {
+			continue
+		}
+		if i < len(quote.Open) && quote.Open[i] != nil && *quote.Open[i] > 0 {
+			return *quote.Open[i], start, nil
+		}
+	}
+	return 0, time.Time{}, fmt.Errorf("no intraday bar for %s at %s: %w", symbol, at.Format("2006-01-02 15:04"), ErrNoData)
+}
+
 // FetchSplits fetches all stock split events for a symbol.
 func (y *YahooFetcher) FetchSplits(ctx context.Context, symbol string) ([]SplitEvent, error) {
 	ylog("fetching splits for %s", symbol)
@@ -223,7 +415,7 @@ func (y *YahooFetcher) FetchSplits(ctx context.Context, symbol string) ([]SplitE
 
 	if resp.StatusCode != http.StatusOK {
 		ylog("non-200 status=%d for %s", resp.StatusCode, symbol)
-		return nil, fmt.Errorf("yahoo finance returned status %d for %s", resp.StatusCode, symbol)
+		return nil, yahooStatusError(resp.StatusCode, symbol)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -0,0 +1,227 @@
+package cron
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	external_api "github.com/stuneak/sopeko/cron/external_api"
+	db "github.com/stuneak/sopeko/db/sqlc"
+	"github.com/stuneak/sopeko/pkg/market"
+)
+
+// Entry rules decide when a mention could first have been traded on, and so
+// the price its returns are measured from. A comment posted on a Saturday or
+// at 9pm is not priced at a close the poster could no longer trade at.
+const (
+	entryNextOpen  = "next_open"  // open of the first session starting after the mention
+	entryNextClose = "next_close" // close of the running session, or of the next one
+	entryIntraday  = "intraday"   // price at the mention during a session, next open outside one
+)
+
+func validEntryRule(rule string) bool {
+	return rule == entryNextOpen || rule == entryNextClose || rule == entryIntraday
+}
+
+// Mentions get their entry when they are stored. The mention-entries job
+// prices the ones whose session had not finished yet, and re-resolves all
+// mentions when ENTRY_RULE changes, newest first, mentionEntriesBatch per run.
+// An entry still without a price mentionEntryRetry after its time is given up.
+const (
+	mentionEntriesJob     = "mention-entries"
+	mentionEntriesTimeout = time.Hour
+	mentionEntriesBatch   = 2000
+	mentionEntryRetry     = 7 * 24 * time.Hour
+
+	// intradayLiveWindow is how recent a mention must be to be priced with
+	// the current quote rather than an intraday bar.
+	intradayLiveWindow = 15 * time.Minute
+)
+
+// entryTime is when a mention at t is entered under rule.
+func entryTime(rule string, t time.Time) time.Time {
+	switch rule {
+	case entryNextClose:
+		return market.NextClose(t)
+	case entryIntraday:
+		if market.InSession(t) {
+			return t
+		}
+	}
+	return market.NextOpen(t)
+}
+
+func (s *Scheduler) resolveMentionEntries() {
+	ctx, cancel := context.WithTimeout(context.Background(), mentionEntriesTimeout)
+	defer cancel()
+
+	startedAt := time.Now()
+	clog("starting mention entries")
+
+	mentions, err := s.store.ListMentionEntriesDue(ctx, db.ListMentionEntriesDueParams{
+		EntryRule:  s.entryRule,
+		RetrySince: startedAt.Add(-mentionEntryRetry),
+		RowLimit:   mentionEntriesBatch,
+	})
+	if err != nil {
+		clog("error fetching mentions from DB: %v", err)
+		return
+	}
+
+	var fetched, failed, skipped int
+	for i, m := range mentions {
+		if ctx.Err() != nil {
+			skipped += len(mentions) - i
+			break
+		}
+		priced, err := s.setMentionEntry(ctx, s.store, m.ID, m.TickerID, m.Symbol, m.MentionedAt)
+		if err != nil && ctx.Err() != nil {
+			skipped += len(mentions) - i
+			break
+		}
+		switch {
+		case err != nil:
+			failed++
+			if failed <= 10 {
+				clog("error for %s at %s: %v", m.Symbol, m.MentionedAt.Format("2006-01-02 15:04"), err)
+			}
+		case priced:
+			fetched++
+		default:
+			// Not traded yet or no price, a later run tries again
+			skipped++
+		}
+	}
+	if len(mentions) == mentionEntriesBatch {
+		clog("batch full, the rest is left for the next run")
+	}
+
+	finishedAt := time.Now()
+	clog("done in %s - %d entries priced, %d errors, %d pending",
+		finishedAt.Sub(startedAt).Round(time.Second), fetched, failed, skipped)
+
+	saveCtx, saveCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer saveCancel()
+	err = s.store.CreateJobRun(saveCtx, db.CreateJobRunParams{
+		Job:        mentionEntriesJob,
+		StartedAt:  startedAt,
+		FinishedAt: finishedAt,
+		DurationMs: finishedAt.Sub(startedAt).Milliseconds(),
+		Total:      int32(len(mentions)),
+		Fetched:    int32(fetched),
+		Failed:     int32(failed),
+		Skipped:    int32(skipped),
+	})
+	if err != nil {
+		clog("error saving run summary: %v", err)
+	}
+}
+
+// setMentionEntry stores the entry of a mention under the current rule, with
+// its price when the entry has been traded, and reports whether the price is
+// known. On errors nothing is stored, so the job tries again.
+func (s *Scheduler) setMentionEntry(ctx context.Context, store *db.Queries, mentionID, tickerID int64, symbol string, mentionedAt time.Time) (bool, error) {
+	entryAt, price, err := s.resolveEntry(ctx, store, tickerID, symbol, mentionedAt)
+	if err != nil {
+		return false, err
+	}
+
+	err = store.SetMentionEntry(ctx, db.SetMentionEntryParams{
+		ID:         mentionID,
+		EntryRule:  s.entryRule,
+		EntryAt:    sql.NullTime{Time: entryAt, Valid: true},
+		EntryPrice: price,
+	})
+	if err != nil {
+		return false, fmt.Errorf("storing entry: %w", err)
+	}
+	return price.Valid, nil
+}
+
+// resolveEntry finds the entry of a mention and its price as traded. The
+// price stays NULL while the entry is in the future or its session has not
+// finished. Intraday entries whose bars are no longer available are entered
+// at the close of their session instead.
+func (s *Scheduler) resolveEntry(ctx context.Context, store *db.Queries, tickerID int64, symbol string, mentionedAt time.Time) (time.Time, sql.NullString, error) {
+	entryAt := entryTime(s.entryRule, mentionedAt)
+
+	if s.entryRule == entryIntraday && entryAt.Equal(mentionedAt) {
+		price, err := s.intradayPrice(ctx, store, tickerID, symbol, mentionedAt)
+		if err == nil {
+			return entryAt, sql.NullString{String: fmt.Sprintf("%.4f", price), Valid: true}, nil
+		}
+		if !errors.Is(err, external_api.ErrNoData) && !errors.Is(err, external_api.ErrNotSupported) {
+			return entryAt, sql.NullString{}, err
+		}
+		entryAt = market.SessionClose(mentionedAt)
+	}
+
+	bar, err := s.entryBar(ctx, store, tickerID, symbol, entryAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entryAt, sql.NullString{}, nil
+	}
+	if err != nil {
+		return entryAt, sql.NullString{}, err
+	}
+	return entryAt, sql.NullString{String: entryBarPrice(bar, entryAt), Valid: true}, nil
+}
+
+// entryBarPrice is the price of bar at entryAt: its open for an entry at the
+// session's start, its close otherwise.
+func entryBarPrice(bar db.TickerDailyBar, entryAt time.Time) string {
+	if entryAt.Equal(bar.OpenedAt) {
+		return bar.Open
+	}
+	return bar.Close
+}
+
+// entryBar returns the daily bar of the session entryAt falls in, fetching
+// the bars from that day on when it is missing. It returns sql.ErrNoRows
+// while the session has not finished, or when no provider has the day.
+func (s *Scheduler) entryBar(ctx context.Context, store *db.Queries, tickerID int64, symbol string, entryAt time.Time) (db.TickerDailyBar, error) {
+	day := market.Day(entryAt)
+	now := time.Now()
+	if market.SessionClose(day).After(now) {
+		return db.TickerDailyBar{}, sql.ErrNoRows
+	}
+
+	bar, err := store.GetDailyBar(ctx, db.GetDailyBarParams{TickerID: tickerID, Day: day})
+	if !errors.Is(err, sql.ErrNoRows) {
+		return bar, err
+	}
+	if strings.Contains(symbol, "^") || strings.Contains(symbol, "/") {
+		return bar, err
+	}
+
+	if _, err := s.syncTickerBars(ctx, store, tickerID, symbol, day, now); err != nil {
+		return db.TickerDailyBar{}, err
+	}
+	return store.GetDailyBar(ctx, db.GetDailyBarParams{TickerID: tickerID, Day: day})
+}
+
+// intradayPrice is the price at an in-session time as traded: the current
+// quote for a mention that just happened, the intraday bar containing it
+// otherwise.
+func (s *Scheduler) intradayPrice(ctx context.Context, store *db.Queries, tickerID int64, symbol string, at time.Time) (float64, error) {
+	if time.Since(at) < intradayLiveWindow {
+		price, _, _, err := s.fetchCurrentPrice(ctx, symbol)
+		return price, err
+	}
+
+	if err := s.priceLimiter.Wait(ctx); err != nil {
+		return 0, err
+	}
+	price, _, err := s.prices.FetchIntradayPrice(ctx, symbol, at)
+	if err != nil {
+		return 0, err
+	}
+
+	splits, err := store.GetSplitsByTicker(ctx, tickerID)
+	if err != nil {
+		return 0, err
+	}
+	return price / splitFactor(splits, market.Day(at)), nil
+}
@@ -0,0 +1,83 @@
+package cron
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	external_api "github.com/stuneak/sopeko/cron/external_api"
+	db "github.com/stuneak/sopeko/db/sqlc"
+	"github.com/stuneak/sopeko/pkg/market"
+)
+
+var newYork, _ = time.LoadLocation("America/New_York")
+
+// storedBar is the row UpsertDailyBar writes for params.
+func storedBar(params db.UpsertDailyBarParams) db.TickerDailyBar {
+	return db.TickerDailyBar{
+		TickerID: params.TickerID,
+		Day:      params.Day,
+		Open:     params.Open,
+		High:     params.High,
+		Low:      params.Low,
+		Close:    params.Close,
+		Volume:   params.Volume,
+		OpenedAt: params.OpenedAt,
+		ClosedAt: params.ClosedAt,
+	}
+}
+
+func TestNextOpenEntryIsPricedAtOpen(t *testing.T) {
+	dir := t.TempDir()
+	csv := "Date,Open,High,Low,Close,Volume\n" +
+		"2024-06-07,100,110,95,105,1000\n" +
+		"2024-06-10,106,112,104,111,2000\n"
+	if err := os.WriteFile(filepath.Join(dir, "ABC.csv"), []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	provider := external_api.NewCSVPriceProvider(dir)
+
+	day := time.Date(2024, time.June, 10, 0, 0, 0, 0, newYork)
+	bars, err := provider.FetchDailyBars(context.Background(), "ABC", day, day)
+	if err != nil || len(bars) != 1 {
+		t.Fatalf("FetchDailyBars = %v, %v", bars, err)
+	}
+	bar := storedBar(dailyBarParams(1, bars[0], nil))
+	if !bar.OpenedAt.Equal(market.SessionOpen(day)) {
+		t.Fatalf("opened_at = %s, want %s", bar.OpenedAt, market.SessionOpen(day))
+	}
+
+	// Saturday evening enters at Monday's open
+	mentionedAt := time.Date(2024, time.June, 8, 20, 0, 0, 0, newYork)
+	entryAt := entryTime(entryNextOpen, mentionedAt)
+	if !entryAt.Equal(market.SessionOpen(day)) {
+		t.Fatalf("entry at %s, want %s", entryAt, market.SessionOpen(day))
+	}
+	if price := entryBarPrice(bar, entryAt); price != "106.0000" {
+		t.Errorf("next_open entry price = %s, want the open 106.0000", price)
+	}
+	if price := entryBarPrice(bar, entryTime(entryNextClose, mentionedAt)); price != "111.0000" {
+		t.Errorf("next_close entry price = %s, want the close 111.0000", price)
+	}
+}
+
+func TestDailyBarParamsUndoSplits(t *testing.T) {
+	day := time.Date(2024, time.June, 7, 0, 0, 0, 0, newYork)
+	splits := []db.TickerSplit{{
+		Ratio:         "0.5", // 2:1 on the Monday after
+		EffectiveDate: time.Date(2024, time.June, 10, 0, 0, 0, 0, time.UTC),
+	}}
+	params := dailyBarParams(1, external_api.DailyBar{
+		Date: day, Open: 50, High: 55, Low: 45, Close: 52, Volume: 2000,
+		OpenedAt: market.SessionOpen(day), ClosedAt: market.SessionClose(day),
+	}, splits)
+
+	if params.Open != "100.0000" || params.Close != "104.0000" || params.Volume != 1000 {
+		t.Errorf("got open %s close %s volume %d, want 100.0000 104.0000 1000", params.Open, params.Close, params.Volume)
+	}
+	if !params.OpenedAt.Equal(market.SessionOpen(day)) || !params.ClosedAt.Equal(market.SessionClose(day)) {
+		t.Errorf("session %s - %s not kept", params.OpenedAt, params.ClosedAt)
+	}
+}
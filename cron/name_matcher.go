@@ -0,0 +1,72 @@
+package cron
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	external_api "github.com/stuneak/sopeko/cron/external_api"
+	db "github.com/stuneak/sopeko/db/sqlc"
+)
+
+// The name matcher is rebuilt from ticker_names and ticker_aliases once it is
+// nameMatcherTTL old, so new listings and aliases are picked up without a
+// restart. A failed rebuild is retried after the same delay, so a database
+// outage doesn't turn every processed item into another rebuild.
+const nameMatcherTTL = 10 * time.Minute
+
+// nameCache holds the matcher shared by every job that processes content.
+type nameCache struct {
+	mu       sync.Mutex
+	matcher  *external_api.NameMatcher
+	builtAt  time.Time
+	failedAt time.Time
+}
+
+// nameMatcher returns the current matcher, rebuilding it when it is stale. If
+// rebuilding fails the previous matcher is kept, or names are not recognized
+// at all until a rebuild works, and the next attempt waits nameMatcherTTL.
+func (s *Scheduler) nameMatcher(ctx context.Context) *external_api.NameMatcher {
+	s.names.mu.Lock()
+	defer s.names.mu.Unlock()
+
+	if s.names.matcher != nil && time.Since(s.names.builtAt) < nameMatcherTTL {
+		return s.names.matcher
+	}
+	if time.Since(s.names.failedAt) < nameMatcherTTL {
+		return s.names.matcher
+	}
+
+	matcher, err := buildNameMatcher(ctx, s.store)
+	if err != nil {
+		clog("error building name matcher, retrying in %s: %v", nameMatcherTTL, err)
+		s.names.failedAt = time.Now()
+		return s.names.matcher
+	}
+	s.names.matcher, s.names.builtAt = matcher, time.Now()
+	return matcher
+}
+
+func buildNameMatcher(ctx context.Context, store *db.Queries) (*external_api.NameMatcher, error) {
+	tickers, err := store.ListAllTickers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	aliasRows, err := store.ListTickerAliases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	companies := make(map[string]string, len(tickers))
+	for _, t := range tickers {
+		// Benchmarks are named after their symbol
+		if t.Exchange != benchmarkExchange {
+			companies[t.Symbol] = t.CompanyName
+		}
+	}
+	aliases := make(map[string]string, len(aliasRows))
+	for _, a := range aliasRows {
+		aliases[a.Alias] = a.Symbol
+	}
+	return external_api.NewNameMatcher(companies, aliases), nil
+}
@@ -0,0 +1,43 @@
+package cron
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	external_api "github.com/stuneak/sopeko/cron/external_api"
+	db "github.com/stuneak/sopeko/db/sqlc"
+)
+
+// Instruments of a mention, stored as ticker_mentions.instrument. Option
+// mentions are left out of stock performance.
+const (
+	instrumentStock  = "stock"
+	instrumentOption = "option"
+)
+
+func mentionInstrument(candidate external_api.TickerCandidate) string {
+	if len(candidate.Options) > 0 {
+		return instrumentOption
+	}
+	return instrumentStock
+}
+
+// storeOptions stores the option contracts written on a mention's ticker. A
+// failed one is logged and skipped, the mention itself is kept.
+func storeOptions(ctx context.Context, store *db.Queries, mentionID int64, symbol string, contracts []external_api.OptionContract) {
+	for _, c := range contracts {
+		params := db.CreateMentionOptionParams{
+			MentionID:  mentionID,
+			OptionType: c.Type,
+			Strike:     fmt.Sprintf("%.4f", c.Strike),
+			Written:    c.Written,
+		}
+		if !c.ExpiresAt.IsZero() {
+			params.ExpiresAt = sql.NullTime{Time: c.ExpiresAt, Valid: true}
+		}
+		if err := store.CreateMentionOption(ctx, params); err != nil {
+			clog("error creating option contract for %s: %v", symbol, err)
+		}
+	}
+}
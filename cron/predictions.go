@@ -0,0 +1,256 @@
+package cron
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	external_api "github.com/stuneak/sopeko/cron/external_api"
+	db "github.com/stuneak/sopeko/db/sqlc"
+	"github.com/stuneak/sopeko/pkg/market"
+)
+
+// Prediction statuses, stored as mention_predictions.status.
+const (
+	predictionOpen   = "open"
+	predictionHit    = "hit"
+	predictionMissed = "missed"
+)
+
+// The mention-predictions job checks open predictions against the prices
+// since their mention's entry, mentionPredictionsBatch per run. A prediction
+// waits for its entry to be priced; a multiple then becomes a target price.
+// A miss is only called predictionSettleDelay after the deadline, once the
+// daily bars of the last sessions are in. A prediction that fails to evaluate
+// is left alone for predictionRetryDelay, doubling with every failure in a
+// row up to predictionMaxRetryDelay.
+const (
+	mentionPredictionsJob     = "mention-predictions"
+	mentionPredictionsTimeout = time.Hour
+	mentionPredictionsBatch   = 5000
+	predictionSettleDelay     = 48 * time.Hour
+	predictionRetryDelay      = 6 * time.Hour
+	predictionMaxRetryDelay   = 7 * 24 * time.Hour
+)
+
+// storePredictions stores the predictions made for a mention. A failed one is
+// logged and skipped, the mention itself is kept.
+func storePredictions(ctx context.Context, store *db.Queries, mentionID int64, symbol string, predictions []external_api.Prediction) {
+	for _, p := range predictions {
+		params := db.CreateMentionPredictionParams{
+			MentionID: mentionID,
+			Deadline:  p.Deadline,
+			Claim:     p.Claim,
+		}
+		if p.Multiple > 0 {
+			params.Multiple = sql.NullFloat64{Float64: p.Multiple, Valid: true}
+		} else {
+			params.TargetPrice = sql.NullString{String: fmt.Sprintf("%.4f", p.TargetPrice), Valid: true}
+		}
+		if err := store.CreateMentionPrediction(ctx, params); err != nil {
+			clog("error creating prediction for %s: %v", symbol, err)
+		}
+	}
+}
+
+func (s *Scheduler) evaluatePredictions() {
+	ctx, cancel := context.WithTimeout(context.Background(), mentionPredictionsTimeout)
+	defer cancel()
+
+	startedAt := time.Now()
+	clog("starting mention predictions")
+
+	predictions, err := s.store.ListOpenPredictions(ctx, mentionPredictionsBatch)
+	if err != nil {
+		clog("error fetching predictions from DB: %v", err)
+		return
+	}
+
+	var resolved, failed, skipped int
+	for i, p := range predictions {
+		if ctx.Err() != nil {
+			skipped += len(predictions) - i
+			break
+		}
+		status, err := s.evaluatePrediction(ctx, p, startedAt)
+		if err != nil && ctx.Err() != nil {
+			skipped += len(predictions) - i
+			break
+		}
+		switch {
+		case err != nil:
+			failed++
+			if failed <= 10 {
+				clog("error for prediction %d on %s: %v", p.ID, p.Symbol, err)
+			}
+			err = s.store.SetPredictionFailed(ctx, db.SetPredictionFailedParams{
+				ID:      p.ID,
+				RetryAt: sql.NullTime{Time: startedAt.Add(predictionRetryBackoff(p.Failures)), Valid: true},
+			})
+			if err != nil {
+				clog("error storing failure of prediction %d: %v", p.ID, err)
+			}
+		case status == predictionOpen:
+			skipped++
+		default:
+			resolved++
+		}
+	}
+	if len(predictions) == mentionPredictionsBatch {
+		clog("batch full, the rest is left for the next run")
+	}
+
+	finishedAt := time.Now()
+	clog("done in %s - %d predictions resolved, %d errors, %d still open",
+		finishedAt.Sub(startedAt).Round(time.Second), resolved, failed, skipped)
+
+	saveCtx, saveCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer saveCancel()
+	err = s.store.CreateJobRun(saveCtx, db.CreateJobRunParams{
+		Job:        mentionPredictionsJob,
+		StartedAt:  startedAt,
+		FinishedAt: finishedAt,
+		DurationMs: finishedAt.Sub(startedAt).Milliseconds(),
+		Total:      int32(len(predictions)),
+		Fetched:    int32(resolved),
+		Failed:     int32(failed),
+		Skipped:    int32(skipped),
+	})
+	if err != nil {
+		clog("error saving run summary: %v", err)
+	}
+}
+
+// predictionRetryBackoff is how long a prediction that failed after failures
+// earlier failures in a row waits before it is evaluated again.
+func predictionRetryBackoff(failures int32) time.Duration {
+	backoff := predictionRetryDelay
+	for range failures {
+		if backoff >= predictionMaxRetryDelay {
+			break
+		}
+		backoff *= 2
+	}
+	return min(backoff, predictionMaxRetryDelay)
+}
+
+// evaluatePrediction stores how far the price has moved towards the target
+// and returns the resulting status. Targets above the entry are reached by a
+// session's high, targets below it by a low. Prices after a split are scaled
+// back to the prices at the mention the target was stated in.
+func (s *Scheduler) evaluatePrediction(ctx context.Context, p db.ListOpenPredictionsRow, now time.Time) (string, error) {
+	entry, err := strconv.ParseFloat(p.EntryPrice.String, 64)
+	if err != nil || entry <= 0 || !p.EntryAt.Valid {
+		return "", fmt.Errorf("invalid entry price %q", p.EntryPrice.String)
+	}
+	target := entry * p.Multiple.Float64
+	if p.TargetPrice.Valid {
+		if target, err = strconv.ParseFloat(p.TargetPrice.String, 64); err != nil {
+			return "", fmt.Errorf("invalid target price %q", p.TargetPrice.String)
+		}
+	}
+	if target <= 0 {
+		return "", fmt.Errorf("no target price")
+	}
+
+	entryAt, end := p.EntryAt.Time, now
+	if p.Deadline.Before(end) {
+		end = p.Deadline
+	}
+	splits, err := s.store.GetSplitsByTicker(ctx, p.TickerID)
+	if err != nil {
+		return "", err
+	}
+	entryFactor := splitFactor(splits, market.Day(entryAt))
+	atMention := func(price float64, day time.Time) float64 {
+		return price * splitFactor(splits, day) / entryFactor
+	}
+
+	// progress is the share of the move from the entry to the target made
+	// at price
+	upside := target > entry
+	progress := func(price float64) float64 {
+		if upside {
+			return (price - entry) / (target - entry)
+		}
+		return (entry - price) / (entry - target)
+	}
+
+	var best float64
+	var hitAt time.Time
+	covered := entryAt
+	if target == entry {
+		best, hitAt = 1, entryAt
+	} else if !end.Before(entryAt) {
+		bars, err := s.store.ListDailyBarsBetween(ctx, db.ListDailyBarsBetweenParams{
+			TickerID: p.TickerID,
+			FromDay:  market.Day(entryAt),
+			ToDay:    market.Day(end),
+		})
+		if err != nil {
+			return "", err
+		}
+		for _, bar := range bars {
+			// Skip sessions that ended by the entry or opened after the deadline
+			if !bar.ClosedAt.After(entryAt) || !bar.OpenedAt.Before(p.Deadline) {
+				continue
+			}
+			extreme := bar.High
+			if !upside {
+				extreme = bar.Low
+			}
+			price, err := strconv.ParseFloat(extreme, 64)
+			if err != nil {
+				continue
+			}
+			best = max(best, progress(atMention(price, bar.Day)))
+			if best >= 1 && hitAt.IsZero() {
+				hitAt = bar.ClosedAt
+			}
+			covered = bar.ClosedAt
+		}
+
+		// Snapshots of the sessions no bar covers yet
+		points, err := s.store.ListTickerPricesBetween(ctx, db.ListTickerPricesBetweenParams{
+			TickerID: p.TickerID,
+			FromTime: covered,
+			ToTime:   end,
+		})
+		if err != nil {
+			return "", err
+		}
+		for _, point := range points {
+			price, err := strconv.ParseFloat(point.Price, 64)
+			if err != nil {
+				continue
+			}
+			best = max(best, progress(atMention(price, market.Day(point.RecordedAt))))
+			if best >= 1 && hitAt.IsZero() {
+				hitAt = point.RecordedAt
+			}
+		}
+	}
+
+	status, resolvedAt := predictionOpen, sql.NullTime{}
+	switch {
+	case best >= 1:
+		status, resolvedAt = predictionHit, sql.NullTime{Time: hitAt, Valid: true}
+	case now.After(p.Deadline.Add(predictionSettleDelay)):
+		status, resolvedAt = predictionMissed, sql.NullTime{Time: p.Deadline, Valid: true}
+	}
+
+	err = s.store.SetPredictionResult(ctx, db.SetPredictionResultParams{
+		ID:          p.ID,
+		TargetPrice: sql.NullString{String: fmt.Sprintf("%.4f", target), Valid: true},
+		Status:      status,
+		Progress:    math.Round(min(best, 1)*10000) / 10000,
+		ResolvedAt:  resolvedAt,
+	})
+	if err != nil {
+		return "", fmt.Errorf("storing result: %w", err)
+	}
+	return status, nil
+}
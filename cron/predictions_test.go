@@ -0,0 +1,25 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPredictionRetryBackoff(t *testing.T) {
+	tests := []struct {
+		failures int32
+		want     time.Duration
+	}{
+		{0, 6 * time.Hour},
+		{1, 12 * time.Hour},
+		{2, 24 * time.Hour},
+		{4, 96 * time.Hour},
+		{5, predictionMaxRetryDelay},
+		{1000, predictionMaxRetryDelay},
+	}
+	for _, tt := range tests {
+		if got := predictionRetryBackoff(tt.failures); got != tt.want {
+			t.Errorf("predictionRetryBackoff(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
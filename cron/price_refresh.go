@@ -0,0 +1,241 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	external_api "github.com/stuneak/sopeko/cron/external_api"
+	db "github.com/stuneak/sopeko/db/sqlc"
+)
+
+// A price refresh walks the tickers that are due with a pool of workers that
+// share one token bucket, so providers see the same request rate however many
+// workers run. Recently mentioned tickers go first, so a run cut short by the
+// timeout only misses prices nobody looks at.
+const (
+	priceRefreshJob     = "ticker-prices"
+	priceRefreshTimeout = 3 * time.Hour
+	priceFetchAttempts  = 3
+	priceRetryBackoff   = 2 * time.Second
+
+	// priceRefreshSlack lets warm tickers through a little early, so the drift
+	// between runs does not push them back a whole run.
+	priceRefreshSlack = time.Hour
+)
+
+// benchmarkExchange marks the benchmark symbols in ticker_names.
+const benchmarkExchange = "BENCHMARK"
+
+// Price tiers decide how often a ticker's price is refreshed.
+const (
+	tierHot  = "hot"  // benchmarks and tickers mentioned within the hot window: every run
+	tierWarm = "warm" // mentioned before that: once per warm interval
+	tierCold = "cold" // never mentioned: not refreshed, priced at its first mention
+)
+
+type priceTiers struct {
+	hotWindow    time.Duration
+	warmInterval time.Duration
+}
+
+// tier returns the tier of a ticker and whether its price is due at now.
+func (p priceTiers) tier(t db.ListTickersByMentionPriorityRow, now time.Time) (string, bool) {
+	switch {
+	case t.Exchange == benchmarkExchange:
+		return tierHot, true
+	case t.LastMentionedAt.Valid && now.Sub(t.LastMentionedAt.Time) < p.hotWindow:
+		return tierHot, true
+	case t.MentionCount > 0:
+		due := !t.PriceRefreshedAt.Valid || now.Sub(t.PriceRefreshedAt.Time) >= p.warmInterval-priceRefreshSlack
+		return tierWarm, due
+	default:
+		return tierCold, false
+	}
+}
+
+func (s *Scheduler) fetchTickerPrices() {
+	ctx, cancel := context.WithTimeout(context.Background(), priceRefreshTimeout)
+	defer cancel()
+
+	startedAt := time.Now()
+	clog("starting ticker prices fetch")
+
+	all, err := s.store.ListTickersByMentionPriority(ctx)
+	if err != nil {
+		clog("error fetching tickers from DB: %v", err)
+		return
+	}
+
+	var tickers []db.ListTickersByMentionPriorityRow
+	counts := make(map[string]int)
+	for _, ticker := range all {
+		tier, due := s.priceTiers.tier(ticker, startedAt)
+		if due {
+			counts[tier]++
+			tickers = append(tickers, ticker)
+		}
+	}
+	clog("fetching prices for %d tickers (%d hot, %d warm due, %d not due) with %d workers",
+		len(tickers), counts[tierHot], counts[tierWarm], len(all)-len(tickers), s.priceWorkers)
+
+	var fetched, failed, skipped atomic.Int64
+	queue := make(chan db.ListTickersByMentionPriorityRow)
+
+	var wg sync.WaitGroup
+	for range s.priceWorkers {
+		wg.Go(func() {
+			for ticker := range queue {
+				// Out of time: count the rest as skipped instead of dropping them silently
+				if ctx.Err() != nil {
+					skipped.Add(1)
+					continue
+				}
+				if err := s.refreshTickerPrice(ctx, ticker.ID, ticker.Symbol); err != nil {
+					if ctx.Err() != nil {
+						skipped.Add(1)
+						continue
+					}
+					if failed.Add(1) <= 10 {
+						clog("error for %s: %v", ticker.Symbol, err)
+					}
+					continue
+				}
+				fetched.Add(1)
+			}
+		})
+	}
+
+	for i, ticker := range tickers {
+		if strings.Contains(ticker.Symbol, "^") || strings.Contains(ticker.Symbol, "/") {
+			skipped.Add(1)
+			continue
+		}
+		if i > 0 && i%500 == 0 {
+			clog("progress %d/%d (%d fetched, %d errors)", i, len(tickers), fetched.Load(), failed.Load())
+		}
+		queue <- ticker
+	}
+	close(queue)
+	wg.Wait()
+
+	finishedAt := time.Now()
+	clog("done in %s - %d fetched, %d errors, %d skipped",
+		finishedAt.Sub(startedAt).Round(time.Second), fetched.Load(), failed.Load(), skipped.Load())
+	if ctx.Err() != nil {
+		clog("timed out after %s, lowest priority tickers were skipped", priceRefreshTimeout)
+	}
+
+	// The run context may have expired, record the summary with a fresh one
+	saveCtx, saveCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer saveCancel()
+	err = s.store.CreateJobRun(saveCtx, db.CreateJobRunParams{
+		Job:        priceRefreshJob,
+		StartedAt:  startedAt,
+		FinishedAt: finishedAt,
+		DurationMs: finishedAt.Sub(startedAt).Milliseconds(),
+		Total:      int32(len(tickers)),
+		Fetched:    int32(fetched.Load()),
+		Failed:     int32(failed.Load()),
+		Skipped:    int32(skipped.Load()),
+	})
+	if err != nil {
+		clog("error saving run summary: %v", err)
+	}
+
+	// Leaderboards depend on the latest prices, rebuild them right away
+	if s.snapshotJob != nil {
+		if err := s.snapshotJob.RunNow(); err != nil {
+			clog("error triggering leaderboard snapshots: %v", err)
+		}
+	}
+}
+
+// refreshTickerPrice fetches the current price of one ticker, replaces
+// today's stored price with it and marks the ticker as refreshed.
+func (s *Scheduler) refreshTickerPrice(ctx context.Context, tickerID int64, symbol string) error {
+	price, volume, recordedAt, err := s.fetchCurrentPrice(ctx, symbol)
+	if err != nil {
+		return err
+	}
+
+	// Delete existing price for this ticker on the same day before inserting
+	s.store.DeleteTickerPriceByDate(ctx, db.DeleteTickerPriceByDateParams{
+		TickerID: tickerID,
+		Date:     recordedAt,
+	})
+
+	_, err = s.store.InsertTickerPrice(ctx, db.InsertTickerPriceParams{
+		TickerID:   tickerID,
+		Price:      fmt.Sprintf("%.2f", price),
+		Volume:     volume,
+		RecordedAt: recordedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("inserting price: %w", err)
+	}
+	return s.store.MarkTickerPriceRefreshed(ctx, tickerID)
+}
+
+// fetchCurrentPrice asks the providers for a price, retrying with exponential
+// backoff and jitter. Every attempt takes a token from the shared limiter.
+func (s *Scheduler) fetchCurrentPrice(ctx context.Context, symbol string) (price float64, volume int64, recordedAt time.Time, err error) {
+	backoff := priceRetryBackoff
+	for attempt := 1; ; attempt++ {
+		if err = s.priceLimiter.Wait(ctx); err != nil {
+			return 0, 0, time.Time{}, err
+		}
+		price, volume, recordedAt, err = s.prices.FetchCurrentPriceAndVolume(ctx, symbol)
+		// No data is an answer, asking again will not change it
+		if err == nil || errors.Is(err, external_api.ErrNoData) || attempt == priceFetchAttempts {
+			return price, volume, recordedAt, err
+		}
+
+		jitter := rand.N(backoff / 2)
+		if err := external_api.SleepContext(ctx, backoff+jitter); err != nil {
+			return 0, 0, time.Time{}, err
+		}
+		backoff *= 2
+	}
+}
+
+// tokenBucket allows rate requests per second on average, with bursts of up
+// to burst requests.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait takes a token, blocking until it is available. Tokens are reserved
+// up front, so concurrent callers queue up in arrival order.
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	return external_api.SleepContext(ctx, wait)
+}
@@ -2,33 +2,104 @@ package cron
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-co-op/gocron/v2"
+	"github.com/stuneak/sopeko/config"
 	external_api "github.com/stuneak/sopeko/cron/external_api"
 	db "github.com/stuneak/sopeko/db/sqlc"
 	"github.com/stuneak/sopeko/pkg/logger"
+	"github.com/stuneak/sopeko/pkg/source"
 )
 
 var clog = logger.NewLogger("CRON")
 
-var subreddits = []string{
-	"pennystocks",
-	"investing",
-	"stocks",
-}
+// Subreddits are managed in the subreddits table. Each enabled subreddit gets
+// its own scrape job; the first runs start redditFirstRunDelay after a sync and
+// are spread evenly over the shortest scrape interval.
+const (
+	redditSyncInterval  = 5 * time.Minute
+	redditFirstRunDelay = 15 * time.Minute
+)
+
+// StockTwits streams and RSS/Atom feeds are scraped on the intervals from the
+// config. The first runs are spread over feedFirstRunSpread; a run never goes
+// back further than feedLookback.
+const (
+	feedFirstRunSpread = 10 * time.Minute
+	feedLookback       = 24 * time.Hour
+)
+
+// Threads are revisited until they are threadMaxAge old, at most
+// threadRevisitLimit per subreddit and run.
+const (
+	threadRevisitMin   = time.Hour
+	threadRevisitMax   = 24 * time.Hour
+	threadMaxAge       = 7 * 24 * time.Hour
+	threadRevisitLimit = 25
+)
+
+// Leaderboard snapshots are materialized for every period the API accepts
+// (see parsePeriod in the api package), at the default horizon (the latest
+// price) and against the default benchmark. Other horizons and benchmarks are
+// rarely asked for and computed live.
+var leaderboardPeriods = []string{"daily", "weekly", "monthly", "all"}
+
+const leaderboardSnapshotHorizon = 0
+
+// A spike is a day on which at least earlySpikeMinUsers distinct users mention
+// a ticker and that count is earlySpikeMultiplier times its trailing 30-day
+// daily average. Users whose first mention falls within earlyCallLookbackDays
+// before the spike are credited with an early call.
+const (
+	earlySpikeMinUsers    = 5
+	earlySpikeMultiplier  = 3.0
+	earlyCallLookbackDays = 30
+)
 
 type Scheduler struct {
 	scheduler     gocron.Scheduler
 	store         *db.Queries
 	redditScraper *external_api.RedditScraper
 	nasdaqFetcher *external_api.NasdaqFetcher
-	yahooFetcher  *external_api.YahooFetcher
+	prices        *external_api.PriceChain
+	priceWorkers  int
+	priceLimiter  *tokenBucket
+	priceTiers    priceTiers
+	entryRule     string
+	minConfidence float64
+	names         *nameCache
+	benchmarks    []string
+	snapshotJob   gocron.Job
+
+	redditMu   sync.Mutex
+	redditJobs map[string]redditJob
+
+	feeds []sourceFeed
 }
 
-func NewScheduler(store *db.Queries) (*Scheduler, error) {
+// sourceFeed is a non-Reddit feed scraped on a fixed schedule. Its checkpoint
+// is stored under (source name, key, "items").
+type sourceFeed struct {
+	source   external_api.Source
+	key      string
+	interval time.Duration
+}
+
+// redditJob is a registered scrape job along with the settings it was created
+// from, so syncRedditJobs can tell when a subreddit was changed.
+type redditJob struct {
+	job      gocron.Job
+	interval time.Duration
+	lookback time.Duration
+}
+
+func NewScheduler(store *db.Queries, cfg config.Config) (*Scheduler, error) {
 	usEastern, err := time.LoadLocation("America/New_York")
 	if err != nil {
 		return nil, err
@@ -39,54 +110,255 @@ func NewScheduler(store *db.Queries) (*Scheduler, error) {
 		return nil, err
 	}
 
+	redditScraper := external_api.NewRedditScraper(external_api.RedditConfig{
+		ClientID:     cfg.RedditClientID,
+		ClientSecret: cfg.RedditClientSecret,
+		Username:     cfg.RedditUsername,
+		Password:     cfg.RedditPassword,
+		UserAgent:    cfg.RedditUserAgent,
+	})
+
+	providers, err := external_api.NewPriceProviders(cfg.PriceProviders, cfg.PriceCSVDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if !validEntryRule(cfg.EntryRule) {
+		return nil, fmt.Errorf("unknown entry rule %q", cfg.EntryRule)
+	}
+
+	var feeds []sourceFeed
+	for _, stream := range cfg.StockTwitsStreams {
+		feeds = append(feeds, sourceFeed{
+			source:   external_api.NewStockTwitsSource(stream, ""),
+			key:      stream,
+			interval: cfg.StockTwitsInterval,
+		})
+	}
+	for _, feedURL := range cfg.RSSFeeds {
+		feeds = append(feeds, sourceFeed{
+			source:   external_api.NewFeedSource(feedURL),
+			key:      feedURL,
+			interval: cfg.RSSInterval,
+		})
+	}
+
 	return &Scheduler{
 		scheduler:     s,
 		store:         store,
-		redditScraper: external_api.NewRedditScraper(),
+		redditScraper: redditScraper,
 		nasdaqFetcher: external_api.NewNasdaqFetcher(),
-		yahooFetcher:  external_api.NewYahooFetcher(),
+		prices:        external_api.NewPriceChain(providers...),
+		priceWorkers:  cfg.PriceWorkers,
+		priceLimiter:  newTokenBucket(cfg.PriceRateLimit, cfg.PriceWorkers),
+		priceTiers: priceTiers{
+			hotWindow:    time.Duration(cfg.PriceHotDays) * 24 * time.Hour,
+			warmInterval: cfg.PriceWarmInterval,
+		},
+		entryRule:     cfg.EntryRule,
+		minConfidence: cfg.TickerMinConfidence,
+		names:         &nameCache{},
+		benchmarks:    cfg.BenchmarkSymbols,
+		redditJobs:    make(map[string]redditJob),
+		feeds:         feeds,
 	}, nil
 }
 
-func (s *Scheduler) fetchSubreddit(subreddit string) {
+func (s *Scheduler) fetchSubreddit(subreddit string, lookback time.Duration) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
 	defer cancel()
 	clog("starting r/%s", subreddit)
 
-	posts, comments, err := s.redditScraper.ScrapeSubreddit(ctx, subreddit)
+	now := time.Now()
+	cutoff := now.Add(-lookback)
+
+	posts, postsCheckpoint, err := s.redditScraper.Posts(subreddit).Fetch(ctx, s.loadCheckpoint(ctx, source.Reddit, subreddit, "posts"), cutoff)
+	if err != nil {
+		clog("error fetching posts r/%s: %v", subreddit, err)
+		return
+	}
+
+	streamed, commentsCheckpoint, err := s.redditScraper.Comments(subreddit).Fetch(ctx, s.loadCheckpoint(ctx, source.Reddit, subreddit, "comments"), cutoff)
 	if err != nil {
-		clog("error scraping r/%s: %v", subreddit, err)
+		clog("error fetching comments r/%s: %v", subreddit, err)
 		return
 	}
 
-	clog("scraped r/%s: %d posts, %d comments", subreddit, len(posts), len(comments))
+	revisited := s.revisitThreads(ctx, subreddit, now)
+	comments := append(streamed, revisited...)
+
+	clog("scraped r/%s: %d new posts, %d new comments, %d from revisited threads", subreddit, len(posts), len(streamed), len(revisited))
 
-	// Process posts as comments (title + selftext)
+	// Posts are processed as comments (title + selftext)
 	for _, post := range posts {
-		if post.Author == "" || post.Author == "[deleted]" {
-			continue
+		s.processContent(ctx, source.Reddit, post)
+	}
+
+	for _, post := range posts {
+		err := s.store.CreateRedditThread(ctx, db.CreateRedditThreadParams{
+			PostID:      post.ID,
+			Subreddit:   subreddit,
+			PostedAt:    post.CreatedAt.UTC(),
+			NextVisitAt: now.Add(threadRevisitMin).UTC(),
+		})
+		if err != nil {
+			clog("error tracking thread %s: %v", post.ID, err)
 		}
-		s.processRedditContent(ctx, post.Author, post.ID, post.Title+" "+post.Selftext, post.CreatedAt, "reddit")
 	}
 
 	// Process comments
 	for _, comment := range comments {
-		if comment.Author == "" || comment.Author == "[deleted]" {
-			continue
+		s.processContent(ctx, source.Reddit, comment)
+	}
+
+	for _, comment := range streamed {
+		err := s.store.TouchRedditThread(ctx, db.TouchRedditThreadParams{
+			PostID:        comment.PostID,
+			LastCommentAt: comment.CreatedAt.UTC(),
+		})
+		if err != nil {
+			clog("error touching thread %s: %v", comment.PostID, err)
 		}
-		s.processRedditContent(ctx, comment.Author, comment.ID, comment.Body, comment.CreatedAt, "reddit")
 	}
 
+	// Checkpoints only move once everything up to them has been processed
+	s.saveCheckpoint(ctx, source.Reddit, subreddit, "posts", postsCheckpoint)
+	s.saveCheckpoint(ctx, source.Reddit, subreddit, "comments", commentsCheckpoint)
+
 	clog("finished r/%s", subreddit)
 }
 
-func (s *Scheduler) processRedditContent(ctx context.Context, author, externalID, content string, createdAt time.Time, source string) {
-	clog("processing author=%s externalID=%s source=%s", author, externalID, source)
+// scrapeFeed fetches and processes everything new in a non-Reddit feed.
+func (s *Scheduler) scrapeFeed(feed sourceFeed) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	name := feed.source.Name()
+	clog("starting %s %s", name, feed.key)
+
+	since := s.loadCheckpoint(ctx, name, feed.key, "items")
+	items, checkpoint, err := feed.source.Fetch(ctx, since, time.Now().Add(-feedLookback))
+	if err != nil {
+		clog("error fetching %s %s: %v", name, feed.key, err)
+		return
+	}
+
+	for _, item := range items {
+		s.processContent(ctx, name, item)
+	}
+	s.saveCheckpoint(ctx, name, feed.key, "items", checkpoint)
+
+	clog("finished %s %s: %d new items", name, feed.key, len(items))
+}
+
+func (s *Scheduler) loadCheckpoint(ctx context.Context, name, feed, stream string) external_api.Checkpoint {
+	checkpoint, err := s.store.GetScrapeCheckpoint(ctx, db.GetScrapeCheckpointParams{
+		Source: name,
+		Feed:   feed,
+		Stream: stream,
+	})
+	if err != nil {
+		return external_api.Checkpoint{}
+	}
+	return external_api.Checkpoint{
+		ID:        checkpoint.ItemID,
+		CreatedAt: checkpoint.CreatedAt,
+	}
+}
+
+// saveCheckpoint stores the checkpoint returned by a fetch, unless the feed
+// has never returned anything.
+func (s *Scheduler) saveCheckpoint(ctx context.Context, name, feed, stream string, checkpoint external_api.Checkpoint) {
+	if checkpoint.ID == "" {
+		return
+	}
+	err := s.store.UpsertScrapeCheckpoint(ctx, db.UpsertScrapeCheckpointParams{
+		Source:    name,
+		Feed:      feed,
+		Stream:    stream,
+		ItemID:    checkpoint.ID,
+		CreatedAt: checkpoint.CreatedAt.UTC(),
+	})
+	if err != nil {
+		clog("error saving %s checkpoint %s %s: %v", stream, name, feed, err)
+	}
+}
+
+// revisitThreads re-fetches the comment trees of recent threads that are due,
+// returning comments at or after each thread's newest known comment. Busy
+// subreddits can outrun the comment stream between runs; this picks up what
+// it missed.
+func (s *Scheduler) revisitThreads(ctx context.Context, subreddit string, now time.Time) []external_api.SourceItem {
+	threads, err := s.store.ListDueRedditThreads(ctx, db.ListDueRedditThreadsParams{
+		Subreddit:   subreddit,
+		NextVisitAt: now.UTC(),
+		PostedAt:    now.Add(-threadMaxAge).UTC(),
+		Limit:       threadRevisitLimit,
+	})
+	if err != nil {
+		clog("error listing threads r/%s: %v", subreddit, err)
+		return nil
+	}
+
+	var comments []external_api.SourceItem
+	for _, thread := range threads {
+		tree, err := s.redditScraper.FetchPostComments(ctx, subreddit, thread.PostID)
+		if err != nil {
+			clog("error revisiting thread %s: %v", thread.PostID, err)
+			continue
+		}
+
+		newest := thread.LastCommentAt
+		for _, c := range tree {
+			if !c.CreatedAt.Before(thread.LastCommentAt) {
+				comments = append(comments, c.SourceItem())
+			}
+			if c.CreatedAt.After(newest) {
+				newest = c.CreatedAt
+			}
+		}
+
+		err = s.store.UpdateRedditThreadVisit(ctx, db.UpdateRedditThreadVisitParams{
+			PostID:        thread.PostID,
+			LastCommentAt: newest.UTC(),
+			NextVisitAt:   now.Add(threadRevisitInterval(now.Sub(newest))).UTC(),
+		})
+		if err != nil {
+			clog("error updating thread %s: %v", thread.PostID, err)
+		}
+	}
+
+	return comments
+}
+
+// threadRevisitInterval waits half as long as the thread has been quiet, so
+// active threads are revisited often and dead ones rarely.
+func threadRevisitInterval(quiet time.Duration) time.Duration {
+	return min(max(quiet/2, threadRevisitMin), threadRevisitMax)
+}
+
+// processContent stores a post or comment with its ticker mentions. name is
+// the source it came from.
+func (s *Scheduler) processContent(ctx context.Context, name string, item external_api.SourceItem) {
+	if item.Author == "" || item.Author == "[deleted]" {
+		return
+	}
+
+	store := s.store
+	author := source.Username(name, item.Author)
+	externalID, content, createdAt := item.ID, item.Body, item.CreatedAt
+	clog("processing author=%s externalID=%s source=%s", author, externalID, name)
+
+	// Only Reddit content is attributed to a subreddit
+	var subreddit string
+	if name == source.Reddit {
+		subreddit = item.Subreddit
+	}
 
 	// Upsert user
-	user, err := s.store.GetUserByUsername(ctx, author)
+	user, err := store.GetUserByUsername(ctx, author)
 	if err != nil {
-		user, err = s.store.CreateUser(ctx, author)
+		user, err = store.CreateUser(ctx, author)
 		if err != nil {
 			clog("error creating user %s: %v", author, err)
 			return
@@ -95,7 +367,7 @@ func (s *Scheduler) processRedditContent(ctx context.Context, author, externalID
 	}
 
 	// Check if comment already exists
-	_, err = s.store.GetCommentByUserAndExternalID(ctx, db.GetCommentByUserAndExternalIDParams{
+	_, err = store.GetCommentByUserAndExternalID(ctx, db.GetCommentByUserAndExternalIDParams{
 		UserID:     user.ID,
 		ExternalID: externalID,
 	})
@@ -105,58 +377,112 @@ func (s *Scheduler) processRedditContent(ctx context.Context, author, externalID
 	}
 
 	// Create comment
-	comment, err := s.store.CreateComment(ctx, db.CreateCommentParams{
+	comment, err := store.CreateComment(ctx, db.CreateCommentParams{
 		UserID:     user.ID,
-		Source:     source,
+		Source:     name,
 		ExternalID: externalID,
 		Content:    content,
 		CreatedAt:  createdAt,
+		Kind:       item.Kind,
+		Subreddit:  subreddit,
+		Permalink:  item.URL,
+		PostID:     item.PostID,
+		ParentID:   item.ParentID,
+		Score:      int32(item.Score),
 	})
 	if err != nil {
 		clog("error creating comment externalID=%s: %v", externalID, err)
 		return
 	}
 
-	// Extract tickers and create mentions
-	tickers := external_api.ExtractTickers(content)
-	clog("extracted %d tickers from externalID=%s", len(tickers), externalID)
+	mentions := s.findMentions(ctx, make(map[string]db.TickerName), content, createdAt, externalID)
+
+	var mentioned int
+	for _, m := range mentions {
+		symbol, candidate := m.ticker.Symbol, m.candidate
+		mention, err := store.CreateTickerMention(ctx, db.CreateTickerMentionParams{
+			TickerID:    m.ticker.ID,
+			UserID:      user.ID,
+			CommentID:   comment.ID,
+			MentionedAt: createdAt,
+			Subreddit:   strings.ToLower(subreddit),
+			Confidence:  sql.NullFloat64{Float64: candidate.Confidence, Valid: true},
+			DetectedBy:  candidate.DetectedBy,
+			Stance:      candidate.Stance,
+			Instrument:  mentionInstrument(candidate),
+		})
+		if err != nil {
+			clog("error creating mention for %s: %v", symbol, err)
+			continue
+		}
+		clog("created mention for %s by %s (%s, %s)", symbol, author, candidate.DetectedBy, candidate.Stance)
+		mentioned++
+		storePredictions(ctx, store, mention.ID, symbol, candidate.Predictions)
+		storeOptions(ctx, store, mention.ID, symbol, candidate.Options)
 
-	for _, symbol := range tickers {
+		if _, err := s.setMentionEntry(ctx, store, mention.ID, m.ticker.ID, symbol, createdAt); err != nil {
+			clog("error setting entry for %s, left to the %s job: %v", symbol, mentionEntriesJob, err)
+		}
+	}
+
+	// Benchmarks need a price at the entry too, so excess returns can be computed
+	if mentioned > 0 {
+		s.fetchBenchmarkEntries(ctx, entryTime(s.entryRule, createdAt))
+	}
+}
+
+// contentMention is a confident mention of a listed ticker.
+type contentMention struct {
+	ticker    db.TickerName
+	candidate external_api.TickerCandidate
+}
+
+// findMentions extracts tickers by symbol and by name and keeps the confident
+// ones that are listed. listed caches the tickers looked up by symbol.
+func (s *Scheduler) findMentions(ctx context.Context, listed map[string]db.TickerName, content string, createdAt time.Time, externalID string) []contentMention {
+	lookup := func(symbol string) (string, bool) {
+		if ticker, ok := listed[symbol]; ok {
+			return ticker.CompanyName, true
+		}
 		ticker, err := s.store.GetTickerBySymbol(ctx, symbol)
 		if err != nil {
+			return "", false
+		}
+		listed[symbol] = ticker
+		return ticker.CompanyName, true
+	}
+	candidates := external_api.ExtractMentions(content, createdAt, lookup, s.nameMatcher(ctx))
+	clog("extracted %d tickers from externalID=%s", len(candidates), externalID)
+
+	var mentions []contentMention
+	for _, candidate := range candidates {
+		symbol := candidate.Symbol
+		if candidate.Confidence < s.minConfidence {
+			clog("ticker %s below confidence (%.2f), skipping", symbol, candidate.Confidence)
+			continue
+		}
+		// Aliases may point at symbols that are not listed (anymore)
+		if _, ok := lookup(symbol); !ok {
 			clog("ticker %s not in database, skipping", symbol)
 			continue
 		}
+		mentions = append(mentions, contentMention{ticker: listed[symbol], candidate: candidate})
+	}
+	return mentions
+}
 
-		// Ensure a ticker price exists before createdAt
-		_, err = s.store.GetTickerPriceBeforeDate(ctx, db.GetTickerPriceBeforeDateParams{
-			TickerID:   ticker.ID,
-			RecordedAt: createdAt,
-		})
+// fetchBenchmarkEntries makes sure the benchmarks have a bar for the session
+// of entryAt.
+func (s *Scheduler) fetchBenchmarkEntries(ctx context.Context, entryAt time.Time) {
+	for _, symbol := range s.benchmarks {
+		ticker, err := s.store.GetTickerBySymbol(ctx, symbol)
 		if err != nil {
-			// No price found, fetch from Yahoo and store
-			clog("no price for %s before %s, fetching from Yahoo", symbol, createdAt.Format("2006-01-02"))
-			price, volume, recordedAt, fetchErr := s.yahooFetcher.FetchHistoricalPrice(ctx, symbol, createdAt)
-			if fetchErr == nil {
-				s.store.InsertTickerPrice(ctx, db.InsertTickerPriceParams{
-					TickerID:   ticker.ID,
-					Price:      fmt.Sprintf("%.2f", price),
-					Volume:     volume,
-					RecordedAt: recordedAt,
-				})
-				clog("stored historical price for %s: %.2f", symbol, price)
-			} else {
-				clog("failed to fetch historical price for %s: %v", symbol, fetchErr)
-			}
+			clog("benchmark %s not in database, skipping", symbol)
+			continue
+		}
+		if _, err := s.entryBar(ctx, s.store, ticker.ID, ticker.Symbol, entryAt); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			clog("error fetching entry bar for %s: %v", symbol, err)
 		}
-
-		s.store.CreateTickerMention(ctx, db.CreateTickerMentionParams{
-			TickerID:    ticker.ID,
-			UserID:      user.ID,
-			CommentID:   comment.ID,
-			MentionedAt: createdAt,
-		})
-		clog("created mention for %s by %s", symbol, author)
 	}
 }
 
@@ -166,6 +492,18 @@ func (s *Scheduler) fetchTickerNames() {
 
 	clog("starting NASDAQ tickers sync")
 
+	// Benchmarks are not part of the NASDAQ screener, register them explicitly
+	for _, symbol := range s.benchmarks {
+		err := s.store.UpsertTicker(ctx, db.UpsertTickerParams{
+			Symbol:      symbol,
+			CompanyName: symbol,
+			Exchange:    benchmarkExchange,
+		})
+		if err != nil {
+			clog("error upserting benchmark %s: %v", symbol, err)
+		}
+	}
+
 	stocks, err := s.nasdaqFetcher.FetchTickers(ctx)
 	if err != nil {
 		clog("error fetching NASDAQ stocks: %v", err)
@@ -195,112 +533,251 @@ func (s *Scheduler) fetchTickerNames() {
 	clog("done - synced %d, skipped %d (contained ^ or /)", synced, skipped)
 }
 
-func (s *Scheduler) fetchTickerPrices() {
+func (s *Scheduler) fetchTickerSplits() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
 	defer cancel()
 
-	clog("starting ticker prices fetch")
+	clog("starting ticker splits fetch")
 
-	tickers, err := s.store.ListAllTickers(ctx)
+	all, err := s.store.ListTickersByMentionPriority(ctx)
 	if err != nil {
 		clog("error fetching tickers from DB: %v", err)
 		return
 	}
 
-	clog("fetching prices for %d tickers", len(tickers))
+	// Cold tickers have no prices to adjust, their splits are not needed
+	now := time.Now()
+	var tickers []db.ListTickersByMentionPriorityRow
+	for _, ticker := range all {
+		if tier, _ := s.priceTiers.tier(ticker, now); tier != tierCold {
+			tickers = append(tickers, ticker)
+		}
+	}
 
-	var fetched int
-	var errorSymbols []string
+	clog("processing %d of %d tickers for splits", len(tickers), len(all))
 
+	var fetched, fetchErrors, insertErrors int
 	for i, ticker := range tickers {
 		if strings.Contains(ticker.Symbol, "^") || strings.Contains(ticker.Symbol, "/") {
 			continue
 		}
 
 		if i > 0 && i%100 == 0 {
-			clog("progress %d/%d (%d fetched, %d errors)", i, len(tickers), fetched, len(errorSymbols))
+			clog("progress %d/%d (%d splits, %d fetch errors, %d insert errors)", i, len(tickers), fetched, fetchErrors, insertErrors)
 		}
 
-		price, volume, recordedAt, err := s.yahooFetcher.FetchCurrentPriceAndVolume(ctx, ticker.Symbol)
+		splits, err := s.prices.FetchSplits(ctx, ticker.Symbol)
 		if err != nil {
-			if len(errorSymbols) < 10 {
+			fetchErrors++
+			if fetchErrors <= 10 {
 				clog("error for %s: %v", ticker.Symbol, err)
 			}
-			errorSymbols = append(errorSymbols, ticker.Symbol)
 			continue
 		}
 
-		// Delete existing price for this ticker on the same day before inserting
-		s.store.DeleteTickerPriceByDate(ctx, db.DeleteTickerPriceByDateParams{
-			TickerID: ticker.ID,
-			Date:     recordedAt,
+		for _, split := range splits {
+			err = s.store.InsertTickerSplit(ctx, db.InsertTickerSplitParams{
+				TickerID:      ticker.ID,
+				Ratio:         fmt.Sprintf("%.4f", split.Ratio),
+				EffectiveDate: split.EffectiveDate,
+			})
+			if err != nil {
+				insertErrors++
+				continue
+			}
+			fetched++
+		}
+	}
+
+	clog("done - %d splits stored, %d fetch errors, %d insert errors", fetched, fetchErrors, insertErrors)
+}
+
+func (s *Scheduler) refreshLeaderboardSnapshots() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	clog("starting leaderboard snapshots")
+
+	benchmark := ""
+	if len(s.benchmarks) > 0 {
+		benchmark = s.benchmarks[0]
+	}
+
+	generatedAt := time.Now()
+	var snapshots, rows, failed int
+	for _, period := range leaderboardPeriods {
+		inserted, err := s.store.InsertLeaderboardSnapshot(ctx, db.InsertLeaderboardSnapshotParams{
+			Period:      period,
+			HorizonDays: leaderboardSnapshotHorizon,
+			Benchmark:   benchmark,
+			GeneratedAt: generatedAt,
+			MentionedAt: leaderboardPeriodCutoff(period, generatedAt),
 		})
+		if err != nil {
+			clog("error building snapshot period=%s: %v", period, err)
+			failed++
+			continue
+		}
 
-		_, err = s.store.InsertTickerPrice(ctx, db.InsertTickerPriceParams{
-			TickerID:   ticker.ID,
-			Price:      fmt.Sprintf("%.2f", price),
-			Volume:     volume,
-			RecordedAt: recordedAt,
+		// Readers switch to the new snapshot once it is complete, even when
+		// it has no rows
+		err = s.store.SetLeaderboardGeneration(ctx, db.SetLeaderboardGenerationParams{
+			Period:      period,
+			HorizonDays: leaderboardSnapshotHorizon,
+			Benchmark:   benchmark,
+			GeneratedAt: generatedAt,
 		})
 		if err != nil {
-			clog("error inserting price for %s: %v", ticker.Symbol, err)
-			errorSymbols = append(errorSymbols, ticker.Symbol)
+			clog("error publishing snapshot period=%s: %v", period, err)
+			failed++
 			continue
 		}
-		fetched++
+
+		snapshots++
+		rows += int(inserted)
 	}
 
-	clog("done - %d fetched, %d errors", fetched, len(errorSymbols))
+	// Drop the keys of an earlier default benchmark, then every row no
+	// generation points at
+	err := s.store.DeleteLeaderboardGenerationsExcept(ctx, db.DeleteLeaderboardGenerationsExceptParams{
+		HorizonDays: leaderboardSnapshotHorizon,
+		Benchmark:   benchmark,
+	})
+	if err != nil {
+		clog("error deleting old leaderboard keys: %v", err)
+	}
+	if err := s.store.DeleteStaleLeaderboardSnapshots(ctx); err != nil {
+		clog("error deleting stale snapshots: %v", err)
+	}
+
+	clog("done - %d snapshots, %d rows, %d errors", snapshots, rows, failed)
 }
 
-func (s *Scheduler) fetchTickerSplits() {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+func (s *Scheduler) refreshEarlyCalls() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
-	clog("starting ticker splits fetch")
+	clog("starting early calls")
 
-	tickers, err := s.store.ListAllTickers(ctx)
+	generatedAt := time.Now()
+	inserted, err := s.store.InsertEarlyCalls(ctx, db.InsertEarlyCallsParams{
+		ExcludedUsernames: source.ExcludedUsernames,
+		MinSpikeUsers:     earlySpikeMinUsers,
+		SpikeMultiplier:   earlySpikeMultiplier,
+		LookbackDays:      earlyCallLookbackDays,
+		GeneratedAt:       generatedAt,
+	})
 	if err != nil {
-		clog("error fetching tickers from DB: %v", err)
+		clog("error computing early calls: %v", err)
 		return
 	}
 
-	clog("processing %d tickers for splits", len(tickers))
+	if err := s.store.DeleteStaleEarlyCalls(ctx, generatedAt); err != nil {
+		clog("error deleting stale early calls: %v", err)
+	}
 
-	var fetched, fetchErrors, insertErrors int
-	for i, ticker := range tickers {
-		if strings.Contains(ticker.Symbol, "^") || strings.Contains(ticker.Symbol, "/") {
+	clog("done - %d early calls", inserted)
+}
+
+// syncRedditJobs makes the registered scrape jobs match the enabled rows in the
+// subreddits table. Only the jobs of subreddits that were added, disabled or
+// changed are touched; the others keep their schedule. New and changed jobs
+// start in their subreddit's slot of the spread, as on startup.
+func (s *Scheduler) syncRedditJobs() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	subs, err := s.store.ListEnabledSubreddits(ctx)
+	if err != nil {
+		clog("error listing subreddits: %v", err)
+		return
+	}
+
+	s.redditMu.Lock()
+	defer s.redditMu.Unlock()
+
+	enabled := make(map[string]bool, len(subs))
+	for _, sub := range subs {
+		enabled[sub.Name] = true
+	}
+	for name, current := range s.redditJobs {
+		if enabled[name] {
 			continue
 		}
+		if err := s.scheduler.RemoveJob(current.job.ID()); err != nil {
+			clog("error removing reddit job r/%s: %v", name, err)
+		}
+		delete(s.redditJobs, name)
+		clog("unscheduled r/%s", name)
+	}
 
-		if i > 0 && i%100 == 0 {
-			clog("progress %d/%d (%d splits, %d fetch errors, %d insert errors)", i, len(tickers), fetched, fetchErrors, insertErrors)
+	if len(subs) == 0 {
+		if len(s.redditJobs) == 0 {
+			clog("no enabled subreddits")
 		}
+		return
+	}
 
-		splits, err := s.yahooFetcher.FetchSplits(ctx, ticker.Symbol)
-		if err != nil {
-			fetchErrors++
-			if fetchErrors <= 10 {
-				clog("error for %s: %v", ticker.Symbol, err)
-			}
+	spread := scrapeInterval(subs[0])
+	for _, sub := range subs[1:] {
+		spread = min(spread, scrapeInterval(sub))
+	}
+
+	now := time.Now()
+	for i, sub := range subs {
+		name := sub.Name
+		interval := scrapeInterval(sub)
+		lookback := scrapeLookback(sub)
+
+		current, exists := s.redditJobs[name]
+		if exists && current.interval == interval && current.lookback == lookback {
 			continue
 		}
 
-		for _, split := range splits {
-			err = s.store.InsertTickerSplit(ctx, db.InsertTickerSplitParams{
-				TickerID:      ticker.ID,
-				Ratio:         fmt.Sprintf("%.4f", split.Ratio),
-				EffectiveDate: split.EffectiveDate,
-			})
-			if err != nil {
-				insertErrors++
-				continue
-			}
-			fetched++
+		start := now.Add(redditFirstRunDelay + spread*time.Duration(i)/time.Duration(len(subs)))
+		definition := gocron.DurationJob(interval)
+		task := gocron.NewTask(func() { s.fetchSubreddit(name, lookback) })
+		options := []gocron.JobOption{
+			gocron.WithName("reddit-scrape-" + name),
+			gocron.WithStartAt(gocron.WithStartDateTime(start)),
+		}
+
+		var job gocron.Job
+		if exists {
+			job, err = s.scheduler.Update(current.job.ID(), definition, task, options...)
+		} else {
+			job, err = s.scheduler.NewJob(definition, task, options...)
 		}
+		if err != nil {
+			clog("error registering reddit job r/%s: %v", name, err)
+			continue
+		}
+
+		s.redditJobs[name] = redditJob{job: job, interval: interval, lookback: lookback}
+		clog("scheduled r/%s every %s, first run at %s", name, interval, start.Format(time.Kitchen))
 	}
+}
 
-	clog("done - %d splits stored, %d fetch errors, %d insert errors", fetched, fetchErrors, insertErrors)
+func scrapeInterval(sub db.Subreddit) time.Duration {
+	return time.Duration(sub.ScrapeIntervalMinutes) * time.Minute
+}
+
+func scrapeLookback(sub db.Subreddit) time.Duration {
+	return time.Duration(sub.LookbackHours) * time.Hour
+}
+
+// leaderboardPeriodCutoff mirrors parsePeriodCutoff in the api package.
+func leaderboardPeriodCutoff(period string, now time.Time) time.Time {
+	switch period {
+	case "daily":
+		return now.AddDate(0, 0, -1)
+	case "weekly":
+		return now.AddDate(0, 0, -7)
+	case "monthly":
+		return now.AddDate(0, -1, 0)
+	default:
+		return time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
 }
 
 func (s *Scheduler) RegisterJobs() error {
@@ -323,8 +800,9 @@ func (s *Scheduler) RegisterJobs() error {
 	_, err = s.scheduler.NewJob(
 		gocron.DurationJob(6*time.Hour),
 		gocron.NewTask(s.fetchTickerPrices),
-		gocron.WithName("ticker-prices"),
+		gocron.WithName(priceRefreshJob),
 		gocron.WithStartAt(gocron.WithStartDateTime(pricesStart)),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
 	)
 	if err != nil {
 		return err
@@ -342,24 +820,98 @@ func (s *Scheduler) RegisterJobs() error {
 		return err
 	}
 
-	// 4. Reddit scraping - 3h cycle, staggered: #1 at +15m, #2 at +1h, #3 at +2h
-	redditDelays := []time.Duration{15 * time.Minute, 1 * time.Hour, 2 * time.Hour}
-	for i, subreddit := range subreddits {
-		sub := subreddit
-		subStart := now.Add(redditDelays[i])
+	// 4. Reddit scraping - one job per enabled subreddit, re-synced from the database every 5 min
+	s.syncRedditJobs()
+	_, err = s.scheduler.NewJob(
+		gocron.DurationJob(redditSyncInterval),
+		gocron.NewTask(s.syncRedditJobs),
+		gocron.WithName("reddit-subreddits-sync"),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	)
+	if err != nil {
+		return err
+	}
 
-		_, err := s.scheduler.NewJob(
-			gocron.DurationJob(3*time.Hour),
-			gocron.NewTask(func() { s.fetchSubreddit(sub) }),
-			gocron.WithName("reddit-scrape-"+sub),
-			gocron.WithStartAt(gocron.WithStartDateTime(subStart)),
+	// 5. Leaderboard snapshots - +1 min after startup, every 6h, and after each price refresh
+	snapshotsStart := now.Add(1 * time.Minute)
+	s.snapshotJob, err = s.scheduler.NewJob(
+		gocron.DurationJob(6*time.Hour),
+		gocron.NewTask(s.refreshLeaderboardSnapshots),
+		gocron.WithName("leaderboard-snapshots"),
+		gocron.WithStartAt(gocron.WithStartDateTime(snapshotsStart)),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	)
+	if err != nil {
+		return err
+	}
+
+	// 6. Early calls - +2 min after startup, every 6h
+	earlyCallsStart := now.Add(2 * time.Minute)
+	_, err = s.scheduler.NewJob(
+		gocron.DurationJob(6*time.Hour),
+		gocron.NewTask(s.refreshEarlyCalls),
+		gocron.WithName("early-calls"),
+		gocron.WithStartAt(gocron.WithStartDateTime(earlyCallsStart)),
+	)
+	if err != nil {
+		return err
+	}
+
+	// 7. StockTwits streams and RSS/Atom feeds - staggered over the first 10 min, then on their configured intervals
+	for i, feed := range s.feeds {
+		start := now.Add(time.Minute + feedFirstRunSpread*time.Duration(i)/time.Duration(len(s.feeds)))
+		_, err = s.scheduler.NewJob(
+			gocron.DurationJob(feed.interval),
+			gocron.NewTask(func() { s.scrapeFeed(feed) }),
+			gocron.WithName(feed.source.Name()+"-"+feed.key),
+			gocron.WithStartAt(gocron.WithStartDateTime(start)),
+			gocron.WithSingletonMode(gocron.LimitModeReschedule),
 		)
 		if err != nil {
 			return err
 		}
 	}
 
-	clog("all %d jobs registered", 3+len(subreddits))
+	// 8. Daily bars - +15 min after startup (after the first splits fetch), every 24h
+	dailyBarsStart := now.Add(15 * time.Minute)
+	_, err = s.scheduler.NewJob(
+		gocron.DurationJob(24*time.Hour),
+		gocron.NewTask(s.syncDailyBars),
+		gocron.WithName(dailyBarsJob),
+		gocron.WithStartAt(gocron.WithStartDateTime(dailyBarsStart)),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	)
+	if err != nil {
+		return err
+	}
+
+	// 9. Mention entries - +20 min after startup (after the first daily bars), every hour
+	mentionEntriesStart := now.Add(20 * time.Minute)
+	_, err = s.scheduler.NewJob(
+		gocron.DurationJob(time.Hour),
+		gocron.NewTask(s.resolveMentionEntries),
+		gocron.WithName(mentionEntriesJob),
+		gocron.WithStartAt(gocron.WithStartDateTime(mentionEntriesStart)),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	)
+	if err != nil {
+		return err
+	}
+
+	// 10. Mention predictions - +30 min after startup (after the first mention entries), every 6 hours
+	mentionPredictionsStart := now.Add(30 * time.Minute)
+	_, err = s.scheduler.NewJob(
+		gocron.DurationJob(6*time.Hour),
+		gocron.NewTask(s.evaluatePredictions),
+		gocron.WithName(mentionPredictionsJob),
+		gocron.WithStartAt(gocron.WithStartDateTime(mentionPredictionsStart)),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	)
+	if err != nil {
+		return err
+	}
+
+	clog("all %d jobs registered", len(s.scheduler.Jobs()))
 	return nil
 }
 
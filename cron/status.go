@@ -0,0 +1,84 @@
+package cron
+
+import (
+	"context"
+	"time"
+
+	external_api "github.com/stuneak/sopeko/cron/external_api"
+	db "github.com/stuneak/sopeko/db/sqlc"
+)
+
+const statusRecentRuns = 20
+
+type JobStatus struct {
+	Name    string     `json:"name"`
+	LastRun *time.Time `json:"last_run"`
+	NextRun *time.Time `json:"next_run"`
+}
+
+type PriceTierStatus struct {
+	Tier    string `json:"tier"`
+	Rule    string `json:"rule"`
+	Tickers int    `json:"tickers"`
+	Due     int    `json:"due"` // would be refreshed by a run right now
+}
+
+type Status struct {
+	Jobs           []JobStatus                   `json:"jobs"`
+	PriceTiers     []PriceTierStatus             `json:"price_tiers"`
+	PriceProviders []external_api.ProviderStatus `json:"price_providers"`
+	EntryRule      string                        `json:"entry_rule"`
+	RecentRuns     []db.JobRun                   `json:"recent_runs"`
+}
+
+// Status reports the registered jobs, how tickers fall into the price tiers,
+// the health of the price providers, the entry rule and the latest run
+// summaries.
+func (s *Scheduler) Status(ctx context.Context) (Status, error) {
+	status := Status{
+		Jobs:           []JobStatus{},
+		PriceProviders: s.prices.Status(),
+		EntryRule:      s.entryRule,
+	}
+
+	for _, job := range s.scheduler.Jobs() {
+		js := JobStatus{Name: job.Name()}
+		if t, err := job.LastRun(); err == nil && !t.IsZero() {
+			js.LastRun = &t
+		}
+		if t, err := job.NextRun(); err == nil && !t.IsZero() {
+			js.NextRun = &t
+		}
+		status.Jobs = append(status.Jobs, js)
+	}
+
+	tickers, err := s.store.ListTickersByMentionPriority(ctx)
+	if err != nil {
+		return Status{}, err
+	}
+	tiers := map[string]*PriceTierStatus{
+		tierHot:  {Tier: tierHot, Rule: "benchmarks and tickers mentioned in the last " + s.priceTiers.hotWindow.String() + ": every run"},
+		tierWarm: {Tier: tierWarm, Rule: "mentioned before that: every " + s.priceTiers.warmInterval.String()},
+		tierCold: {Tier: tierCold, Rule: "never mentioned: fetched at first mention"},
+	}
+	now := time.Now()
+	for _, ticker := range tickers {
+		tier, due := s.priceTiers.tier(ticker, now)
+		tiers[tier].Tickers++
+		if due {
+			tiers[tier].Due++
+		}
+	}
+	for _, name := range []string{tierHot, tierWarm, tierCold} {
+		status.PriceTiers = append(status.PriceTiers, *tiers[name])
+	}
+
+	status.RecentRuns, err = s.store.ListRecentJobRuns(ctx, statusRecentRuns)
+	if err != nil {
+		return Status{}, err
+	}
+	if status.RecentRuns == nil {
+		status.RecentRuns = []db.JobRun{}
+	}
+	return status, nil
+}
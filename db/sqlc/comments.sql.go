@@ -8,13 +8,27 @@ package db
 import (
 	"context"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 const createComment = `-- name: CreateComment :one
-INSERT INTO comments (user_id, source, external_id, content, created_at)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO comments (
+  user_id,
+  source,
+  external_id,
+  content,
+  created_at,
+  kind,
+  subreddit,
+  permalink,
+  post_id,
+  parent_id,
+  score
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 ON CONFLICT (user_id, external_id) DO UPDATE SET external_id = EXCLUDED.external_id
-RETURNING id, user_id, source, external_id, content, created_at
+RETURNING id, user_id, source, external_id, content, created_at, kind, subreddit, permalink, post_id, parent_id, score
 `
 
 type CreateCommentParams struct {
@@ -23,6 +37,12 @@ type CreateCommentParams struct {
 	ExternalID string    `json:"external_id"`
 	Content    string    `json:"content"`
 	CreatedAt  time.Time `json:"created_at"`
+	Kind       string    `json:"kind"`
+	Subreddit  string    `json:"subreddit"`
+	Permalink  string    `json:"permalink"`
+	PostID     string    `json:"post_id"`
+	ParentID   string    `json:"parent_id"`
+	Score      int32     `json:"score"`
 }
 
 func (q *Queries) CreateComment(ctx context.Context, arg CreateCommentParams) (Comment, error) {
@@ -32,6 +52,12 @@ func (q *Queries) CreateComment(ctx context.Context, arg CreateCommentParams) (C
 		arg.ExternalID,
 		arg.Content,
 		arg.CreatedAt,
+		arg.Kind,
+		arg.Subreddit,
+		arg.Permalink,
+		arg.PostID,
+		arg.ParentID,
+		arg.Score,
 	)
 	var i Comment
 	err := row.Scan(
@@ -41,12 +67,106 @@ func (q *Queries) CreateComment(ctx context.Context, arg CreateCommentParams) (C
 		&i.ExternalID,
 		&i.Content,
 		&i.CreatedAt,
+		&i.Kind,
+		&i.Subreddit,
+		&i.Permalink,
+		&i.PostID,
+		&i.ParentID,
+		&i.Score,
 	)
 	return i, err
 }
 
+const createComments = `-- name: CreateComments :many
+INSERT INTO comments (
+  user_id,
+  source,
+  external_id,
+  content,
+  created_at,
+  kind,
+  subreddit,
+  permalink,
+  post_id,
+  parent_id,
+  score
+)
+SELECT c.user_id, c.source, c.external_id, c.content, c.created_at, c.kind, c.subreddit, c.permalink, c.post_id, c.parent_id, c.score FROM unnest(
+  $1::bigint[],
+  $2::text[],
+  $3::text[],
+  $4::text[],
+  $5::timestamp[],
+  $6::text[],
+  $7::text[],
+  $8::text[],
+  $9::text[],
+  $10::text[],
+  $11::int[]
+) AS c(user_id, source, external_id, content, created_at, kind, subreddit, permalink, post_id, parent_id, score)
+ON CONFLICT (user_id, external_id) DO NOTHING
+RETURNING id, user_id, external_id
+`
+
+type CreateCommentsParams struct {
+	UserIds     []int64     `json:"user_ids"`
+	Sources     []string    `json:"sources"`
+	ExternalIds []string    `json:"external_ids"`
+	Contents    []string    `json:"contents"`
+	CreatedAts  []time.Time `json:"created_ats"`
+	Kinds       []string    `json:"kinds"`
+	Subreddits  []string    `json:"subreddits"`
+	Permalinks  []string    `json:"permalinks"`
+	PostIds     []string    `json:"post_ids"`
+	ParentIds   []string    `json:"parent_ids"`
+	Scores      []int32     `json:"scores"`
+}
+
+type CreateCommentsRow struct {
+	ID         int64  `json:"id"`
+	UserID     int64  `json:"user_id"`
+	ExternalID string `json:"external_id"`
+}
+
+// Inserts a batch of comments. Comments that are already stored are skipped,
+// only the created ones are returned.
+func (q *Queries) CreateComments(ctx context.Context, arg CreateCommentsParams) ([]CreateCommentsRow, error) {
+	rows, err := q.db.QueryContext(ctx, createComments,
+		pq.Array(arg.UserIds),
+		pq.Array(arg.Sources),
+		pq.Array(arg.ExternalIds),
+		pq.Array(arg.Contents),
+		pq.Array(arg.CreatedAts),
+		pq.Array(arg.Kinds),
+		pq.Array(arg.Subreddits),
+		pq.Array(arg.Permalinks),
+		pq.Array(arg.PostIds),
+		pq.Array(arg.ParentIds),
+		pq.Array(arg.Scores),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CreateCommentsRow
+	for rows.Next() {
+		var i CreateCommentsRow
+		if err := rows.Scan(&i.ID, &i.UserID, &i.ExternalID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getCommentByUserAndExternalID = `-- name: GetCommentByUserAndExternalID :one
-SELECT id, user_id, source, external_id, content, created_at FROM comments
+SELECT id, user_id, source, external_id, content, created_at, kind, subreddit, permalink, post_id, parent_id, score FROM comments
 WHERE user_id = $1 AND external_id = $2
 `
 
@@ -65,6 +185,53 @@ func (q *Queries) GetCommentByUserAndExternalID(ctx context.Context, arg GetComm
 		&i.ExternalID,
 		&i.Content,
 		&i.CreatedAt,
+		&i.Kind,
+		&i.Subreddit,
+		&i.Permalink,
+		&i.PostID,
+		&i.ParentID,
+		&i.Score,
 	)
 	return i, err
 }
+
+const listExistingComments = `-- name: ListExistingComments :many
+SELECT u.username, c.external_id
+FROM unnest($1::text[], $2::text[]) AS item(username, external_id)
+JOIN users u ON u.username = item.username
+JOIN comments c ON c.user_id = u.id AND c.external_id = item.external_id
+`
+
+type ListExistingCommentsParams struct {
+	Usernames   []string `json:"usernames"`
+	ExternalIds []string `json:"external_ids"`
+}
+
+type ListExistingCommentsRow struct {
+	Username   string `json:"username"`
+	ExternalID string `json:"external_id"`
+}
+
+// The comments of a batch, by author and external id, that are already stored.
+func (q *Queries) ListExistingComments(ctx context.Context, arg ListExistingCommentsParams) ([]ListExistingCommentsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listExistingComments, pq.Array(arg.Usernames), pq.Array(arg.ExternalIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListExistingCommentsRow
+	for rows.Next() {
+		var i ListExistingCommentsRow
+		if err := rows.Scan(&i.Username, &i.ExternalID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
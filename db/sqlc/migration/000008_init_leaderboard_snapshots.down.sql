@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS leaderboard_generations;
+DROP INDEX IF EXISTS idx_leaderboard_snapshots_key;
+DROP TABLE IF EXISTS leaderboard_snapshots;
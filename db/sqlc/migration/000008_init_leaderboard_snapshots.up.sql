@@ -0,0 +1,67 @@
+CREATE TABLE leaderboard_snapshots (
+  id                    BIGSERIAL PRIMARY KEY,
+  period                TEXT NOT NULL, -- daily | weekly | monthly | all
+  horizon_days          INTEGER NOT NULL, -- 0 = latest price
+  benchmark             TEXT NOT NULL,
+  symbol                TEXT NOT NULL,
+  username              TEXT NOT NULL,
+  mention_price         NUMERIC(18,4) NOT NULL,
+  current_price         NUMERIC(18,4) NOT NULL,
+  current_price_date    TIMESTAMPTZ NOT NULL,
+  has_current_price     BOOLEAN NOT NULL,
+  mentioned_at          TIMESTAMP NOT NULL,
+  split_ratio           DOUBLE PRECISION NOT NULL,
+  benchmark_start_price NUMERIC(18,4) NOT NULL,
+  benchmark_end_price   NUMERIC(18,4) NOT NULL,
+  stance                TEXT NOT NULL DEFAULT 'neutral', -- bullish | bearish | neutral
+  generated_at          TIMESTAMPTZ NOT NULL,
+  -- Per-pick returns, computed like computePickReturn in the api package, so
+  -- the leaderboards can be ranked, filtered and limited in SQL. Bearish picks
+  -- are scored as shorts, against a short of the benchmark; the benchmark's
+  -- own return is kept as it moved.
+  scorable              BOOLEAN NOT NULL GENERATED ALWAYS AS (
+    mention_price > 0 AND (horizon_days = 0 OR has_current_price)
+  ) STORED,
+  percent_change        DOUBLE PRECISION NOT NULL GENERATED ALWAYS AS (
+    CASE WHEN stance = 'bearish' THEN -1 ELSE 1 END
+    * CASE WHEN mention_price > 0 AND split_ratio > 0
+        THEN (current_price::double precision - mention_price::double precision * split_ratio)
+             / (mention_price::double precision * split_ratio) * 100
+        ELSE 0
+      END
+  ) STORED,
+  benchmark_percent_change DOUBLE PRECISION NOT NULL GENERATED ALWAYS AS (
+    CASE WHEN benchmark_start_price > 0 AND benchmark_end_price > 0
+      THEN (benchmark_end_price::double precision - benchmark_start_price::double precision)
+           / benchmark_start_price::double precision * 100
+      ELSE 0
+    END
+  ) STORED,
+  excess_return         DOUBLE PRECISION NOT NULL GENERATED ALWAYS AS (
+    CASE WHEN stance = 'bearish' THEN -1 ELSE 1 END
+    * (CASE WHEN mention_price > 0 AND split_ratio > 0
+         THEN (current_price::double precision - mention_price::double precision * split_ratio)
+              / (mention_price::double precision * split_ratio) * 100
+         ELSE 0
+       END
+     - CASE WHEN benchmark_start_price > 0 AND benchmark_end_price > 0
+         THEN (benchmark_end_price::double precision - benchmark_start_price::double precision)
+              / benchmark_start_price::double precision * 100
+         ELSE 0
+       END)
+  ) STORED
+);
+
+CREATE INDEX idx_leaderboard_snapshots_key
+  ON leaderboard_snapshots (period, horizon_days, benchmark, generated_at DESC);
+
+-- The snapshot readers should use for each leaderboard key. A key with a row
+-- has been computed, even when its snapshot has no rows; keys without one are
+-- computed live.
+CREATE TABLE leaderboard_generations (
+  period        TEXT NOT NULL,
+  horizon_days  INTEGER NOT NULL,
+  benchmark     TEXT NOT NULL,
+  generated_at  TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (period, horizon_days, benchmark)
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_early_calls_user;
+DROP INDEX IF EXISTS idx_early_calls_ticker;
+DROP TABLE IF EXISTS early_calls;
@@ -0,0 +1,15 @@
+CREATE TABLE early_calls (
+  id            BIGSERIAL PRIMARY KEY,
+  ticker_id     BIGINT NOT NULL REFERENCES ticker_names(id),
+  user_id       BIGINT NOT NULL REFERENCES users(id),
+  spike_date    DATE NOT NULL,
+  spike_users   INTEGER NOT NULL,
+  mentioned_at  TIMESTAMP NOT NULL,
+  generated_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_early_calls_ticker
+  ON early_calls (ticker_id, generated_at DESC);
+
+CREATE INDEX idx_early_calls_user
+  ON early_calls (user_id, generated_at DESC);
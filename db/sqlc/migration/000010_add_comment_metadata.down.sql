@@ -0,0 +1,7 @@
+ALTER TABLE comments
+  DROP COLUMN IF EXISTS score,
+  DROP COLUMN IF EXISTS parent_id,
+  DROP COLUMN IF EXISTS post_id,
+  DROP COLUMN IF EXISTS permalink,
+  DROP COLUMN IF EXISTS subreddit,
+  DROP COLUMN IF EXISTS kind;
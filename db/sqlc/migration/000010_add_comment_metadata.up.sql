@@ -0,0 +1,7 @@
+ALTER TABLE comments
+  ADD COLUMN kind       TEXT NOT NULL DEFAULT 'comment', -- post | comment
+  ADD COLUMN subreddit  TEXT NOT NULL DEFAULT '',
+  ADD COLUMN permalink  TEXT NOT NULL DEFAULT '', -- path relative to the source site
+  ADD COLUMN post_id    TEXT NOT NULL DEFAULT '',
+  ADD COLUMN parent_id  TEXT NOT NULL DEFAULT '', -- fullname of the parent (t1_ / t3_), empty for posts
+  ADD COLUMN score      INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+ALTER TABLE leaderboard_snapshots
+  DROP COLUMN IF EXISTS subreddit;
+
+DROP INDEX IF EXISTS idx_mentions_subreddit_time;
+
+ALTER TABLE ticker_mentions
+  DROP COLUMN IF EXISTS subreddit;
@@ -0,0 +1,13 @@
+ALTER TABLE ticker_mentions
+  ADD COLUMN subreddit TEXT NOT NULL DEFAULT ''; -- lowercase, empty for non-reddit sources
+
+UPDATE ticker_mentions tm
+SET subreddit = LOWER(c.subreddit)
+FROM comments c
+WHERE c.id = tm.comment_id;
+
+CREATE INDEX idx_mentions_subreddit_time
+  ON ticker_mentions (subreddit, mentioned_at DESC);
+
+ALTER TABLE leaderboard_snapshots
+  ADD COLUMN subreddit TEXT NOT NULL DEFAULT '';
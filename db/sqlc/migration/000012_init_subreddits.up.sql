@@ -0,0 +1,12 @@
+CREATE TABLE subreddits (
+  id                       BIGSERIAL PRIMARY KEY,
+  name                     TEXT NOT NULL UNIQUE, -- lowercase, without r/
+  enabled                  BOOLEAN NOT NULL DEFAULT true,
+  scrape_interval_minutes  INTEGER NOT NULL DEFAULT 180,
+  lookback_hours           INTEGER NOT NULL DEFAULT 24,
+  created_at               TIMESTAMP NOT NULL DEFAULT now(),
+  updated_at               TIMESTAMP NOT NULL DEFAULT now()
+);
+
+INSERT INTO subreddits (name)
+VALUES ('pennystocks'), ('investing'), ('stocks');
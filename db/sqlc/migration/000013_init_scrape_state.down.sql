@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_reddit_threads_due;
+DROP TABLE IF EXISTS reddit_threads;
+DROP TABLE IF EXISTS scrape_checkpoints;
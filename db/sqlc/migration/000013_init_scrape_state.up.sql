@@ -0,0 +1,19 @@
+CREATE TABLE scrape_checkpoints (
+  subreddit    TEXT NOT NULL,
+  stream       TEXT NOT NULL, -- posts | comments
+  fullname     TEXT NOT NULL, -- newest item seen, e.g. t3_abc123
+  created_at   TIMESTAMP NOT NULL, -- creation time of that item (UTC)
+  updated_at   TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY (subreddit, stream)
+);
+
+CREATE TABLE reddit_threads (
+  post_id          TEXT PRIMARY KEY,
+  subreddit        TEXT NOT NULL,
+  posted_at        TIMESTAMP NOT NULL, -- UTC
+  last_comment_at  TIMESTAMP NOT NULL, -- newest comment seen (UTC)
+  next_visit_at    TIMESTAMP NOT NULL  -- when the comment tree is re-fetched (UTC)
+);
+
+CREATE INDEX idx_reddit_threads_due
+  ON reddit_threads (subreddit, next_visit_at);
@@ -0,0 +1,8 @@
+CREATE TABLE archive_imports (
+  file            TEXT PRIMARY KEY, -- base name of the dump, e.g. RC_2024-01.zst
+  lines_processed BIGINT NOT NULL DEFAULT 0,
+  items_imported  BIGINT NOT NULL DEFAULT 0,
+  started_at      TIMESTAMP NOT NULL DEFAULT now(),
+  updated_at      TIMESTAMP NOT NULL DEFAULT now(),
+  completed_at    TIMESTAMP
+);
@@ -0,0 +1,9 @@
+DELETE FROM scrape_checkpoints WHERE source <> 'reddit';
+
+ALTER TABLE scrape_checkpoints DROP CONSTRAINT scrape_checkpoints_pkey;
+
+ALTER TABLE scrape_checkpoints RENAME COLUMN item_id TO fullname;
+ALTER TABLE scrape_checkpoints RENAME COLUMN feed TO subreddit;
+ALTER TABLE scrape_checkpoints DROP COLUMN source;
+
+ALTER TABLE scrape_checkpoints ADD PRIMARY KEY (subreddit, stream);
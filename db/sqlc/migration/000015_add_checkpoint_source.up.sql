@@ -0,0 +1,10 @@
+-- Checkpoints are kept per source and feed: the subreddit for Reddit, the
+-- stream for StockTwits, the feed URL for RSS/Atom.
+ALTER TABLE scrape_checkpoints
+  ADD COLUMN source TEXT NOT NULL DEFAULT 'reddit';
+
+ALTER TABLE scrape_checkpoints RENAME COLUMN subreddit TO feed;
+ALTER TABLE scrape_checkpoints RENAME COLUMN fullname TO item_id;
+
+ALTER TABLE scrape_checkpoints DROP CONSTRAINT scrape_checkpoints_pkey;
+ALTER TABLE scrape_checkpoints ADD PRIMARY KEY (source, feed, stream);
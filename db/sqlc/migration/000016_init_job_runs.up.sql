@@ -0,0 +1,14 @@
+CREATE TABLE job_runs (
+  id           BIGSERIAL PRIMARY KEY,
+  job          TEXT NOT NULL, -- e.g. ticker-prices
+  started_at   TIMESTAMP NOT NULL,
+  finished_at  TIMESTAMP NOT NULL,
+  duration_ms  BIGINT NOT NULL,
+  total        INT NOT NULL, -- tickers considered
+  fetched      INT NOT NULL,
+  failed       INT NOT NULL,
+  skipped      INT NOT NULL  -- unsupported symbols and tickers not reached before the timeout
+);
+
+CREATE INDEX idx_job_runs_job_time
+  ON job_runs (job, started_at DESC);
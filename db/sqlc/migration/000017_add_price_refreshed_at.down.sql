@@ -0,0 +1,2 @@
+ALTER TABLE ticker_names
+  DROP COLUMN IF EXISTS price_refreshed_at;
@@ -0,0 +1,2 @@
+ALTER TABLE ticker_names
+  ADD COLUMN price_refreshed_at TIMESTAMP; -- last successful current price fetch, NULL if never
@@ -0,0 +1,2 @@
+DROP VIEW IF EXISTS ticker_price_points;
+DROP TABLE IF EXISTS ticker_daily_bars;
@@ -0,0 +1,23 @@
+CREATE TABLE ticker_daily_bars (
+  ticker_id  BIGINT NOT NULL REFERENCES ticker_names(id),
+  day        DATE NOT NULL, -- trading day (New York)
+  open       NUMERIC(18,4) NOT NULL,
+  high       NUMERIC(18,4) NOT NULL,
+  low        NUMERIC(18,4) NOT NULL,
+  close      NUMERIC(18,4) NOT NULL,
+  volume     BIGINT NOT NULL DEFAULT 0,
+  closed_at  TIMESTAMPTZ NOT NULL, -- end of the session
+  PRIMARY KEY (ticker_id, day)
+);
+
+CREATE INDEX idx_daily_bars_closed
+  ON ticker_daily_bars (ticker_id, closed_at DESC);
+
+-- Every known price of a ticker: daily closes plus the snapshots of the
+-- ticker-prices job. Entry and exit prices of mentions are looked up here.
+CREATE VIEW ticker_price_points AS
+SELECT ticker_id, close AS price, closed_at AS recorded_at
+FROM ticker_daily_bars
+UNION ALL
+SELECT ticker_id, price, recorded_at
+FROM ticker_prices;
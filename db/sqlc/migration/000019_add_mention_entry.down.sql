@@ -0,0 +1,16 @@
+CREATE OR REPLACE VIEW ticker_price_points AS
+SELECT ticker_id, close AS price, closed_at AS recorded_at
+FROM ticker_daily_bars
+UNION ALL
+SELECT ticker_id, price, recorded_at
+FROM ticker_prices;
+
+ALTER TABLE ticker_daily_bars
+  DROP COLUMN IF EXISTS opened_at;
+
+DROP INDEX IF EXISTS idx_mentions_entry_pending;
+
+ALTER TABLE ticker_mentions
+  DROP COLUMN IF EXISTS entry_price,
+  DROP COLUMN IF EXISTS entry_rule,
+  DROP COLUMN IF EXISTS entry_at;
@@ -0,0 +1,28 @@
+ALTER TABLE ticker_mentions
+  ADD COLUMN entry_rule  TEXT NOT NULL DEFAULT '', -- ENTRY_RULE the entry was resolved with, '' if not yet
+  ADD COLUMN entry_at    TIMESTAMPTZ,              -- when the mention could first be traded on
+  ADD COLUMN entry_price NUMERIC(18,4);            -- price at entry_at as traded, NULL until known
+
+CREATE INDEX idx_mentions_entry_pending
+  ON ticker_mentions (entry_at)
+  WHERE entry_price IS NULL;
+
+ALTER TABLE ticker_daily_bars
+  ADD COLUMN opened_at TIMESTAMPTZ; -- start of the session
+
+UPDATE ticker_daily_bars
+SET opened_at = (day + TIME '09:30') AT TIME ZONE 'America/New_York';
+
+ALTER TABLE ticker_daily_bars
+  ALTER COLUMN opened_at SET NOT NULL;
+
+-- Opens are price points too, so benchmarks can be read at a next-open entry
+CREATE OR REPLACE VIEW ticker_price_points AS
+SELECT ticker_id, close AS price, closed_at AS recorded_at
+FROM ticker_daily_bars
+UNION ALL
+SELECT ticker_id, open, opened_at
+FROM ticker_daily_bars
+UNION ALL
+SELECT ticker_id, price, recorded_at
+FROM ticker_prices;
@@ -0,0 +1,2 @@
+ALTER TABLE ticker_mentions
+  DROP COLUMN IF EXISTS confidence;
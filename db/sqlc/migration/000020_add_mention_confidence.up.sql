@@ -0,0 +1,2 @@
+ALTER TABLE ticker_mentions
+  ADD COLUMN confidence DOUBLE PRECISION; -- extractor's confidence in the symbol (0-1), NULL before scoring existed
@@ -0,0 +1,4 @@
+ALTER TABLE ticker_mentions
+  DROP COLUMN IF EXISTS detected_by;
+
+DROP TABLE IF EXISTS ticker_aliases;
@@ -0,0 +1,33 @@
+-- Names tickers are recognized by besides their company name: nicknames and
+-- former names. Aliases point at a symbol rather than a ticker id so they can
+-- be seeded before the NASDAQ sync has created the tickers.
+CREATE TABLE ticker_aliases (
+  id          BIGSERIAL PRIMARY KEY,
+  alias       TEXT NOT NULL UNIQUE, -- lowercase words separated by single spaces
+  symbol      TEXT NOT NULL,        -- ticker_names.symbol
+  kind        TEXT NOT NULL DEFAULT 'nickname', -- nickname | former_name
+  created_at  TIMESTAMP NOT NULL DEFAULT now()
+);
+
+INSERT INTO ticker_aliases (alias, symbol, kind)
+VALUES ('google', 'GOOGL', 'nickname'),
+       ('facebook', 'META', 'former_name'),
+       ('amazon', 'AMZN', 'nickname'),
+       ('disney', 'DIS', 'nickname'),
+       ('coke', 'KO', 'nickname'),
+       ('coca cola', 'KO', 'nickname'),
+       ('mcdonalds', 'MCD', 'nickname'),
+       ('bofa', 'BAC', 'nickname'),
+       ('jpmorgan', 'JPM', 'nickname'),
+       ('jp morgan', 'JPM', 'nickname'),
+       ('exxon', 'XOM', 'nickname'),
+       ('costco', 'COST', 'nickname'),
+       ('tsmc', 'TSM', 'nickname'),
+       ('amd', 'AMD', 'nickname'),
+       ('general electric', 'GE', 'former_name'),
+       ('marathon digital', 'MARA', 'former_name');
+
+-- How the extractor found the ticker: cashtag, symbol, name or alias. Older
+-- mentions all came from the symbol extractor.
+ALTER TABLE ticker_mentions
+  ADD COLUMN detected_by TEXT NOT NULL DEFAULT 'symbol';
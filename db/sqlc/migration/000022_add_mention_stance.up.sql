@@ -0,0 +1,4 @@
+-- Stance of the mention: bullish | bearish | neutral. Mentions stored before
+-- stances were classified are neutral, so they keep being scored as longs.
+ALTER TABLE ticker_mentions
+  ADD COLUMN stance TEXT NOT NULL DEFAULT 'neutral';
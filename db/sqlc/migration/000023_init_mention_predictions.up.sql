@@ -0,0 +1,25 @@
+-- Price targets and multiples claimed in comments ("PT $15 by Q3", "this
+-- 10x by EOY"), one row per claim, linked to the mention of the ticker they
+-- were made for. The mention-predictions job marks them hit or missed.
+CREATE TABLE mention_predictions (
+  id            BIGSERIAL PRIMARY KEY,
+  mention_id    BIGINT NOT NULL REFERENCES ticker_mentions(id),
+  target_price  NUMERIC(18,4),          -- stated price, or entry price x multiple once the entry is priced
+  multiple      DOUBLE PRECISION,       -- NULL for stated prices
+  deadline      TIMESTAMPTZ NOT NULL,
+  claim         TEXT NOT NULL,          -- the sentence the prediction was read from
+  status        TEXT NOT NULL DEFAULT 'open', -- open | hit | missed
+  progress      DOUBLE PRECISION NOT NULL DEFAULT 0, -- share of the move to the target made so far, 0 to 1
+  resolved_at   TIMESTAMPTZ,            -- when the target was reached, or the deadline of a miss
+  evaluated_at  TIMESTAMPTZ,
+  failures      INTEGER NOT NULL DEFAULT 0, -- failed evaluations in a row, backed off instead of retried every run
+  retry_at      TIMESTAMPTZ,            -- not evaluated again before, NULL after a success
+  created_at    TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_mention_predictions_mention
+  ON mention_predictions (mention_id);
+
+CREATE INDEX idx_mention_predictions_open
+  ON mention_predictions (deadline)
+  WHERE status = 'open';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS mention_options;
+
+ALTER TABLE ticker_mentions
+  DROP COLUMN IF EXISTS instrument;
@@ -0,0 +1,19 @@
+-- What the mention is a position in: stock | option. Option mentions are
+-- left out of stock performance.
+ALTER TABLE ticker_mentions
+  ADD COLUMN instrument TEXT NOT NULL DEFAULT 'stock';
+
+-- Option contracts written in comments ("AAPL 200c 6/21", "SPY 450p 0DTE"),
+-- linked to the mention of their underlying.
+CREATE TABLE mention_options (
+  id           BIGSERIAL PRIMARY KEY,
+  mention_id   BIGINT NOT NULL REFERENCES ticker_mentions(id),
+  option_type  TEXT NOT NULL,           -- call | put
+  strike       NUMERIC(18,4) NOT NULL,  -- as stated, at the prices of the mention
+  expires_at   TIMESTAMPTZ,             -- close of the expiry session, NULL when not given
+  written      BOOLEAN NOT NULL DEFAULT false, -- sold rather than bought
+  created_at   TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_mention_options_mention
+  ON mention_options (mention_id);
@@ -5,9 +5,19 @@
 package db
 
 import (
+	"database/sql"
 	"time"
 )
 
+type ArchiveImport struct {
+	File           string       `json:"file"`
+	LinesProcessed int64        `json:"lines_processed"`
+	ItemsImported  int64        `json:"items_imported"`
+	StartedAt      time.Time    `json:"started_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	CompletedAt    sql.NullTime `json:"completed_at"`
+}
+
 type Comment struct {
 	ID         int64     `json:"id"`
 	UserID     int64     `json:"user_id"`
@@ -15,23 +25,164 @@ type Comment struct {
 	ExternalID string    `json:"external_id"`
 	Content    string    `json:"content"`
 	CreatedAt  time.Time `json:"created_at"`
+	Kind       string    `json:"kind"`
+	Subreddit  string    `json:"subreddit"`
+	Permalink  string    `json:"permalink"`
+	PostID     string    `json:"post_id"`
+	ParentID   string    `json:"parent_id"`
+	Score      int32     `json:"score"`
 }
 
-type TickerMention struct {
+type EarlyCall struct {
 	ID          int64     `json:"id"`
 	TickerID    int64     `json:"ticker_id"`
 	UserID      int64     `json:"user_id"`
-	CommentID   int64     `json:"comment_id"`
+	SpikeDate   time.Time `json:"spike_date"`
+	SpikeUsers  int32     `json:"spike_users"`
 	MentionedAt time.Time `json:"mentioned_at"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+type JobRun struct {
+	ID         int64     `json:"id"`
+	Job        string    `json:"job"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Total      int32     `json:"total"`
+	Fetched    int32     `json:"fetched"`
+	Failed     int32     `json:"failed"`
+	Skipped    int32     `json:"skipped"`
+}
+
+type LeaderboardGeneration struct {
+	Period      string    `json:"period"`
+	HorizonDays int32     `json:"horizon_days"`
+	Benchmark   string    `json:"benchmark"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+type LeaderboardSnapshot struct {
+	ID                     int64     `json:"id"`
+	Period                 string    `json:"period"`
+	HorizonDays            int32     `json:"horizon_days"`
+	Benchmark              string    `json:"benchmark"`
+	Symbol                 string    `json:"symbol"`
+	Username               string    `json:"username"`
+	MentionPrice           string    `json:"mention_price"`
+	CurrentPrice           string    `json:"current_price"`
+	CurrentPriceDate       time.Time `json:"current_price_date"`
+	HasCurrentPrice        bool      `json:"has_current_price"`
+	MentionedAt            time.Time `json:"mentioned_at"`
+	SplitRatio             float64   `json:"split_ratio"`
+	BenchmarkStartPrice    string    `json:"benchmark_start_price"`
+	BenchmarkEndPrice      string    `json:"benchmark_end_price"`
+	Stance                 string    `json:"stance"`
+	GeneratedAt            time.Time `json:"generated_at"`
+	Scorable               bool      `json:"scorable"`
+	PercentChange          float64   `json:"percent_change"`
+	BenchmarkPercentChange float64   `json:"benchmark_percent_change"`
+	ExcessReturn           float64   `json:"excess_return"`
+	Subreddit              string    `json:"subreddit"`
+}
+
+type MentionOption struct {
+	ID         int64        `json:"id"`
+	MentionID  int64        `json:"mention_id"`
+	OptionType string       `json:"option_type"`
+	Strike     string       `json:"strike"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	Written    bool         `json:"written"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type MentionPrediction struct {
+	ID          int64           `json:"id"`
+	MentionID   int64           `json:"mention_id"`
+	TargetPrice sql.NullString  `json:"target_price"`
+	Multiple    sql.NullFloat64 `json:"multiple"`
+	Deadline    time.Time       `json:"deadline"`
+	Claim       string          `json:"claim"`
+	Status      string          `json:"status"`
+	Progress    float64         `json:"progress"`
+	ResolvedAt  sql.NullTime    `json:"resolved_at"`
+	EvaluatedAt sql.NullTime    `json:"evaluated_at"`
+	Failures    int32           `json:"failures"`
+	RetryAt     sql.NullTime    `json:"retry_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type RedditThread struct {
+	PostID        string    `json:"post_id"`
+	Subreddit     string    `json:"subreddit"`
+	PostedAt      time.Time `json:"posted_at"`
+	LastCommentAt time.Time `json:"last_comment_at"`
+	NextVisitAt   time.Time `json:"next_visit_at"`
+}
+
+type ScrapeCheckpoint struct {
+	Feed      string    `json:"feed"`
+	Stream    string    `json:"stream"`
+	ItemID    string    `json:"item_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Source    string    `json:"source"`
+}
+
+type Subreddit struct {
+	ID                    int64     `json:"id"`
+	Name                  string    `json:"name"`
+	Enabled               bool      `json:"enabled"`
+	ScrapeIntervalMinutes int32     `json:"scrape_interval_minutes"`
+	LookbackHours         int32     `json:"lookback_hours"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
+type TickerAlias struct {
+	ID        int64     `json:"id"`
+	Alias     string    `json:"alias"`
+	Symbol    string    `json:"symbol"`
+	Kind      string    `json:"kind"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TickerDailyBar struct {
+	TickerID int64     `json:"ticker_id"`
+	Day      time.Time `json:"day"`
+	Open     string    `json:"open"`
+	High     string    `json:"high"`
+	Low      string    `json:"low"`
+	Close    string    `json:"close"`
+	Volume   int64     `json:"volume"`
+	ClosedAt time.Time `json:"closed_at"`
+	OpenedAt time.Time `json:"opened_at"`
+}
+
+type TickerMention struct {
+	ID          int64           `json:"id"`
+	TickerID    int64           `json:"ticker_id"`
+	UserID      int64           `json:"user_id"`
+	CommentID   int64           `json:"comment_id"`
+	MentionedAt time.Time       `json:"mentioned_at"`
+	Subreddit   string          `json:"subreddit"`
+	EntryRule   string          `json:"entry_rule"`
+	EntryAt     sql.NullTime    `json:"entry_at"`
+	EntryPrice  sql.NullString  `json:"entry_price"`
+	Confidence  sql.NullFloat64 `json:"confidence"`
+	DetectedBy  string          `json:"detected_by"`
+	Stance      string          `json:"stance"`
+	Instrument  string          `json:"instrument"`
 }
 
 type TickerName struct {
-	ID          int64     `json:"id"`
-	Symbol      string    `json:"symbol"`
-	CompanyName string    `json:"company_name"`
-	Exchange    string    `json:"exchange"`
-	Currency    string    `json:"currency"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID               int64        `json:"id"`
+	Symbol           string       `json:"symbol"`
+	CompanyName      string       `json:"company_name"`
+	Exchange         string       `json:"exchange"`
+	Currency         string       `json:"currency"`
+	CreatedAt        time.Time    `json:"created_at"`
+	PriceRefreshedAt sql.NullTime `json:"price_refreshed_at"`
 }
 
 type TickerPrice struct {
@@ -42,6 +193,12 @@ type TickerPrice struct {
 	Volume     int64     `json:"volume"`
 }
 
+type TickerPricePoint struct {
+	TickerID   int64     `json:"ticker_id"`
+	Price      string    `json:"price"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
 type TickerSplit struct {
 	ID            int64     `json:"id"`
 	TickerID      int64     `json:"ticker_id"`
@@ -10,22 +10,80 @@ import (
 )
 
 type Querier interface {
+	CompleteArchiveImport(ctx context.Context, file string) error
 	CreateComment(ctx context.Context, arg CreateCommentParams) (Comment, error)
+	// Inserts a batch of comments. Comments that are already stored are skipped,
+	// only the created ones are returned.
+	CreateComments(ctx context.Context, arg CreateCommentsParams) ([]CreateCommentsRow, error)
+	CreateJobRun(ctx context.Context, arg CreateJobRunParams) error
+	CreateMentionOption(ctx context.Context, arg CreateMentionOptionParams) error
+	// Inserts a batch of option contracts. An empty expires_at is stored as NULL.
+	CreateMentionOptions(ctx context.Context, arg CreateMentionOptionsParams) error
+	CreateMentionPrediction(ctx context.Context, arg CreateMentionPredictionParams) error
+	// Inserts a batch of predictions. An empty target_price or a multiple of 0
+	// is stored as NULL.
+	CreateMentionPredictions(ctx context.Context, arg CreateMentionPredictionsParams) error
+	CreateRedditThread(ctx context.Context, arg CreateRedditThreadParams) error
+	CreateSubreddit(ctx context.Context, arg CreateSubredditParams) (Subreddit, error)
 	CreateTicker(ctx context.Context, arg CreateTickerParams) (TickerName, error)
+	CreateTickerAlias(ctx context.Context, arg CreateTickerAliasParams) (TickerAlias, error)
 	CreateTickerMention(ctx context.Context, arg CreateTickerMentionParams) (TickerMention, error)
+	// Inserts a batch of mentions with their entries. Entries are NULL where
+	// entry_at and entry_price are empty, and an entry without a rule was not
+	// resolved, so the mention-entries job resolves it.
+	CreateTickerMentions(ctx context.Context, arg CreateTickerMentionsParams) ([]CreateTickerMentionsRow, error)
 	CreateUser(ctx context.Context, username string) (User, error)
+	// Creates the users of a batch that do not exist yet and returns the ids of
+	// all of them.
+	CreateUsers(ctx context.Context, usernames []string) ([]CreateUsersRow, error)
 	CreateVisitor(ctx context.Context, arg CreateVisitorParams) error
+	// Keys that are no longer snapshotted go back to being computed live.
+	DeleteLeaderboardGenerationsExcept(ctx context.Context, arg DeleteLeaderboardGenerationsExceptParams) error
+	DeleteStaleEarlyCalls(ctx context.Context, generatedAt time.Time) error
+	// Rows of earlier runs and of keys without a generation.
+	DeleteStaleLeaderboardSnapshots(ctx context.Context) error
+	DeleteTickerAlias(ctx context.Context, alias string) (int64, error)
 	DeleteTickerPriceByDate(ctx context.Context, arg DeleteTickerPriceByDateParams) error
-	GetAllMentionsComplete(ctx context.Context, mentionedAt time.Time) ([]GetAllMentionsCompleteRow, error)
+	GetAllMentionsComplete(ctx context.Context, arg GetAllMentionsCompleteParams) ([]GetAllMentionsCompleteRow, error)
 	GetAllSplits(ctx context.Context) ([]GetAllSplitsRow, error)
 	GetAllVisitors(ctx context.Context, limit int32) ([]Visitor, error)
+	GetArchiveImport(ctx context.Context, file string) (ArchiveImport, error)
 	GetCommentByUserAndExternalID(ctx context.Context, arg GetCommentByUserAndExternalIDParams) (Comment, error)
+	GetDailyBar(ctx context.Context, arg GetDailyBarParams) (TickerDailyBar, error)
+	GetDailyMentionCounts(ctx context.Context, arg GetDailyMentionCountsParams) ([]GetDailyMentionCountsRow, error)
+	// Daily bars, plus the latest snapshot of days without a bar (e.g. today).
+	GetDailyTickerPrices(ctx context.Context, tickerID int64) ([]GetDailyTickerPricesRow, error)
+	GetEarlyCallCountsByUsernames(ctx context.Context, usernames []string) ([]GetEarlyCallCountsByUsernamesRow, error)
+	GetEarlyCallersByTicker(ctx context.Context, tickerID int64) ([]GetEarlyCallersByTickerRow, error)
+	GetLeaderboardGeneration(ctx context.Context, arg GetLeaderboardGenerationParams) (time.Time, error)
+	// The best scored picks of a snapshot or, with ascending, the worst.
+	GetLeaderboardPicks(ctx context.Context, arg GetLeaderboardPicksParams) ([]GetLeaderboardPicksRow, error)
+	// Mention volume and average pick performance per subreddit of a snapshot.
+	GetLeaderboardSubreddits(ctx context.Context, arg GetLeaderboardSubredditsParams) ([]GetLeaderboardSubredditsRow, error)
+	// The scored picks of the given users in a snapshot, best first.
+	GetLeaderboardUserPicks(ctx context.Context, arg GetLeaderboardUserPicksParams) ([]GetLeaderboardUserPicksRow, error)
+	// The ranked users of a snapshot, scored like computeUserStats in the api
+	// package. Each pick contributes its percent change or, with
+	// by_excess_return, its excess return; the shrunk mean blends in
+	// shrinkage_prior_picks picks at the mean of every pick.
+	GetLeaderboardUsers(ctx context.Context, arg GetLeaderboardUsersParams) ([]GetLeaderboardUsersRow, error)
+	GetScrapeCheckpoint(ctx context.Context, arg GetScrapeCheckpointParams) (ScrapeCheckpoint, error)
 	GetSplitsBetweenDates(ctx context.Context, arg GetSplitsBetweenDatesParams) ([]GetSplitsBetweenDatesRow, error)
 	GetSplitsByTicker(ctx context.Context, tickerID int64) ([]TickerSplit, error)
+	GetSubredditByName(ctx context.Context, name string) (Subreddit, error)
+	GetTickerAlias(ctx context.Context, alias string) (TickerAlias, error)
 	GetTickerBySymbol(ctx context.Context, symbol string) (TickerName, error)
-	GetTickerPriceBeforeDate(ctx context.Context, arg GetTickerPriceBeforeDateParams) (TickerPrice, error)
+	GetTickerMentioners(ctx context.Context, arg GetTickerMentionersParams) ([]GetTickerMentionersRow, error)
+	GetTickerPriceBeforeDate(ctx context.Context, arg GetTickerPriceBeforeDateParams) (GetTickerPriceBeforeDateRow, error)
+	GetTrendingTickers(ctx context.Context, arg GetTrendingTickersParams) ([]GetTrendingTickersRow, error)
 	GetUserByUsername(ctx context.Context, username string) (User, error)
 	GetUserMentionsComplete(ctx context.Context, arg GetUserMentionsCompleteParams) ([]GetUserMentionsCompleteRow, error)
+	// Every option contract of a user, with the underlying at the mention's
+	// entry and at expiry (the latest price while the contract runs), and the
+	// splits in between.
+	GetUserOptionPositions(ctx context.Context, username string) ([]GetUserOptionPositionsRow, error)
+	GetUserPredictions(ctx context.Context, username string) ([]GetUserPredictionsRow, error)
+	GetUsersMentionsComplete(ctx context.Context, arg GetUsersMentionsCompleteParams) ([]GetUsersMentionsCompleteRow, error)
 	GetVisitorCountAll(ctx context.Context) (int64, error)
 	GetVisitorCountLastDay(ctx context.Context) (int64, error)
 	GetVisitorCountLastMonth(ctx context.Context) (int64, error)
@@ -35,9 +93,46 @@ type Querier interface {
 	GetVisitorsLastDay(ctx context.Context) ([]Visitor, error)
 	GetVisitorsLastMonth(ctx context.Context) ([]Visitor, error)
 	GetVisitorsLastWeek(ctx context.Context) ([]Visitor, error)
+	// Spikes and first mentions only count stock mentions of users that are not
+	// excluded, like the leaderboards.
+	InsertEarlyCalls(ctx context.Context, arg InsertEarlyCallsParams) (int64, error)
+	InsertLeaderboardSnapshot(ctx context.Context, arg InsertLeaderboardSnapshotParams) (int64, error)
 	InsertTickerPrice(ctx context.Context, arg InsertTickerPriceParams) (TickerPrice, error)
 	InsertTickerSplit(ctx context.Context, arg InsertTickerSplitParams) error
 	ListAllTickers(ctx context.Context) ([]TickerName, error)
+	// Benchmarks and every mentioned ticker, with their first mention and the
+	// newest stored bar.
+	ListDailyBarTargets(ctx context.Context) ([]ListDailyBarTargetsRow, error)
+	ListDailyBarsBetween(ctx context.Context, arg ListDailyBarsBetweenParams) ([]TickerDailyBar, error)
+	ListDueRedditThreads(ctx context.Context, arg ListDueRedditThreadsParams) ([]RedditThread, error)
+	ListEnabledSubreddits(ctx context.Context) ([]Subreddit, error)
+	// The comments of a batch, by author and external id, that are already stored.
+	ListExistingComments(ctx context.Context, arg ListExistingCommentsParams) ([]ListExistingCommentsRow, error)
+	// Mentions without an entry under the current rule, plus recent entries whose
+	// price was not known yet. Newest first, so fresh mentions are priced before
+	// a backfill of old ones.
+	ListMentionEntriesDue(ctx context.Context, arg ListMentionEntriesDueParams) ([]ListMentionEntriesDueRow, error)
+	// Open predictions whose mention has an entry price and that are not backing
+	// off from a failure, the ones evaluated longest ago first.
+	ListOpenPredictions(ctx context.Context, rowLimit int32) ([]ListOpenPredictionsRow, error)
+	ListRecentJobRuns(ctx context.Context, limit int32) ([]JobRun, error)
+	ListSubreddits(ctx context.Context) ([]Subreddit, error)
+	ListTickerAliases(ctx context.Context) ([]TickerAlias, error)
+	ListTickerPricesBetween(ctx context.Context, arg ListTickerPricesBetweenParams) ([]ListTickerPricesBetweenRow, error)
+	// Benchmarks first, then mentioned tickers (most recently mentioned first),
+	// then everything else.
+	ListTickersByMentionPriority(ctx context.Context) ([]ListTickersByMentionPriorityRow, error)
+	MarkTickerPriceRefreshed(ctx context.Context, id int64) error
+	SaveArchiveImportProgress(ctx context.Context, arg SaveArchiveImportProgressParams) error
+	SetLeaderboardGeneration(ctx context.Context, arg SetLeaderboardGenerationParams) error
+	SetMentionEntry(ctx context.Context, arg SetMentionEntryParams) error
+	SetPredictionFailed(ctx context.Context, arg SetPredictionFailedParams) error
+	SetPredictionResult(ctx context.Context, arg SetPredictionResultParams) error
+	TouchRedditThread(ctx context.Context, arg TouchRedditThreadParams) error
+	UpdateRedditThreadVisit(ctx context.Context, arg UpdateRedditThreadVisitParams) error
+	UpdateSubreddit(ctx context.Context, arg UpdateSubredditParams) (Subreddit, error)
+	UpsertDailyBar(ctx context.Context, arg UpsertDailyBarParams) error
+	UpsertScrapeCheckpoint(ctx context.Context, arg UpsertScrapeCheckpointParams) error
 	UpsertTicker(ctx context.Context, arg UpsertTickerParams) error
 }
 
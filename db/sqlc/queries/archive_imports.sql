@@ -0,0 +1,18 @@
+-- name: GetArchiveImport :one
+SELECT *
+FROM archive_imports
+WHERE file = $1;
+
+-- name: SaveArchiveImportProgress :exec
+INSERT INTO archive_imports (file, lines_processed, items_imported)
+VALUES ($1, $2, $3)
+ON CONFLICT (file) DO UPDATE
+SET lines_processed = EXCLUDED.lines_processed,
+    items_imported = EXCLUDED.items_imported,
+    updated_at = now();
+
+-- name: CompleteArchiveImport :exec
+UPDATE archive_imports
+SET completed_at = now(),
+    updated_at = now()
+WHERE file = $1;
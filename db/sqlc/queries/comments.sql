@@ -1,9 +1,60 @@
 -- name: CreateComment :one
-INSERT INTO comments (user_id, source, external_id, content, created_at)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO comments (
+  user_id,
+  source,
+  external_id,
+  content,
+  created_at,
+  kind,
+  subreddit,
+  permalink,
+  post_id,
+  parent_id,
+  score
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 ON CONFLICT (user_id, external_id) DO UPDATE SET external_id = EXCLUDED.external_id
 RETURNING *;
 
+-- name: CreateComments :many
+-- Inserts a batch of comments. Comments that are already stored are skipped,
+-- only the created ones are returned.
+INSERT INTO comments (
+  user_id,
+  source,
+  external_id,
+  content,
+  created_at,
+  kind,
+  subreddit,
+  permalink,
+  post_id,
+  parent_id,
+  score
+)
+SELECT c.* FROM unnest(
+  @user_ids::bigint[],
+  @sources::text[],
+  @external_ids::text[],
+  @contents::text[],
+  @created_ats::timestamp[],
+  @kinds::text[],
+  @subreddits::text[],
+  @permalinks::text[],
+  @post_ids::text[],
+  @parent_ids::text[],
+  @scores::int[]
+) AS c(user_id, source, external_id, content, created_at, kind, subreddit, permalink, post_id, parent_id, score)
+ON CONFLICT (user_id, external_id) DO NOTHING
+RETURNING id, user_id, external_id;
+
+-- name: ListExistingComments :many
+-- The comments of a batch, by author and external id, that are already stored.
+SELECT u.username, c.external_id
+FROM unnest(@usernames::text[], @external_ids::text[]) AS item(username, external_id)
+JOIN users u ON u.username = item.username
+JOIN comments c ON c.user_id = u.id AND c.external_id = item.external_id;
+
 -- name: GetCommentByUserAndExternalID :one
 SELECT * FROM comments
 WHERE user_id = $1 AND external_id = $2;
@@ -0,0 +1,122 @@
+-- name: InsertEarlyCalls :execrows
+-- Spikes and first mentions only count stock mentions of users that are not
+-- excluded, like the leaderboards.
+WITH daily AS (
+  SELECT
+    tm.ticker_id,
+    tm.mentioned_at::date AS day,
+    COUNT(DISTINCT tm.user_id) AS unique_users
+  FROM ticker_mentions tm
+  JOIN users u ON u.id = tm.user_id
+  WHERE tm.instrument = 'stock'
+    AND u.username <> ALL(@excluded_usernames::text[])
+  GROUP BY tm.ticker_id, tm.mentioned_at::date
+),
+scored AS (
+  SELECT
+    ticker_id,
+    day,
+    unique_users,
+    COALESCE(SUM(unique_users) OVER (
+      PARTITION BY ticker_id
+      ORDER BY day
+      RANGE BETWEEN '30 days'::interval PRECEDING AND '1 day'::interval PRECEDING
+    ), 0) / 30.0 AS baseline
+  FROM daily
+),
+spike_days AS (
+  SELECT ticker_id, day, unique_users
+  FROM scored
+  WHERE unique_users >= @min_spike_users::int
+    AND unique_users >= @spike_multiplier::double precision * baseline
+),
+spikes AS (
+  SELECT ticker_id, day, unique_users
+  FROM (
+    SELECT
+      ticker_id,
+      day,
+      unique_users,
+      LAG(day) OVER (PARTITION BY ticker_id ORDER BY day) AS previous_day
+    FROM spike_days
+  ) s
+  WHERE previous_day IS NULL OR day - previous_day > @lookback_days::int
+),
+first_mentions AS (
+  SELECT DISTINCT ON (tm.user_id, tm.ticker_id) tm.user_id, tm.ticker_id, tm.mentioned_at
+  FROM ticker_mentions tm
+  JOIN users u ON u.id = tm.user_id
+  WHERE tm.instrument = 'stock'
+    AND u.username <> ALL(@excluded_usernames::text[])
+  ORDER BY tm.user_id, tm.ticker_id, tm.mentioned_at ASC
+)
+INSERT INTO early_calls (
+  ticker_id,
+  user_id,
+  spike_date,
+  spike_users,
+  mentioned_at,
+  generated_at
+)
+SELECT
+  sp.ticker_id,
+  fm.user_id,
+  sp.day,
+  sp.unique_users,
+  fm.mentioned_at,
+  @generated_at::timestamptz
+FROM spikes sp
+JOIN first_mentions fm
+  ON fm.ticker_id = sp.ticker_id
+  AND fm.mentioned_at < sp.day
+  AND fm.mentioned_at >= sp.day - @lookback_days::int;
+
+-- name: DeleteStaleEarlyCalls :exec
+DELETE FROM early_calls
+WHERE generated_at < @generated_at::timestamptz;
+
+-- name: GetEarlyCallersByTicker :many
+SELECT
+  u.username,
+  COALESCE(entry.entry_price::text, mention_price.price::text, '0') AS mention_price,
+  ec.mentioned_at,
+  ec.spike_date,
+  ec.spike_users,
+  COALESCE((
+    SELECT EXP(SUM(LN(ts.ratio::double precision)))
+    FROM ticker_splits ts
+    WHERE ts.ticker_id = ec.ticker_id
+      AND ts.effective_date >= COALESCE(entry.entry_at, ec.mentioned_at)
+  ), 1.0)::double precision AS split_ratio
+FROM early_calls ec
+JOIN users u ON u.id = ec.user_id
+LEFT JOIN LATERAL (
+  SELECT entry_at, entry_price
+  FROM ticker_mentions
+  WHERE user_id = ec.user_id
+    AND ticker_id = ec.ticker_id
+    AND mentioned_at = ec.mentioned_at
+  LIMIT 1
+) entry ON true
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE entry.entry_at IS NULL
+    AND ticker_id = ec.ticker_id
+    AND recorded_at <= ec.mentioned_at
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) mention_price ON true
+WHERE ec.ticker_id = $1
+  AND ec.generated_at = (SELECT MAX(generated_at) FROM early_calls)
+ORDER BY ec.spike_date ASC, ec.mentioned_at ASC;
+
+-- name: GetEarlyCallCountsByUsernames :many
+SELECT
+  u.username,
+  COUNT(DISTINCT ec.ticker_id) AS early_calls
+FROM early_calls ec
+JOIN users u ON u.id = ec.user_id
+WHERE u.username = ANY(@usernames::text[])
+  AND ec.generated_at = (SELECT MAX(generated_at) FROM early_calls)
+GROUP BY u.username;
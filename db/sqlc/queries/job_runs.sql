@@ -0,0 +1,9 @@
+-- name: CreateJobRun :exec
+INSERT INTO job_runs (job, started_at, finished_at, duration_ms, total, fetched, failed, skipped)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: ListRecentJobRuns :many
+SELECT *
+FROM job_runs
+ORDER BY started_at DESC
+LIMIT $1;
@@ -0,0 +1,272 @@
+-- name: InsertLeaderboardSnapshot :execrows
+INSERT INTO leaderboard_snapshots (
+  period,
+  horizon_days,
+  benchmark,
+  symbol,
+  username,
+  mention_price,
+  current_price,
+  current_price_date,
+  has_current_price,
+  mentioned_at,
+  split_ratio,
+  benchmark_start_price,
+  benchmark_end_price,
+  subreddit,
+  stance,
+  generated_at
+)
+SELECT
+  @period::text,
+  @horizon_days::int,
+  @benchmark::text,
+  tn.symbol,
+  u.username,
+  COALESCE(tm.entry_price, mention_price.price, 0),
+  COALESCE(current_price.price, 0),
+  COALESCE(current_price.recorded_at, now()),
+  (current_price.price IS NOT NULL),
+  tm.mentioned_at,
+  COALESCE((
+    SELECT EXP(SUM(LN(ts.ratio::double precision)))
+    FROM ticker_splits ts
+    WHERE ts.ticker_id = tm.ticker_id
+      AND ts.effective_date >= COALESCE(tm.entry_at, tm.mentioned_at)
+      AND ts.effective_date <= COALESCE(current_price.recorded_at, now())
+  ), 1.0)::double precision,
+  COALESCE(benchmark_start.price, 0),
+  COALESCE(benchmark_end.price, 0),
+  tm.subreddit,
+  tm.stance,
+  @generated_at::timestamptz
+FROM ticker_mentions tm
+JOIN users u ON u.id = tm.user_id
+JOIN ticker_names tn ON tn.id = tm.ticker_id
+LEFT JOIN LATERAL (
+  SELECT price, recorded_at
+  FROM ticker_price_points
+  WHERE tm.entry_at IS NULL
+    AND ticker_id = tm.ticker_id
+    AND recorded_at <= tm.mentioned_at
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) mention_price ON true
+LEFT JOIN LATERAL (
+  SELECT price, recorded_at
+  FROM ticker_price_points
+  WHERE ticker_id = tm.ticker_id
+    AND (@horizon_days::int = 0 OR recorded_at BETWEEN COALESCE(tm.entry_at, tm.mentioned_at) + make_interval(days => @horizon_days::int)
+      AND COALESCE(tm.entry_at, tm.mentioned_at) + make_interval(days => @horizon_days::int) + interval '7 days')
+  ORDER BY
+    CASE WHEN @horizon_days::int > 0 THEN recorded_at END ASC,
+    recorded_at DESC
+  LIMIT 1
+) current_price ON true
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE ticker_id = (SELECT id FROM ticker_names WHERE symbol = @benchmark::text)
+    AND recorded_at <= COALESCE(tm.entry_at, tm.mentioned_at)
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) benchmark_start ON true
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE ticker_id = (SELECT id FROM ticker_names WHERE symbol = @benchmark::text)
+    AND recorded_at <= COALESCE(current_price.recorded_at, now())
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) benchmark_end ON true
+WHERE tm.mentioned_at >= @mentioned_at
+  AND tm.instrument = 'stock';
+
+-- name: SetLeaderboardGeneration :exec
+INSERT INTO leaderboard_generations (period, horizon_days, benchmark, generated_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (period, horizon_days, benchmark) DO UPDATE
+SET generated_at = EXCLUDED.generated_at;
+
+-- name: DeleteLeaderboardGenerationsExcept :exec
+-- Keys that are no longer snapshotted go back to being computed live.
+DELETE FROM leaderboard_generations
+WHERE horizon_days <> $1
+   OR benchmark <> $2;
+
+-- name: DeleteStaleLeaderboardSnapshots :exec
+-- Rows of earlier runs and of keys without a generation.
+DELETE FROM leaderboard_snapshots s
+WHERE NOT EXISTS (
+  SELECT 1
+  FROM leaderboard_generations g
+  WHERE g.period = s.period
+    AND g.horizon_days = s.horizon_days
+    AND g.benchmark = s.benchmark
+    AND g.generated_at = s.generated_at
+);
+
+-- name: GetLeaderboardGeneration :one
+SELECT generated_at
+FROM leaderboard_generations
+WHERE period = $1
+  AND horizon_days = $2
+  AND benchmark = $3;
+
+-- name: GetLeaderboardPicks :many
+-- The best scored picks of a snapshot or, with ascending, the worst.
+SELECT
+  symbol,
+  mention_price,
+  current_price,
+  current_price_date,
+  mentioned_at,
+  split_ratio,
+  stance,
+  percent_change,
+  benchmark_percent_change,
+  excess_return
+FROM leaderboard_snapshots
+WHERE period = @period
+  AND horizon_days = @horizon_days
+  AND benchmark = @benchmark
+  AND generated_at = @generated_at
+  AND scorable
+  AND username <> ALL(@excluded_usernames::text[])
+  AND (@subreddit::text = '' OR subreddit = @subreddit::text)
+ORDER BY
+  CASE WHEN @by_excess_return::bool THEN excess_return ELSE percent_change END
+    * CASE WHEN @ascending::bool THEN 1 ELSE -1 END,
+  mentioned_at ASC
+LIMIT @row_limit;
+
+-- name: GetLeaderboardUsers :many
+-- The ranked users of a snapshot, scored like computeUserStats in the api
+-- package. Each pick contributes its percent change or, with
+-- by_excess_return, its excess return; the shrunk mean blends in
+-- shrinkage_prior_picks picks at the mean of every pick.
+WITH picks AS (
+  SELECT
+    username,
+    percent_change,
+    benchmark_percent_change,
+    excess_return,
+    CASE WHEN @by_excess_return::bool THEN excess_return ELSE percent_change END AS pick_return
+  FROM leaderboard_snapshots
+  WHERE period = @period
+    AND horizon_days = @horizon_days
+    AND benchmark = @benchmark
+    AND generated_at = @generated_at
+    AND scorable
+    AND username <> ALL(@excluded_usernames::text[])
+    AND (@subreddit::text = '' OR subreddit = @subreddit::text)
+),
+population AS (
+  SELECT COALESCE(AVG(pick_return), 0)::double precision AS mean
+  FROM picks
+),
+users AS (
+  SELECT
+    username,
+    COUNT(*)::int AS pick_count,
+    (COUNT(*) FILTER (WHERE pick_return > 0))::int AS wins,
+    SUM(pick_return)::double precision AS total,
+    AVG(pick_return)::double precision AS mean,
+    (percentile_cont(0.5) WITHIN GROUP (ORDER BY pick_return))::double precision AS median,
+    SUM(percent_change)::double precision AS total_percent_gain,
+    SUM(benchmark_percent_change)::double precision AS benchmark_percent_change,
+    SUM(excess_return)::double precision AS excess_return
+  FROM picks
+  GROUP BY username
+),
+stats AS (
+  SELECT
+    u.*,
+    u.wins::double precision / u.pick_count AS win_rate,
+    -- Lower bound of the Wilson score interval for wins/pick_count
+    (u.wins::double precision / u.pick_count
+      + @wilson_z::double precision ^ 2 / (2 * u.pick_count)
+      - @wilson_z::double precision * sqrt(
+          (u.wins::double precision / u.pick_count * (1 - u.wins::double precision / u.pick_count)
+            + @wilson_z::double precision ^ 2 / (4 * u.pick_count)) / u.pick_count))
+      / (1 + @wilson_z::double precision ^ 2 / u.pick_count) AS wilson_lower_bound,
+    (u.total + @shrinkage_prior_picks::double precision * p.mean)
+      / (u.pick_count + @shrinkage_prior_picks::double precision) AS shrunk_mean
+  FROM users u
+  CROSS JOIN population p
+),
+scored AS (
+  SELECT
+    stats.*,
+    (CASE @score_mode::text
+      WHEN 'mean' THEN mean
+      WHEN 'median' THEN median
+      WHEN 'win_rate' THEN win_rate
+      WHEN 'wilson' THEN wilson_lower_bound
+      WHEN 'shrinkage' THEN shrunk_mean
+      ELSE total
+    END)::double precision AS score
+  FROM stats
+)
+SELECT
+  username,
+  pick_count,
+  wins,
+  total,
+  mean,
+  median,
+  win_rate::double precision AS win_rate,
+  wilson_lower_bound::double precision AS wilson_lower_bound,
+  shrunk_mean::double precision AS shrunk_mean,
+  total_percent_gain,
+  benchmark_percent_change,
+  excess_return,
+  score
+FROM scored
+WHERE pick_count >= @min_picks::int
+  AND score >= 0
+ORDER BY score DESC, username ASC
+LIMIT @row_limit;
+
+-- name: GetLeaderboardUserPicks :many
+-- The scored picks of the given users in a snapshot, best first.
+SELECT
+  username,
+  symbol,
+  mention_price,
+  current_price,
+  split_ratio,
+  stance,
+  percent_change,
+  benchmark_percent_change,
+  excess_return
+FROM leaderboard_snapshots
+WHERE period = @period
+  AND horizon_days = @horizon_days
+  AND benchmark = @benchmark
+  AND generated_at = @generated_at
+  AND scorable
+  AND username = ANY(@usernames::text[])
+  AND (@subreddit::text = '' OR subreddit = @subreddit::text)
+ORDER BY username ASC, percent_change DESC;
+
+-- name: GetLeaderboardSubreddits :many
+-- Mention volume and average pick performance per subreddit of a snapshot.
+SELECT
+  subreddit,
+  COUNT(*)::int AS mentions,
+  COUNT(DISTINCT username)::int AS unique_users,
+  COUNT(DISTINCT symbol)::int AS unique_tickers,
+  AVG(percent_change)::double precision AS average_percent_change,
+  AVG(excess_return)::double precision AS average_excess_return,
+  ((COUNT(*) FILTER (WHERE percent_change > 0))::double precision / COUNT(*))::double precision AS win_rate
+FROM leaderboard_snapshots
+WHERE period = @period
+  AND horizon_days = @horizon_days
+  AND benchmark = @benchmark
+  AND generated_at = @generated_at
+  AND scorable
+  AND subreddit <> ''
+  AND username <> ALL(@excluded_usernames::text[])
+GROUP BY subreddit
+ORDER BY mentions DESC, subreddit ASC;
@@ -0,0 +1,58 @@
+-- name: CreateMentionOption :exec
+INSERT INTO mention_options (mention_id, option_type, strike, expires_at, written)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: CreateMentionOptions :exec
+-- Inserts a batch of option contracts. An empty expires_at is stored as NULL.
+INSERT INTO mention_options (mention_id, option_type, strike, expires_at, written)
+SELECT
+  o.mention_id,
+  o.option_type,
+  o.strike::numeric,
+  NULLIF(o.expires_at, '')::timestamptz,
+  o.written
+FROM unnest(
+  @mention_ids::bigint[],
+  @option_types::text[],
+  @strikes::text[],
+  @expires_ats::text[],
+  @written::bool[]
+) AS o(mention_id, option_type, strike, expires_at, written);
+
+-- name: GetUserOptionPositions :many
+-- Every option contract of a user, with the underlying at the mention's
+-- entry and at expiry (the latest price while the contract runs), and the
+-- splits in between.
+SELECT
+  tn.symbol,
+  o.option_type,
+  o.strike::text AS strike,
+  o.expires_at,
+  o.written,
+  COALESCE(tm.entry_price::text, '0') AS entry_price,
+  COALESCE(underlying.price::text, '0') AS underlying_price,
+  underlying.recorded_at AS underlying_price_date,
+  (underlying.recorded_at IS NOT NULL AND tm.entry_at IS NOT NULL AND underlying.recorded_at > tm.entry_at)::boolean AS has_underlying_price,
+  COALESCE((
+    SELECT EXP(SUM(LN(ts.ratio::double precision)))
+    FROM ticker_splits ts
+    WHERE ts.ticker_id = tm.ticker_id
+      AND ts.effective_date >= COALESCE(tm.entry_at, tm.mentioned_at)
+      AND ts.effective_date <= COALESCE(underlying.recorded_at, now())
+  ), 1.0)::double precision AS split_ratio,
+  tm.mentioned_at,
+  c.permalink
+FROM mention_options o
+JOIN ticker_mentions tm ON tm.id = o.mention_id
+JOIN ticker_names tn ON tn.id = tm.ticker_id
+JOIN comments c ON c.id = tm.comment_id
+LEFT JOIN LATERAL (
+  SELECT price, recorded_at
+  FROM ticker_price_points
+  WHERE ticker_id = tm.ticker_id
+    AND recorded_at <= COALESCE(o.expires_at, now())
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) underlying ON true
+WHERE tm.user_id = (SELECT id FROM users WHERE username = @username)
+ORDER BY tm.mentioned_at DESC, o.id ASC;
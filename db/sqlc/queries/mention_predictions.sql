@@ -0,0 +1,64 @@
+-- name: CreateMentionPrediction :exec
+INSERT INTO mention_predictions (mention_id, target_price, multiple, deadline, claim)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: CreateMentionPredictions :exec
+-- Inserts a batch of predictions. An empty target_price or a multiple of 0
+-- is stored as NULL.
+INSERT INTO mention_predictions (mention_id, target_price, multiple, deadline, claim)
+SELECT
+  p.mention_id,
+  NULLIF(p.target_price, '')::numeric,
+  NULLIF(p.multiple, 0),
+  p.deadline,
+  p.claim
+FROM unnest(
+  @mention_ids::bigint[],
+  @target_prices::text[],
+  @multiples::double precision[],
+  @deadlines::timestamptz[],
+  @claims::text[]
+) AS p(mention_id, target_price, multiple, deadline, claim);
+
+-- name: ListOpenPredictions :many
+-- Open predictions whose mention has an entry price and that are not backing
+-- off from a failure, the ones evaluated longest ago first.
+SELECT p.id, p.target_price, p.multiple, p.deadline, p.failures,
+       tm.ticker_id, tn.symbol, tm.entry_at, tm.entry_price
+FROM mention_predictions p
+JOIN ticker_mentions tm ON tm.id = p.mention_id
+JOIN ticker_names tn ON tn.id = tm.ticker_id
+WHERE p.status = 'open'
+  AND tm.entry_price IS NOT NULL
+  AND (p.retry_at IS NULL OR p.retry_at <= now())
+ORDER BY p.evaluated_at ASC NULLS FIRST, p.deadline ASC
+LIMIT @row_limit;
+
+-- name: SetPredictionResult :exec
+UPDATE mention_predictions
+SET target_price = $2,
+    status = $3,
+    progress = $4,
+    resolved_at = $5,
+    evaluated_at = now(),
+    failures = 0,
+    retry_at = NULL
+WHERE id = $1;
+
+-- name: SetPredictionFailed :exec
+UPDATE mention_predictions
+SET failures = failures + 1,
+    retry_at = @retry_at,
+    evaluated_at = now()
+WHERE id = @id;
+
+-- name: GetUserPredictions :many
+SELECT tn.symbol, p.claim, p.target_price, p.multiple, tm.entry_price,
+       p.deadline, p.status, p.progress, p.resolved_at,
+       tm.mentioned_at, tm.stance, c.permalink
+FROM mention_predictions p
+JOIN ticker_mentions tm ON tm.id = p.mention_id
+JOIN ticker_names tn ON tn.id = tm.ticker_id
+JOIN comments c ON c.id = tm.comment_id
+WHERE tm.user_id = (SELECT id FROM users WHERE username = @username)
+ORDER BY tm.mentioned_at DESC, p.id ASC;
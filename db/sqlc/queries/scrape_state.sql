@@ -0,0 +1,37 @@
+-- name: GetScrapeCheckpoint :one
+SELECT *
+FROM scrape_checkpoints
+WHERE source = $1 AND feed = $2 AND stream = $3;
+
+-- name: UpsertScrapeCheckpoint :exec
+INSERT INTO scrape_checkpoints (source, feed, stream, item_id, created_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (source, feed, stream) DO UPDATE
+SET item_id = EXCLUDED.item_id,
+    created_at = EXCLUDED.created_at,
+    updated_at = now();
+
+-- name: CreateRedditThread :exec
+INSERT INTO reddit_threads (post_id, subreddit, posted_at, last_comment_at, next_visit_at)
+VALUES ($1, $2, $3, $3, $4)
+ON CONFLICT (post_id) DO NOTHING;
+
+-- name: TouchRedditThread :exec
+UPDATE reddit_threads
+SET last_comment_at = GREATEST(last_comment_at, $2::timestamp)
+WHERE post_id = $1;
+
+-- name: ListDueRedditThreads :many
+SELECT *
+FROM reddit_threads
+WHERE subreddit = $1
+  AND next_visit_at <= $2
+  AND posted_at >= $3
+ORDER BY next_visit_at ASC
+LIMIT $4;
+
+-- name: UpdateRedditThreadVisit :exec
+UPDATE reddit_threads
+SET last_comment_at = GREATEST(last_comment_at, $2::timestamp),
+    next_visit_at = $3
+WHERE post_id = $1;
@@ -0,0 +1,29 @@
+-- name: CreateSubreddit :one
+INSERT INTO subreddits (name, enabled, scrape_interval_minutes, lookback_hours)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetSubredditByName :one
+SELECT *
+FROM subreddits
+WHERE name = $1;
+
+-- name: ListSubreddits :many
+SELECT *
+FROM subreddits
+ORDER BY name;
+
+-- name: ListEnabledSubreddits :many
+SELECT *
+FROM subreddits
+WHERE enabled
+ORDER BY name;
+
+-- name: UpdateSubreddit :one
+UPDATE subreddits
+SET enabled = $2,
+    scrape_interval_minutes = $3,
+    lookback_hours = $4,
+    updated_at = now()
+WHERE name = $1
+RETURNING *;
@@ -0,0 +1,18 @@
+-- name: ListTickerAliases :many
+SELECT *
+FROM ticker_aliases
+ORDER BY alias;
+
+-- name: GetTickerAlias :one
+SELECT *
+FROM ticker_aliases
+WHERE alias = $1;
+
+-- name: CreateTickerAlias :one
+INSERT INTO ticker_aliases (alias, symbol, kind)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: DeleteTickerAlias :execrows
+DELETE FROM ticker_aliases
+WHERE alias = $1;
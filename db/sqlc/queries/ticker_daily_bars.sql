@@ -0,0 +1,36 @@
+-- name: UpsertDailyBar :exec
+INSERT INTO ticker_daily_bars (ticker_id, day, open, high, low, close, volume, opened_at, closed_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (ticker_id, day) DO UPDATE
+SET open = EXCLUDED.open,
+    high = EXCLUDED.high,
+    low = EXCLUDED.low,
+    close = EXCLUDED.close,
+    volume = EXCLUDED.volume,
+    opened_at = EXCLUDED.opened_at,
+    closed_at = EXCLUDED.closed_at;
+
+-- name: GetDailyBar :one
+SELECT *
+FROM ticker_daily_bars
+WHERE ticker_id = $1 AND day = $2;
+
+-- name: ListDailyBarTargets :many
+-- Benchmarks and every mentioned ticker, with their first mention and the
+-- newest stored bar.
+SELECT t.id, t.symbol, t.exchange,
+       MIN(m.mentioned_at)::timestamp AS first_mentioned_at,
+       (SELECT MAX(b.day) FROM ticker_daily_bars b WHERE b.ticker_id = t.id)::date AS last_bar_day
+FROM ticker_names t
+LEFT JOIN ticker_mentions m ON m.ticker_id = t.id
+WHERE t.exchange = 'BENCHMARK' OR m.id IS NOT NULL
+GROUP BY t.id
+ORDER BY t.symbol;
+
+-- name: ListDailyBarsBetween :many
+SELECT *
+FROM ticker_daily_bars
+WHERE ticker_id = @ticker_id
+  AND day >= @from_day
+  AND day <= @to_day
+ORDER BY day ASC;
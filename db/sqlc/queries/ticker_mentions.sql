@@ -3,80 +3,391 @@ INSERT INTO ticker_mentions (
   ticker_id,
   user_id,
   comment_id,
-  mentioned_at
+  mentioned_at,
+  subreddit,
+  confidence,
+  detected_by,
+  stance,
+  instrument
 )
-VALUES ($1, $2, $3, $4)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 RETURNING *;
 
+-- name: CreateTickerMentions :many
+-- Inserts a batch of mentions with their entries. Entries are NULL where
+-- entry_at and entry_price are empty, and an entry without a rule was not
+-- resolved, so the mention-entries job resolves it.
+INSERT INTO ticker_mentions (
+  ticker_id,
+  user_id,
+  comment_id,
+  mentioned_at,
+  subreddit,
+  confidence,
+  detected_by,
+  stance,
+  instrument,
+  entry_rule,
+  entry_at,
+  entry_price
+)
+SELECT
+  m.ticker_id,
+  m.user_id,
+  m.comment_id,
+  m.mentioned_at,
+  m.subreddit,
+  m.confidence,
+  m.detected_by,
+  m.stance,
+  m.instrument,
+  m.entry_rule,
+  NULLIF(m.entry_at, '')::timestamptz,
+  NULLIF(m.entry_price, '')::numeric
+FROM unnest(
+  @ticker_ids::bigint[],
+  @user_ids::bigint[],
+  @comment_ids::bigint[],
+  @mentioned_ats::timestamp[],
+  @subreddits::text[],
+  @confidences::double precision[],
+  @detected_bys::text[],
+  @stances::text[],
+  @instruments::text[],
+  @entry_rules::text[],
+  @entry_ats::text[],
+  @entry_prices::text[]
+) AS m(ticker_id, user_id, comment_id, mentioned_at, subreddit, confidence, detected_by, stance, instrument, entry_rule, entry_at, entry_price)
+RETURNING id, comment_id, ticker_id;
+
+-- name: SetMentionEntry :exec
+UPDATE ticker_mentions
+SET entry_rule = $2,
+    entry_at = $3,
+    entry_price = $4
+WHERE id = $1;
+
+-- name: ListMentionEntriesDue :many
+-- Mentions without an entry under the current rule, plus recent entries whose
+-- price was not known yet. Newest first, so fresh mentions are priced before
+-- a backfill of old ones.
+SELECT tm.id, tm.ticker_id, tn.symbol, tm.mentioned_at, tm.entry_rule, tm.entry_at
+FROM ticker_mentions tm
+JOIN ticker_names tn ON tn.id = tm.ticker_id
+WHERE tm.entry_rule <> @entry_rule::text
+   OR (tm.entry_price IS NULL AND tm.entry_at <= now() AND tm.entry_at >= @retry_since)
+ORDER BY tm.mentioned_at DESC
+LIMIT @row_limit;
+
 -- name: GetUserMentionsComplete :many
 SELECT
   tn.symbol,
-  COALESCE(mention_price.price::text, '0') AS mention_price,
+  COALESCE(tm.entry_price::text, mention_price.price::text, '0') AS mention_price,
   COALESCE(current_price.price::text, '0') AS current_price,
   COALESCE(current_price.recorded_at, now()) AS current_price_date,
+  (current_price.price IS NOT NULL)::boolean AS has_current_price,
   tm.mentioned_at,
   COALESCE((
     SELECT EXP(SUM(LN(ts.ratio::double precision)))
     FROM ticker_splits ts
     WHERE ts.ticker_id = tm.ticker_id
-      AND ts.effective_date >= tm.mentioned_at
+      AND ts.effective_date >= COALESCE(tm.entry_at, tm.mentioned_at)
       AND ts.effective_date <= COALESCE(current_price.recorded_at, now())
-  ), 1.0)::double precision AS split_ratio
+  ), 1.0)::double precision AS split_ratio,
+  COALESCE(benchmark_start.price::text, '0') AS benchmark_start_price,
+  COALESCE(benchmark_end.price::text, '0') AS benchmark_end_price,
+  c.permalink,
+  tm.stance
 FROM (
-  SELECT DISTINCT ON (ticker_id) ticker_id, mentioned_at
+  SELECT DISTINCT ON (ticker_id) ticker_id, comment_id, mentioned_at, entry_at, entry_price, stance
   FROM ticker_mentions
-  WHERE user_id = (SELECT id FROM users WHERE username = $1)
-    AND mentioned_at >= $2
+  WHERE user_id = (SELECT id FROM users WHERE username = @username)
+    AND mentioned_at >= @mentioned_at
+    AND (@subreddit::text = '' OR subreddit = @subreddit::text)
+    AND instrument = 'stock'
   ORDER BY ticker_id, mentioned_at ASC
 ) tm
 JOIN ticker_names tn ON tn.id = tm.ticker_id
+JOIN comments c ON c.id = tm.comment_id
 LEFT JOIN LATERAL (
   SELECT price, recorded_at
-  FROM ticker_prices
-  WHERE ticker_id = tm.ticker_id AND recorded_at <= tm.mentioned_at
+  FROM ticker_price_points
+  WHERE tm.entry_at IS NULL
+    AND ticker_id = tm.ticker_id
+    AND recorded_at <= tm.mentioned_at
   ORDER BY recorded_at DESC
   LIMIT 1
 ) mention_price ON true
 LEFT JOIN LATERAL (
   SELECT price, recorded_at
-  FROM ticker_prices
+  FROM ticker_price_points
   WHERE ticker_id = tm.ticker_id
-  ORDER BY recorded_at DESC
+    AND (@horizon_days::int = 0 OR recorded_at BETWEEN COALESCE(tm.entry_at, tm.mentioned_at) + make_interval(days => @horizon_days::int)
+      AND COALESCE(tm.entry_at, tm.mentioned_at) + make_interval(days => @horizon_days::int) + interval '7 days')
+  ORDER BY
+    CASE WHEN @horizon_days::int > 0 THEN recorded_at END ASC,
+    recorded_at DESC
   LIMIT 1
 ) current_price ON true
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE ticker_id = (SELECT id FROM ticker_names WHERE symbol = @benchmark)
+    AND recorded_at <= COALESCE(tm.entry_at, tm.mentioned_at)
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) benchmark_start ON true
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE ticker_id = (SELECT id FROM ticker_names WHERE symbol = @benchmark)
+    AND recorded_at <= COALESCE(current_price.recorded_at, now())
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) benchmark_end ON true
 ORDER BY tn.symbol;
 
 -- name: GetAllMentionsComplete :many
 SELECT
   tn.symbol,
   u.username,
-  COALESCE(mention_price.price::text, '0') AS mention_price,
+  COALESCE(tm.entry_price::text, mention_price.price::text, '0') AS mention_price,
   COALESCE(current_price.price::text, '0') AS current_price,
   COALESCE(current_price.recorded_at, now()) AS current_price_date,
+  (current_price.price IS NOT NULL)::boolean AS has_current_price,
   tm.mentioned_at,
   COALESCE((
     SELECT EXP(SUM(LN(ts.ratio::double precision)))
     FROM ticker_splits ts
     WHERE ts.ticker_id = tm.ticker_id
-      AND ts.effective_date >= tm.mentioned_at
+      AND ts.effective_date >= COALESCE(tm.entry_at, tm.mentioned_at)
       AND ts.effective_date <= COALESCE(current_price.recorded_at, now())
-  ), 1.0)::double precision AS split_ratio
+  ), 1.0)::double precision AS split_ratio,
+  COALESCE(benchmark_start.price::text, '0') AS benchmark_start_price,
+  COALESCE(benchmark_end.price::text, '0') AS benchmark_end_price,
+  tm.subreddit,
+  tm.stance
 FROM ticker_mentions tm
 JOIN users u ON u.id = tm.user_id
 JOIN ticker_names tn ON tn.id = tm.ticker_id
 LEFT JOIN LATERAL (
   SELECT price, recorded_at
-  FROM ticker_prices
-  WHERE ticker_id = tm.ticker_id AND recorded_at <= tm.mentioned_at
+  FROM ticker_price_points
+  WHERE tm.entry_at IS NULL
+    AND ticker_id = tm.ticker_id
+    AND recorded_at <= tm.mentioned_at
   ORDER BY recorded_at DESC
   LIMIT 1
 ) mention_price ON true
 LEFT JOIN LATERAL (
   SELECT price, recorded_at
-  FROM ticker_prices
+  FROM ticker_price_points
   WHERE ticker_id = tm.ticker_id
+    AND (@horizon_days::int = 0 OR recorded_at BETWEEN COALESCE(tm.entry_at, tm.mentioned_at) + make_interval(days => @horizon_days::int)
+      AND COALESCE(tm.entry_at, tm.mentioned_at) + make_interval(days => @horizon_days::int) + interval '7 days')
+  ORDER BY
+    CASE WHEN @horizon_days::int > 0 THEN recorded_at END ASC,
+    recorded_at DESC
+  LIMIT 1
+) current_price ON true
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE ticker_id = (SELECT id FROM ticker_names WHERE symbol = @benchmark)
+    AND recorded_at <= COALESCE(tm.entry_at, tm.mentioned_at)
   ORDER BY recorded_at DESC
   LIMIT 1
+) benchmark_start ON true
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE ticker_id = (SELECT id FROM ticker_names WHERE symbol = @benchmark)
+    AND recorded_at <= COALESCE(current_price.recorded_at, now())
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) benchmark_end ON true
+WHERE tm.mentioned_at >= @mentioned_at
+  AND (@subreddit::text = '' OR tm.subreddit = @subreddit::text)
+  AND tm.instrument = 'stock'
+ORDER BY tm.mentioned_at ASC;
+
+-- name: GetUsersMentionsComplete :many
+SELECT
+  tm.username,
+  tn.symbol,
+  COALESCE(tm.entry_price::text, mention_price.price::text, '0') AS mention_price,
+  COALESCE(current_price.price::text, '0') AS current_price,
+  COALESCE(current_price.recorded_at, now()) AS current_price_date,
+  (current_price.price IS NOT NULL)::boolean AS has_current_price,
+  tm.mentioned_at,
+  COALESCE((
+    SELECT EXP(SUM(LN(ts.ratio::double precision)))
+    FROM ticker_splits ts
+    WHERE ts.ticker_id = tm.ticker_id
+      AND ts.effective_date >= COALESCE(tm.entry_at, tm.mentioned_at)
+      AND ts.effective_date <= COALESCE(current_price.recorded_at, now())
+  ), 1.0)::double precision AS split_ratio,
+  COALESCE(benchmark_start.price::text, '0') AS benchmark_start_price,
+  COALESCE(benchmark_end.price::text, '0') AS benchmark_end_price,
+  c.permalink,
+  tm.stance
+FROM (
+  SELECT DISTINCT ON (m.user_id, m.ticker_id) u.username, m.ticker_id, m.comment_id, m.mentioned_at, m.entry_at, m.entry_price, m.stance
+  FROM ticker_mentions m
+  JOIN users u ON u.id = m.user_id
+  WHERE u.username = ANY(@usernames::text[])
+    AND m.mentioned_at >= @mentioned_at
+    AND (@subreddit::text = '' OR m.subreddit = @subreddit::text)
+    AND m.instrument = 'stock'
+  ORDER BY m.user_id, m.ticker_id, m.mentioned_at ASC
+) tm
+JOIN ticker_names tn ON tn.id = tm.ticker_id
+JOIN comments c ON c.id = tm.comment_id
+LEFT JOIN LATERAL (
+  SELECT price, recorded_at
+  FROM ticker_price_points
+  WHERE tm.entry_at IS NULL
+    AND ticker_id = tm.ticker_id
+    AND recorded_at <= tm.mentioned_at
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) mention_price ON true
+LEFT JOIN LATERAL (
+  SELECT price, recorded_at
+  FROM ticker_price_points
+  WHERE ticker_id = tm.ticker_id
+    AND (@horizon_days::int = 0 OR recorded_at BETWEEN COALESCE(tm.entry_at, tm.mentioned_at) + make_interval(days => @horizon_days::int)
+      AND COALESCE(tm.entry_at, tm.mentioned_at) + make_interval(days => @horizon_days::int) + interval '7 days')
+  ORDER BY
+    CASE WHEN @horizon_days::int > 0 THEN recorded_at END ASC,
+    recorded_at DESC
+  LIMIT 1
 ) current_price ON true
-WHERE tm.mentioned_at >= $1
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE ticker_id = (SELECT id FROM ticker_names WHERE symbol = @benchmark)
+    AND recorded_at <= COALESCE(tm.entry_at, tm.mentioned_at)
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) benchmark_start ON true
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE ticker_id = (SELECT id FROM ticker_names WHERE symbol = @benchmark)
+    AND recorded_at <= COALESCE(current_price.recorded_at, now())
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) benchmark_end ON true
+ORDER BY tm.username, tn.symbol;
+
+-- name: GetDailyMentionCounts :many
+SELECT
+  tm.mentioned_at::date AS day,
+  COUNT(*) AS mention_count,
+  COUNT(DISTINCT tm.user_id) AS unique_users
+FROM ticker_mentions tm
+JOIN users u ON u.id = tm.user_id
+WHERE tm.ticker_id = @ticker_id
+  AND tm.instrument = 'stock'
+  AND u.username <> ALL(@excluded_usernames::text[])
+GROUP BY tm.mentioned_at::date
+ORDER BY day ASC;
+
+-- name: GetTickerMentioners :many
+SELECT
+  u.username,
+  COALESCE(tm.entry_price::text, mention_price.price::text, '0') AS mention_price,
+  tm.mentioned_at,
+  COALESCE((
+    SELECT EXP(SUM(LN(ts.ratio::double precision)))
+    FROM ticker_splits ts
+    WHERE ts.ticker_id = tm.ticker_id
+      AND ts.effective_date >= COALESCE(tm.entry_at, tm.mentioned_at)
+  ), 1.0)::double precision AS split_ratio
+FROM (
+  SELECT DISTINCT ON (user_id) user_id, ticker_id, mentioned_at, entry_at, entry_price
+  FROM ticker_mentions
+  WHERE ticker_id = @ticker_id
+    AND instrument = 'stock'
+  ORDER BY user_id, mentioned_at ASC
+) tm
+JOIN users u ON u.id = tm.user_id
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE tm.entry_at IS NULL
+    AND ticker_id = tm.ticker_id
+    AND recorded_at <= tm.mentioned_at
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) mention_price ON true
+WHERE u.username <> ALL(@excluded_usernames::text[])
 ORDER BY tm.mentioned_at ASC;
+
+-- name: GetTrendingTickers :many
+WITH recent AS (
+  SELECT
+    tm.ticker_id,
+    COUNT(*) AS mention_count,
+    COUNT(DISTINCT tm.user_id) AS unique_users
+  FROM ticker_mentions tm
+  JOIN users u ON u.id = tm.user_id
+  WHERE tm.mentioned_at >= @window_start
+    AND u.username <> ALL(@excluded_usernames::text[])
+    AND tm.instrument = 'stock'
+  GROUP BY tm.ticker_id
+),
+baseline_buckets AS (
+  SELECT
+    tm.ticker_id,
+    FLOOR(EXTRACT(EPOCH FROM (@window_start - tm.mentioned_at)) / @window_seconds::double precision) AS bucket,
+    COUNT(DISTINCT tm.user_id) AS unique_users
+  FROM ticker_mentions tm
+  JOIN users u ON u.id = tm.user_id
+  WHERE tm.mentioned_at >= @baseline_start
+    AND tm.mentioned_at < @window_start
+    AND u.username <> ALL(@excluded_usernames::text[])
+    AND tm.instrument = 'stock'
+  GROUP BY tm.ticker_id, bucket
+),
+baseline AS (
+  SELECT
+    ticker_id,
+    SUM(unique_users)::double precision AS user_sum,
+    SUM(unique_users * unique_users)::double precision AS user_sum_squares
+  FROM baseline_buckets
+  GROUP BY ticker_id
+)
+SELECT
+  tn.symbol,
+  r.mention_count,
+  r.unique_users,
+  COALESCE(b.user_sum, 0)::double precision AS baseline_user_sum,
+  COALESCE(b.user_sum_squares, 0)::double precision AS baseline_user_sum_squares,
+  COALESCE(current_price.price::text, '0') AS current_price,
+  COALESCE(window_start_price.price::text, '0') AS window_start_price,
+  COALESCE((
+    SELECT EXP(SUM(LN(ts.ratio::double precision)))
+    FROM ticker_splits ts
+    WHERE ts.ticker_id = r.ticker_id
+      AND ts.effective_date >= @window_start
+  ), 1.0)::double precision AS split_ratio
+FROM recent r
+JOIN ticker_names tn ON tn.id = r.ticker_id
+LEFT JOIN baseline b ON b.ticker_id = r.ticker_id
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE ticker_id = r.ticker_id
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) current_price ON true
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE ticker_id = r.ticker_id AND recorded_at <= @window_start
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) window_start_price ON true
+WHERE r.unique_users >= @min_unique_users::int;
@@ -15,3 +15,21 @@ ON CONFLICT (symbol) DO NOTHING;
 
 -- name: ListAllTickers :many
 SELECT * FROM ticker_names ORDER BY symbol;
+
+-- name: ListTickersByMentionPriority :many
+-- Benchmarks first, then mentioned tickers (most recently mentioned first),
+-- then everything else.
+SELECT t.id, t.symbol, t.exchange, t.price_refreshed_at,
+       COUNT(m.id)::bigint AS mention_count,
+       MAX(m.mentioned_at)::timestamp AS last_mentioned_at
+FROM ticker_names t
+LEFT JOIN ticker_mentions m ON m.ticker_id = t.id
+GROUP BY t.id
+ORDER BY (t.exchange = 'BENCHMARK') DESC,
+         MAX(m.mentioned_at) DESC NULLS LAST,
+         t.symbol;
+
+-- name: MarkTickerPriceRefreshed :exec
+UPDATE ticker_names
+SET price_refreshed_at = now()
+WHERE id = $1;
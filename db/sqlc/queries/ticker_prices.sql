@@ -6,8 +6,8 @@ RETURNING *;
 
 
 -- name: GetTickerPriceBeforeDate :one
-SELECT id, ticker_id, price, recorded_at, volume
-FROM ticker_prices
+SELECT price, recorded_at
+FROM ticker_price_points
 WHERE ticker_id = $1 AND recorded_at <= $2
 ORDER BY recorded_at DESC
 LIMIT 1;
@@ -18,3 +18,31 @@ WHERE ticker_id = $1 AND DATE(recorded_at) = DATE($2);
 
 
 
+
+-- name: GetDailyTickerPrices :many
+-- Daily bars, plus the latest snapshot of days without a bar (e.g. today).
+SELECT DISTINCT ON (p.day)
+  p.day,
+  p.open,
+  p.high,
+  p.low,
+  p.price,
+  p.volume
+FROM (
+  SELECT day, open, high, low, close AS price, volume, closed_at AS recorded_at, 0 AS rank
+  FROM ticker_daily_bars
+  WHERE ticker_id = $1
+  UNION ALL
+  SELECT recorded_at::date, price, price, price, price, volume, recorded_at, 1
+  FROM ticker_prices
+  WHERE ticker_id = $1
+) p
+ORDER BY p.day ASC, p.rank ASC, p.recorded_at DESC;
+
+-- name: ListTickerPricesBetween :many
+SELECT price, recorded_at
+FROM ticker_prices
+WHERE ticker_id = @ticker_id
+  AND recorded_at > @from_time
+  AND recorded_at <= @to_time
+ORDER BY recorded_at ASC;
@@ -3,6 +3,19 @@ INSERT INTO users (username)
 VALUES ($1)
 RETURNING id, username, created_at;
 
+-- name: CreateUsers :many
+-- Creates the users of a batch that do not exist yet and returns the ids of
+-- all of them.
+WITH created AS (
+  INSERT INTO users (username)
+  SELECT DISTINCT unnest(@usernames::text[])
+  ON CONFLICT (username) DO NOTHING
+  RETURNING id, username
+)
+SELECT id, username FROM created
+UNION ALL
+SELECT id, username FROM users WHERE username = ANY(@usernames::text[]);
+
 -- name: GetUserByUsername :one
 SELECT id, username, created_at
 FROM users
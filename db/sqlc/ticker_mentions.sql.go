@@ -7,7 +7,10 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 const createTickerMention = `-- name: CreateTickerMention :one
@@ -15,17 +18,27 @@ INSERT INTO ticker_mentions (
   ticker_id,
   user_id,
   comment_id,
-  mentioned_at
+  mentioned_at,
+  subreddit,
+  confidence,
+  detected_by,
+  stance,
+  instrument
 )
-VALUES ($1, $2, $3, $4)
-RETURNING id, ticker_id, user_id, comment_id, mentioned_at
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, ticker_id, user_id, comment_id, mentioned_at, subreddit, entry_rule, entry_at, entry_price, confidence, detected_by, stance, instrument
 `
 
 type CreateTickerMentionParams struct {
-	TickerID    int64     `json:"ticker_id"`
-	UserID      int64     `json:"user_id"`
-	CommentID   int64     `json:"comment_id"`
-	MentionedAt time.Time `json:"mentioned_at"`
+	TickerID    int64           `json:"ticker_id"`
+	UserID      int64           `json:"user_id"`
+	CommentID   int64           `json:"comment_id"`
+	MentionedAt time.Time       `json:"mentioned_at"`
+	Subreddit   string          `json:"subreddit"`
+	Confidence  sql.NullFloat64 `json:"confidence"`
+	DetectedBy  string          `json:"detected_by"`
+	Stance      string          `json:"stance"`
+	Instrument  string          `json:"instrument"`
 }
 
 func (q *Queries) CreateTickerMention(ctx context.Context, arg CreateTickerMentionParams) (TickerMention, error) {
@@ -34,6 +47,11 @@ func (q *Queries) CreateTickerMention(ctx context.Context, arg CreateTickerMenti
 		arg.UserID,
 		arg.CommentID,
 		arg.MentionedAt,
+		arg.Subreddit,
+		arg.Confidence,
+		arg.DetectedBy,
+		arg.Stance,
+		arg.Instrument,
 	)
 	var i TickerMention
 	err := row.Scan(
@@ -42,58 +60,217 @@ func (q *Queries) CreateTickerMention(ctx context.Context, arg CreateTickerMenti
 		&i.UserID,
 		&i.CommentID,
 		&i.MentionedAt,
+		&i.Subreddit,
+		&i.EntryRule,
+		&i.EntryAt,
+		&i.EntryPrice,
+		&i.Confidence,
+		&i.DetectedBy,
+		&i.Stance,
+		&i.Instrument,
 	)
 	return i, err
 }
 
+const createTickerMentions = `-- name: CreateTickerMentions :many
+INSERT INTO ticker_mentions (
+  ticker_id,
+  user_id,
+  comment_id,
+  mentioned_at,
+  subreddit,
+  confidence,
+  detected_by,
+  stance,
+  instrument,
+  entry_rule,
+  entry_at,
+  entry_price
+)
+SELECT
+  m.ticker_id,
+  m.user_id,
+  m.comment_id,
+  m.mentioned_at,
+  m.subreddit,
+  m.confidence,
+  m.detected_by,
+  m.stance,
+  m.instrument,
+  m.entry_rule,
+  NULLIF(m.entry_at, '')::timestamptz,
+  NULLIF(m.entry_price, '')::numeric
+FROM unnest(
+  $1::bigint[],
+  $2::bigint[],
+  $3::bigint[],
+  $4::timestamp[],
+  $5::text[],
+  $6::double precision[],
+  $7::text[],
+  $8::text[],
+  $9::text[],
+  $10::text[],
+  $11::text[],
+  $12::text[]
+) AS m(ticker_id, user_id, comment_id, mentioned_at, subreddit, confidence, detected_by, stance, instrument, entry_rule, entry_at, entry_price)
+RETURNING id, comment_id, ticker_id
+`
+
+type CreateTickerMentionsParams struct {
+	TickerIds    []int64     `json:"ticker_ids"`
+	UserIds      []int64     `json:"user_ids"`
+	CommentIds   []int64     `json:"comment_ids"`
+	MentionedAts []time.Time `json:"mentioned_ats"`
+	Subreddits   []string    `json:"subreddits"`
+	Confidences  []float64   `json:"confidences"`
+	DetectedBys  []string    `json:"detected_bys"`
+	Stances      []string    `json:"stances"`
+	Instruments  []string    `json:"instruments"`
+	EntryRules   []string    `json:"entry_rules"`
+	EntryAts     []string    `json:"entry_ats"`
+	EntryPrices  []string    `json:"entry_prices"`
+}
+
+type CreateTickerMentionsRow struct {
+	ID        int64 `json:"id"`
+	CommentID int64 `json:"comment_id"`
+	TickerID  int64 `json:"ticker_id"`
+}
+
+// Inserts a batch of mentions with their entries. Entries are NULL where
+// entry_at and entry_price are empty, and an entry without a rule was not
+// resolved, so the mention-entries job resolves it.
+func (q *Queries) CreateTickerMentions(ctx context.Context, arg CreateTickerMentionsParams) ([]CreateTickerMentionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, createTickerMentions,
+		pq.Array(arg.TickerIds),
+		pq.Array(arg.UserIds),
+		pq.Array(arg.CommentIds),
+		pq.Array(arg.MentionedAts),
+		pq.Array(arg.Subreddits),
+		pq.Array(arg.Confidences),
+		pq.Array(arg.DetectedBys),
+		pq.Array(arg.Stances),
+		pq.Array(arg.Instruments),
+		pq.Array(arg.EntryRules),
+		pq.Array(arg.EntryAts),
+		pq.Array(arg.EntryPrices),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CreateTickerMentionsRow
+	for rows.Next() {
+		var i CreateTickerMentionsRow
+		if err := rows.Scan(&i.ID, &i.CommentID, &i.TickerID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getAllMentionsComplete = `-- name: GetAllMentionsComplete :many
 SELECT
   tn.symbol,
   u.username,
-  COALESCE(mention_price.price::text, '0') AS mention_price,
+  COALESCE(tm.entry_price::text, mention_price.price::text, '0') AS mention_price,
   COALESCE(current_price.price::text, '0') AS current_price,
   COALESCE(current_price.recorded_at, now()) AS current_price_date,
+  (current_price.price IS NOT NULL)::boolean AS has_current_price,
   tm.mentioned_at,
   COALESCE((
     SELECT EXP(SUM(LN(ts.ratio::double precision)))
     FROM ticker_splits ts
     WHERE ts.ticker_id = tm.ticker_id
-      AND ts.effective_date >= tm.mentioned_at
+      AND ts.effective_date >= COALESCE(tm.entry_at, tm.mentioned_at)
       AND ts.effective_date <= COALESCE(current_price.recorded_at, now())
-  ), 1.0)::double precision AS split_ratio
+  ), 1.0)::double precision AS split_ratio,
+  COALESCE(benchmark_start.price::text, '0') AS benchmark_start_price,
+  COALESCE(benchmark_end.price::text, '0') AS benchmark_end_price,
+  tm.subreddit,
+  tm.stance
 FROM ticker_mentions tm
 JOIN users u ON u.id = tm.user_id
 JOIN ticker_names tn ON tn.id = tm.ticker_id
 LEFT JOIN LATERAL (
   SELECT price, recorded_at
-  FROM ticker_prices
-  WHERE ticker_id = tm.ticker_id AND recorded_at <= tm.mentioned_at
+  FROM ticker_price_points
+  WHERE tm.entry_at IS NULL
+    AND ticker_id = tm.ticker_id
+    AND recorded_at <= tm.mentioned_at
   ORDER BY recorded_at DESC
   LIMIT 1
 ) mention_price ON true
 LEFT JOIN LATERAL (
   SELECT price, recorded_at
-  FROM ticker_prices
+  FROM ticker_price_points
   WHERE ticker_id = tm.ticker_id
-  ORDER BY recorded_at DESC
+    AND ($1::int = 0 OR recorded_at BETWEEN COALESCE(tm.entry_at, tm.mentioned_at) + make_interval(days => $1::int)
+      AND COALESCE(tm.entry_at, tm.mentioned_at) + make_interval(days => $1::int) + interval '7 days')
+  ORDER BY
+    CASE WHEN $1::int > 0 THEN recorded_at END ASC,
+    recorded_at DESC
   LIMIT 1
 ) current_price ON true
-WHERE tm.mentioned_at >= $1
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE ticker_id = (SELECT id FROM ticker_names WHERE symbol = $2)
+    AND recorded_at <= COALESCE(tm.entry_at, tm.mentioned_at)
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) benchmark_start ON true
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE ticker_id = (SELECT id FROM ticker_names WHERE symbol = $2)
+    AND recorded_at <= COALESCE(current_price.recorded_at, now())
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) benchmark_end ON true
+WHERE tm.mentioned_at >= $3
+  AND ($4::text = '' OR tm.subreddit = $4::text)
+  AND tm.instrument = 'stock'
 ORDER BY tm.mentioned_at ASC
 `
 
+type GetAllMentionsCompleteParams struct {
+	HorizonDays int32     `json:"horizon_days"`
+	Benchmark   string    `json:"benchmark"`
+	MentionedAt time.Time `json:"mentioned_at"`
+	Subreddit   string    `json:"subreddit"`
+}
+
 type GetAllMentionsCompleteRow struct {
-	Symbol           string      `json:"symbol"`
-	Username         string      `json:"username"`
-	MentionPrice     interface{} `json:"mention_price"`
-	CurrentPrice     interface{} `json:"current_price"`
-	CurrentPriceDate time.Time   `json:"current_price_date"`
-	MentionedAt      time.Time   `json:"mentioned_at"`
-	SplitRatio       float64     `json:"split_ratio"`
+	Symbol              string      `json:"symbol"`
+	Username            string      `json:"username"`
+	MentionPrice        interface{} `json:"mention_price"`
+	CurrentPrice        interface{} `json:"current_price"`
+	CurrentPriceDate    time.Time   `json:"current_price_date"`
+	HasCurrentPrice     bool        `json:"has_current_price"`
+	MentionedAt         time.Time   `json:"mentioned_at"`
+	SplitRatio          float64     `json:"split_ratio"`
+	BenchmarkStartPrice interface{} `json:"benchmark_start_price"`
+	BenchmarkEndPrice   interface{} `json:"benchmark_end_price"`
+	Subreddit           string      `json:"subreddit"`
+	Stance              string      `json:"stance"`
 }
 
-func (q *Queries) GetAllMentionsComplete(ctx context.Context, mentionedAt time.Time) ([]GetAllMentionsCompleteRow, error) {
-	rows, err := q.db.QueryContext(ctx, getAllMentionsComplete, mentionedAt)
+func (q *Queries) GetAllMentionsComplete(ctx context.Context, arg GetAllMentionsCompleteParams) ([]GetAllMentionsCompleteRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAllMentionsComplete,
+		arg.HorizonDays,
+		arg.Benchmark,
+		arg.MentionedAt,
+		arg.Subreddit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -107,8 +284,258 @@ func (q *Queries) GetAllMentionsComplete(ctx context.Context, mentionedAt time.T
 			&i.MentionPrice,
 			&i.CurrentPrice,
 			&i.CurrentPriceDate,
+			&i.HasCurrentPrice,
 			&i.MentionedAt,
 			&i.SplitRatio,
+			&i.BenchmarkStartPrice,
+			&i.BenchmarkEndPrice,
+			&i.Subreddit,
+			&i.Stance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDailyMentionCounts = `-- name: GetDailyMentionCounts :many
+SELECT
+  tm.mentioned_at::date AS day,
+  COUNT(*) AS mention_count,
+  COUNT(DISTINCT tm.user_id) AS unique_users
+FROM ticker_mentions tm
+JOIN users u ON u.id = tm.user_id
+WHERE tm.ticker_id = $1
+  AND tm.instrument = 'stock'
+  AND u.username <> ALL($2::text[])
+GROUP BY tm.mentioned_at::date
+ORDER BY day ASC
+`
+
+type GetDailyMentionCountsParams struct {
+	TickerID          int64    `json:"ticker_id"`
+	ExcludedUsernames []string `json:"excluded_usernames"`
+}
+
+type GetDailyMentionCountsRow struct {
+	Day          time.Time `json:"day"`
+	MentionCount int64     `json:"mention_count"`
+	UniqueUsers  int64     `json:"unique_users"`
+}
+
+func (q *Queries) GetDailyMentionCounts(ctx context.Context, arg GetDailyMentionCountsParams) ([]GetDailyMentionCountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDailyMentionCounts, arg.TickerID, pq.Array(arg.ExcludedUsernames))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDailyMentionCountsRow
+	for rows.Next() {
+		var i GetDailyMentionCountsRow
+		if err := rows.Scan(&i.Day, &i.MentionCount, &i.UniqueUsers); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTickerMentioners = `-- name: GetTickerMentioners :many
+SELECT
+  u.username,
+  COALESCE(tm.entry_price::text, mention_price.price::text, '0') AS mention_price,
+  tm.mentioned_at,
+  COALESCE((
+    SELECT EXP(SUM(LN(ts.ratio::double precision)))
+    FROM ticker_splits ts
+    WHERE ts.ticker_id = tm.ticker_id
+      AND ts.effective_date >= COALESCE(tm.entry_at, tm.mentioned_at)
+  ), 1.0)::double precision AS split_ratio
+FROM (
+  SELECT DISTINCT ON (user_id) user_id, ticker_id, mentioned_at, entry_at, entry_price
+  FROM ticker_mentions
+  WHERE ticker_id = $1
+    AND instrument = 'stock'
+  ORDER BY user_id, mentioned_at ASC
+) tm
+JOIN users u ON u.id = tm.user_id
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE tm.entry_at IS NULL
+    AND ticker_id = tm.ticker_id
+    AND recorded_at <= tm.mentioned_at
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) mention_price ON true
+WHERE u.username <> ALL($2::text[])
+ORDER BY tm.mentioned_at ASC
+`
+
+type GetTickerMentionersParams struct {
+	TickerID          int64    `json:"ticker_id"`
+	ExcludedUsernames []string `json:"excluded_usernames"`
+}
+
+type GetTickerMentionersRow struct {
+	Username     string      `json:"username"`
+	MentionPrice interface{} `json:"mention_price"`
+	MentionedAt  time.Time   `json:"mentioned_at"`
+	SplitRatio   float64     `json:"split_ratio"`
+}
+
+func (q *Queries) GetTickerMentioners(ctx context.Context, arg GetTickerMentionersParams) ([]GetTickerMentionersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTickerMentioners, arg.TickerID, pq.Array(arg.ExcludedUsernames))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTickerMentionersRow
+	for rows.Next() {
+		var i GetTickerMentionersRow
+		if err := rows.Scan(
+			&i.Username,
+			&i.MentionPrice,
+			&i.MentionedAt,
+			&i.SplitRatio,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTrendingTickers = `-- name: GetTrendingTickers :many
+WITH recent AS (
+  SELECT
+    tm.ticker_id,
+    COUNT(*) AS mention_count,
+    COUNT(DISTINCT tm.user_id) AS unique_users
+  FROM ticker_mentions tm
+  JOIN users u ON u.id = tm.user_id
+  WHERE tm.mentioned_at >= $1
+    AND u.username <> ALL($2::text[])
+    AND tm.instrument = 'stock'
+  GROUP BY tm.ticker_id
+),
+baseline_buckets AS (
+  SELECT
+    tm.ticker_id,
+    FLOOR(EXTRACT(EPOCH FROM ($1 - tm.mentioned_at)) / $3::double precision) AS bucket,
+    COUNT(DISTINCT tm.user_id) AS unique_users
+  FROM ticker_mentions tm
+  JOIN users u ON u.id = tm.user_id
+  WHERE tm.mentioned_at >= $4
+    AND tm.mentioned_at < $1
+    AND u.username <> ALL($2::text[])
+    AND tm.instrument = 'stock'
+  GROUP BY tm.ticker_id, bucket
+),
+baseline AS (
+  SELECT
+    ticker_id,
+    SUM(unique_users)::double precision AS user_sum,
+    SUM(unique_users * unique_users)::double precision AS user_sum_squares
+  FROM baseline_buckets
+  GROUP BY ticker_id
+)
+SELECT
+  tn.symbol,
+  r.mention_count,
+  r.unique_users,
+  COALESCE(b.user_sum, 0)::double precision AS baseline_user_sum,
+  COALESCE(b.user_sum_squares, 0)::double precision AS baseline_user_sum_squares,
+  COALESCE(current_price.price::text, '0') AS current_price,
+  COALESCE(window_start_price.price::text, '0') AS window_start_price,
+  COALESCE((
+    SELECT EXP(SUM(LN(ts.ratio::double precision)))
+    FROM ticker_splits ts
+    WHERE ts.ticker_id = r.ticker_id
+      AND ts.effective_date >= $1
+  ), 1.0)::double precision AS split_ratio
+FROM recent r
+JOIN ticker_names tn ON tn.id = r.ticker_id
+LEFT JOIN baseline b ON b.ticker_id = r.ticker_id
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE ticker_id = r.ticker_id
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) current_price ON true
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE ticker_id = r.ticker_id AND recorded_at <= $1
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) window_start_price ON true
+WHERE r.unique_users >= $5::int
+`
+
+type GetTrendingTickersParams struct {
+	WindowStart       time.Time `json:"window_start"`
+	ExcludedUsernames []string  `json:"excluded_usernames"`
+	WindowSeconds     float64   `json:"window_seconds"`
+	BaselineStart     time.Time `json:"baseline_start"`
+	MinUniqueUsers    int32     `json:"min_unique_users"`
+}
+
+type GetTrendingTickersRow struct {
+	Symbol                 string      `json:"symbol"`
+	MentionCount           int64       `json:"mention_count"`
+	UniqueUsers            int64       `json:"unique_users"`
+	BaselineUserSum        float64     `json:"baseline_user_sum"`
+	BaselineUserSumSquares float64     `json:"baseline_user_sum_squares"`
+	CurrentPrice           interface{} `json:"current_price"`
+	WindowStartPrice       interface{} `json:"window_start_price"`
+	SplitRatio             float64     `json:"split_ratio"`
+}
+
+func (q *Queries) GetTrendingTickers(ctx context.Context, arg GetTrendingTickersParams) ([]GetTrendingTickersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTrendingTickers,
+		arg.WindowStart,
+		pq.Array(arg.ExcludedUsernames),
+		arg.WindowSeconds,
+		arg.BaselineStart,
+		arg.MinUniqueUsers,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTrendingTickersRow
+	for rows.Next() {
+		var i GetTrendingTickersRow
+		if err := rows.Scan(
+			&i.Symbol,
+			&i.MentionCount,
+			&i.UniqueUsers,
+			&i.BaselineUserSum,
+			&i.BaselineUserSumSquares,
+			&i.CurrentPrice,
+			&i.WindowStartPrice,
+			&i.SplitRatio,
 		); err != nil {
 			return nil, err
 		}
@@ -126,58 +553,102 @@ func (q *Queries) GetAllMentionsComplete(ctx context.Context, mentionedAt time.T
 const getUserMentionsComplete = `-- name: GetUserMentionsComplete :many
 SELECT
   tn.symbol,
-  COALESCE(mention_price.price::text, '0') AS mention_price,
+  COALESCE(tm.entry_price::text, mention_price.price::text, '0') AS mention_price,
   COALESCE(current_price.price::text, '0') AS current_price,
   COALESCE(current_price.recorded_at, now()) AS current_price_date,
+  (current_price.price IS NOT NULL)::boolean AS has_current_price,
   tm.mentioned_at,
   COALESCE((
     SELECT EXP(SUM(LN(ts.ratio::double precision)))
     FROM ticker_splits ts
     WHERE ts.ticker_id = tm.ticker_id
-      AND ts.effective_date >= tm.mentioned_at
+      AND ts.effective_date >= COALESCE(tm.entry_at, tm.mentioned_at)
       AND ts.effective_date <= COALESCE(current_price.recorded_at, now())
-  ), 1.0)::double precision AS split_ratio
+  ), 1.0)::double precision AS split_ratio,
+  COALESCE(benchmark_start.price::text, '0') AS benchmark_start_price,
+  COALESCE(benchmark_end.price::text, '0') AS benchmark_end_price,
+  c.permalink,
+  tm.stance
 FROM (
-  SELECT DISTINCT ON (ticker_id) ticker_id, mentioned_at
+  SELECT DISTINCT ON (ticker_id) ticker_id, comment_id, mentioned_at, entry_at, entry_price, stance
   FROM ticker_mentions
   WHERE user_id = (SELECT id FROM users WHERE username = $1)
     AND mentioned_at >= $2
+    AND ($3::text = '' OR subreddit = $3::text)
+    AND instrument = 'stock'
   ORDER BY ticker_id, mentioned_at ASC
 ) tm
 JOIN ticker_names tn ON tn.id = tm.ticker_id
+JOIN comments c ON c.id = tm.comment_id
 LEFT JOIN LATERAL (
   SELECT price, recorded_at
-  FROM ticker_prices
-  WHERE ticker_id = tm.ticker_id AND recorded_at <= tm.mentioned_at
+  FROM ticker_price_points
+  WHERE tm.entry_at IS NULL
+    AND ticker_id = tm.ticker_id
+    AND recorded_at <= tm.mentioned_at
   ORDER BY recorded_at DESC
   LIMIT 1
 ) mention_price ON true
 LEFT JOIN LATERAL (
   SELECT price, recorded_at
-  FROM ticker_prices
+  FROM ticker_price_points
   WHERE ticker_id = tm.ticker_id
-  ORDER BY recorded_at DESC
+    AND ($4::int = 0 OR recorded_at BETWEEN COALESCE(tm.entry_at, tm.mentioned_at) + make_interval(days => $4::int)
+      AND COALESCE(tm.entry_at, tm.mentioned_at) + make_interval(days => $4::int) + interval '7 days')
+  ORDER BY
+    CASE WHEN $4::int > 0 THEN recorded_at END ASC,
+    recorded_at DESC
   LIMIT 1
 ) current_price ON true
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE ticker_id = (SELECT id FROM ticker_names WHERE symbol = $5)
+    AND recorded_at <= COALESCE(tm.entry_at, tm.mentioned_at)
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) benchmark_start ON true
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE ticker_id = (SELECT id FROM ticker_names WHERE symbol = $5)
+    AND recorded_at <= COALESCE(current_price.recorded_at, now())
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) benchmark_end ON true
 ORDER BY tn.symbol
 `
 
 type GetUserMentionsCompleteParams struct {
 	Username    string    `json:"username"`
 	MentionedAt time.Time `json:"mentioned_at"`
+	Subreddit   string    `json:"subreddit"`
+	HorizonDays int32     `json:"horizon_days"`
+	Benchmark   string    `json:"benchmark"`
 }
 
 type GetUserMentionsCompleteRow struct {
-	Symbol           string      `json:"symbol"`
-	MentionPrice     interface{} `json:"mention_price"`
-	CurrentPrice     interface{} `json:"current_price"`
-	CurrentPriceDate time.Time   `json:"current_price_date"`
-	MentionedAt      time.Time   `json:"mentioned_at"`
-	SplitRatio       float64     `json:"split_ratio"`
+	Symbol              string      `json:"symbol"`
+	MentionPrice        interface{} `json:"mention_price"`
+	CurrentPrice        interface{} `json:"current_price"`
+	CurrentPriceDate    time.Time   `json:"current_price_date"`
+	HasCurrentPrice     bool        `json:"has_current_price"`
+	MentionedAt         time.Time   `json:"mentioned_at"`
+	SplitRatio          float64     `json:"split_ratio"`
+	BenchmarkStartPrice interface{} `json:"benchmark_start_price"`
+	BenchmarkEndPrice   interface{} `json:"benchmark_end_price"`
+	Permalink           string      `json:"permalink"`
+	Stance              string      `json:"stance"`
 }
 
 func (q *Queries) GetUserMentionsComplete(ctx context.Context, arg GetUserMentionsCompleteParams) ([]GetUserMentionsCompleteRow, error) {
-	rows, err := q.db.QueryContext(ctx, getUserMentionsComplete, arg.Username, arg.MentionedAt)
+	rows, err := q.db.QueryContext(ctx, getUserMentionsComplete,
+		arg.Username,
+		arg.MentionedAt,
+		arg.Subreddit,
+		arg.HorizonDays,
+		arg.Benchmark,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -190,8 +661,149 @@ func (q *Queries) GetUserMentionsComplete(ctx context.Context, arg GetUserMentio
 			&i.MentionPrice,
 			&i.CurrentPrice,
 			&i.CurrentPriceDate,
+			&i.HasCurrentPrice,
+			&i.MentionedAt,
+			&i.SplitRatio,
+			&i.BenchmarkStartPrice,
+			&i.BenchmarkEndPrice,
+			&i.Permalink,
+			&i.Stance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUsersMentionsComplete = `-- name: GetUsersMentionsComplete :many
+SELECT
+  tm.username,
+  tn.symbol,
+  COALESCE(tm.entry_price::text, mention_price.price::text, '0') AS mention_price,
+  COALESCE(current_price.price::text, '0') AS current_price,
+  COALESCE(current_price.recorded_at, now()) AS current_price_date,
+  (current_price.price IS NOT NULL)::boolean AS has_current_price,
+  tm.mentioned_at,
+  COALESCE((
+    SELECT EXP(SUM(LN(ts.ratio::double precision)))
+    FROM ticker_splits ts
+    WHERE ts.ticker_id = tm.ticker_id
+      AND ts.effective_date >= COALESCE(tm.entry_at, tm.mentioned_at)
+      AND ts.effective_date <= COALESCE(current_price.recorded_at, now())
+  ), 1.0)::double precision AS split_ratio,
+  COALESCE(benchmark_start.price::text, '0') AS benchmark_start_price,
+  COALESCE(benchmark_end.price::text, '0') AS benchmark_end_price,
+  c.permalink,
+  tm.stance
+FROM (
+  SELECT DISTINCT ON (m.user_id, m.ticker_id) u.username, m.ticker_id, m.comment_id, m.mentioned_at, m.entry_at, m.entry_price, m.stance
+  FROM ticker_mentions m
+  JOIN users u ON u.id = m.user_id
+  WHERE u.username = ANY($1::text[])
+    AND m.mentioned_at >= $2
+    AND ($3::text = '' OR m.subreddit = $3::text)
+    AND m.instrument = 'stock'
+  ORDER BY m.user_id, m.ticker_id, m.mentioned_at ASC
+) tm
+JOIN ticker_names tn ON tn.id = tm.ticker_id
+JOIN comments c ON c.id = tm.comment_id
+LEFT JOIN LATERAL (
+  SELECT price, recorded_at
+  FROM ticker_price_points
+  WHERE tm.entry_at IS NULL
+    AND ticker_id = tm.ticker_id
+    AND recorded_at <= tm.mentioned_at
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) mention_price ON true
+LEFT JOIN LATERAL (
+  SELECT price, recorded_at
+  FROM ticker_price_points
+  WHERE ticker_id = tm.ticker_id
+    AND ($4::int = 0 OR recorded_at BETWEEN COALESCE(tm.entry_at, tm.mentioned_at) + make_interval(days => $4::int)
+      AND COALESCE(tm.entry_at, tm.mentioned_at) + make_interval(days => $4::int) + interval '7 days')
+  ORDER BY
+    CASE WHEN $4::int > 0 THEN recorded_at END ASC,
+    recorded_at DESC
+  LIMIT 1
+) current_price ON true
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE ticker_id = (SELECT id FROM ticker_names WHERE symbol = $5)
+    AND recorded_at <= COALESCE(tm.entry_at, tm.mentioned_at)
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) benchmark_start ON true
+LEFT JOIN LATERAL (
+  SELECT price
+  FROM ticker_price_points
+  WHERE ticker_id = (SELECT id FROM ticker_names WHERE symbol = $5)
+    AND recorded_at <= COALESCE(current_price.recorded_at, now())
+  ORDER BY recorded_at DESC
+  LIMIT 1
+) benchmark_end ON true
+ORDER BY tm.username, tn.symbol
+`
+
+type GetUsersMentionsCompleteParams struct {
+	Usernames   []string  `json:"usernames"`
+	MentionedAt time.Time `json:"mentioned_at"`
+	Subreddit   string    `json:"subreddit"`
+	HorizonDays int32     `json:"horizon_days"`
+	Benchmark   string    `json:"benchmark"`
+}
+
+type GetUsersMentionsCompleteRow struct {
+	Username            string      `json:"username"`
+	Symbol              string      `json:"symbol"`
+	MentionPrice        interface{} `json:"mention_price"`
+	CurrentPrice        interface{} `json:"current_price"`
+	CurrentPriceDate    time.Time   `json:"current_price_date"`
+	HasCurrentPrice     bool        `json:"has_current_price"`
+	MentionedAt         time.Time   `json:"mentioned_at"`
+	SplitRatio          float64     `json:"split_ratio"`
+	BenchmarkStartPrice interface{} `json:"benchmark_start_price"`
+	BenchmarkEndPrice   interface{} `json:"benchmark_end_price"`
+	Permalink           string      `json:"permalink"`
+	Stance              string      `json:"stance"`
+}
+
+func (q *Queries) GetUsersMentionsComplete(ctx context.Context, arg GetUsersMentionsCompleteParams) ([]GetUsersMentionsCompleteRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersMentionsComplete,
+		pq.Array(arg.Usernames),
+		arg.MentionedAt,
+		arg.Subreddit,
+		arg.HorizonDays,
+		arg.Benchmark,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUsersMentionsCompleteRow
+	for rows.Next() {
+		var i GetUsersMentionsCompleteRow
+		if err := rows.Scan(
+			&i.Username,
+			&i.Symbol,
+			&i.MentionPrice,
+			&i.CurrentPrice,
+			&i.CurrentPriceDate,
+			&i.HasCurrentPrice,
 			&i.MentionedAt,
 			&i.SplitRatio,
+			&i.BenchmarkStartPrice,
+			&i.BenchmarkEndPrice,
+			&i.Permalink,
+			&i.Stance,
 		); err != nil {
 			return nil, err
 		}
@@ -205,3 +817,86 @@ func (q *Queries) GetUserMentionsComplete(ctx context.Context, arg GetUserMentio
 	}
 	return items, nil
 }
+
+const listMentionEntriesDue = `-- name: ListMentionEntriesDue :many
+SELECT tm.id, tm.ticker_id, tn.symbol, tm.mentioned_at, tm.entry_rule, tm.entry_at
+FROM ticker_mentions tm
+JOIN ticker_names tn ON tn.id = tm.ticker_id
+WHERE tm.entry_rule <> $1::text
+   OR (tm.entry_price IS NULL AND tm.entry_at <= now() AND tm.entry_at >= $2)
+ORDER BY tm.mentioned_at DESC
+LIMIT $3
+`
+
+type ListMentionEntriesDueParams struct {
+	EntryRule  string    `json:"entry_rule"`
+	RetrySince time.Time `json:"retry_since"`
+	RowLimit   int32     `json:"row_limit"`
+}
+
+type ListMentionEntriesDueRow struct {
+	ID          int64        `json:"id"`
+	TickerID    int64        `json:"ticker_id"`
+	Symbol      string       `json:"symbol"`
+	MentionedAt time.Time    `json:"mentioned_at"`
+	EntryRule   string       `json:"entry_rule"`
+	EntryAt     sql.NullTime `json:"entry_at"`
+}
+
+// Mentions without an entry under the current rule, plus recent entries whose
+// price was not known yet. Newest first, so fresh mentions are priced before
+// a backfill of old ones.
+func (q *Queries) ListMentionEntriesDue(ctx context.Context, arg ListMentionEntriesDueParams) ([]ListMentionEntriesDueRow, error) {
+	rows, err := q.db.QueryContext(ctx, listMentionEntriesDue, arg.EntryRule, arg.RetrySince, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMentionEntriesDueRow
+	for rows.Next() {
+		var i ListMentionEntriesDueRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TickerID,
+			&i.Symbol,
+			&i.MentionedAt,
+			&i.EntryRule,
+			&i.EntryAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setMentionEntry = `-- name: SetMentionEntry :exec
+UPDATE ticker_mentions
+SET entry_rule = $2,
+    entry_at = $3,
+    entry_price = $4
+WHERE id = $1
+`
+
+type SetMentionEntryParams struct {
+	ID         int64          `json:"id"`
+	EntryRule  string         `json:"entry_rule"`
+	EntryAt    sql.NullTime   `json:"entry_at"`
+	EntryPrice sql.NullString `json:"entry_price"`
+}
+
+func (q *Queries) SetMentionEntry(ctx context.Context, arg SetMentionEntryParams) error {
+	_, err := q.db.ExecContext(ctx, setMentionEntry,
+		arg.ID,
+		arg.EntryRule,
+		arg.EntryAt,
+		arg.EntryPrice,
+	)
+	return err
+}
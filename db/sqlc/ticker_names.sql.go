@@ -7,12 +7,13 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createTicker = `-- name: CreateTicker :one
 INSERT INTO ticker_names (symbol, company_name, exchange)
 VALUES ($1, $2, $3)
-RETURNING id, symbol, company_name, exchange, currency, created_at
+RETURNING id, symbol, company_name, exchange, currency, created_at, price_refreshed_at
 `
 
 type CreateTickerParams struct {
@@ -31,12 +32,13 @@ func (q *Queries) CreateTicker(ctx context.Context, arg CreateTickerParams) (Tic
 		&i.Exchange,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.PriceRefreshedAt,
 	)
 	return i, err
 }
 
 const getTickerBySymbol = `-- name: GetTickerBySymbol :one
-SELECT id, symbol, company_name, exchange, currency, created_at
+SELECT id, symbol, company_name, exchange, currency, created_at, price_refreshed_at
 FROM ticker_names
 WHERE symbol = $1
 `
@@ -51,12 +53,13 @@ func (q *Queries) GetTickerBySymbol(ctx context.Context, symbol string) (TickerN
 		&i.Exchange,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.PriceRefreshedAt,
 	)
 	return i, err
 }
 
 const listAllTickers = `-- name: ListAllTickers :many
-SELECT id, symbol, company_name, exchange, currency, created_at FROM ticker_names ORDER BY symbol
+SELECT id, symbol, company_name, exchange, currency, created_at, price_refreshed_at FROM ticker_names ORDER BY symbol
 `
 
 func (q *Queries) ListAllTickers(ctx context.Context) ([]TickerName, error) {
@@ -75,6 +78,7 @@ func (q *Queries) ListAllTickers(ctx context.Context) ([]TickerName, error) {
 			&i.Exchange,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.PriceRefreshedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -89,6 +93,70 @@ func (q *Queries) ListAllTickers(ctx context.Context) ([]TickerName, error) {
 	return items, nil
 }
 
+const listTickersByMentionPriority = `-- name: ListTickersByMentionPriority :many
+SELECT t.id, t.symbol, t.exchange, t.price_refreshed_at,
+       COUNT(m.id)::bigint AS mention_count,
+       MAX(m.mentioned_at)::timestamp AS last_mentioned_at
+FROM ticker_names t
+LEFT JOIN ticker_mentions m ON m.ticker_id = t.id
+GROUP BY t.id
+ORDER BY (t.exchange = 'BENCHMARK') DESC,
+         MAX(m.mentioned_at) DESC NULLS LAST,
+         t.symbol
+`
+
+type ListTickersByMentionPriorityRow struct {
+	ID               int64        `json:"id"`
+	Symbol           string       `json:"symbol"`
+	Exchange         string       `json:"exchange"`
+	PriceRefreshedAt sql.NullTime `json:"price_refreshed_at"`
+	MentionCount     int64        `json:"mention_count"`
+	LastMentionedAt  sql.NullTime `json:"last_mentioned_at"`
+}
+
+// Benchmarks first, then mentioned tickers (most recently mentioned first),
+// then everything else.
+func (q *Queries) ListTickersByMentionPriority(ctx context.Context) ([]ListTickersByMentionPriorityRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTickersByMentionPriority)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTickersByMentionPriorityRow
+	for rows.Next() {
+		var i ListTickersByMentionPriorityRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Symbol,
+			&i.Exchange,
+			&i.PriceRefreshedAt,
+			&i.MentionCount,
+			&i.LastMentionedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markTickerPriceRefreshed = `-- name: MarkTickerPriceRefreshed :exec
+UPDATE ticker_names
+SET price_refreshed_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) MarkTickerPriceRefreshed(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, markTickerPriceRefreshed, id)
+	return err
+}
+
 const upsertTicker = `-- name: UpsertTicker :exec
 INSERT INTO ticker_names (symbol, company_name, exchange)
 VALUES ($1, $2, $3)
@@ -25,9 +25,69 @@ func (q *Queries) DeleteTickerPriceByDate(ctx context.Context, arg DeleteTickerP
 	return err
 }
 
+const getDailyTickerPrices = `-- name: GetDailyTickerPrices :many
+SELECT DISTINCT ON (p.day)
+  p.day,
+  p.open,
+  p.high,
+  p.low,
+  p.price,
+  p.volume
+FROM (
+  SELECT day, open, high, low, close AS price, volume, closed_at AS recorded_at, 0 AS rank
+  FROM ticker_daily_bars
+  WHERE ticker_id = $1
+  UNION ALL
+  SELECT recorded_at::date, price, price, price, price, volume, recorded_at, 1
+  FROM ticker_prices
+  WHERE ticker_id = $1
+) p
+ORDER BY p.day ASC, p.rank ASC, p.recorded_at DESC
+`
+
+type GetDailyTickerPricesRow struct {
+	Day    time.Time `json:"day"`
+	Open   string    `json:"open"`
+	High   string    `json:"high"`
+	Low    string    `json:"low"`
+	Price  string    `json:"price"`
+	Volume int64     `json:"volume"`
+}
+
+// Daily bars, plus the latest snapshot of days without a bar (e.g. today).
+func (q *Queries) GetDailyTickerPrices(ctx context.Context, tickerID int64) ([]GetDailyTickerPricesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDailyTickerPrices, tickerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDailyTickerPricesRow
+	for rows.Next() {
+		var i GetDailyTickerPricesRow
+		if err := rows.Scan(
+			&i.Day,
+			&i.Open,
+			&i.High,
+			&i.Low,
+			&i.Price,
+			&i.Volume,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getTickerPriceBeforeDate = `-- name: GetTickerPriceBeforeDate :one
-SELECT id, ticker_id, price, recorded_at, volume
-FROM ticker_prices
+SELECT price, recorded_at
+FROM ticker_price_points
 WHERE ticker_id = $1 AND recorded_at <= $2
 ORDER BY recorded_at DESC
 LIMIT 1
@@ -38,16 +98,15 @@ type GetTickerPriceBeforeDateParams struct {
 	RecordedAt time.Time `json:"recorded_at"`
 }
 
-func (q *Queries) GetTickerPriceBeforeDate(ctx context.Context, arg GetTickerPriceBeforeDateParams) (TickerPrice, error) {
+type GetTickerPriceBeforeDateRow struct {
+	Price      string    `json:"price"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+func (q *Queries) GetTickerPriceBeforeDate(ctx context.Context, arg GetTickerPriceBeforeDateParams) (GetTickerPriceBeforeDateRow, error) {
 	row := q.db.QueryRowContext(ctx, getTickerPriceBeforeDate, arg.TickerID, arg.RecordedAt)
-	var i TickerPrice
-	err := row.Scan(
-		&i.ID,
-		&i.TickerID,
-		&i.Price,
-		&i.RecordedAt,
-		&i.Volume,
-	)
+	var i GetTickerPriceBeforeDateRow
+	err := row.Scan(&i.Price, &i.RecordedAt)
 	return i, err
 }
 
@@ -82,3 +141,46 @@ func (q *Queries) InsertTickerPrice(ctx context.Context, arg InsertTickerPricePa
 	)
 	return i, err
 }
+
+const listTickerPricesBetween = `-- name: ListTickerPricesBetween :many
+SELECT price, recorded_at
+FROM ticker_prices
+WHERE ticker_id = $1
+  AND recorded_at > $2
+  AND recorded_at <= $3
+ORDER BY recorded_at ASC
+`
+
+type ListTickerPricesBetweenParams struct {
+	TickerID int64     `json:"ticker_id"`
+	FromTime time.Time `json:"from_time"`
+	ToTime   time.Time `json:"to_time"`
+}
+
+type ListTickerPricesBetweenRow struct {
+	Price      string    `json:"price"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+func (q *Queries) ListTickerPricesBetween(ctx context.Context, arg ListTickerPricesBetweenParams) ([]ListTickerPricesBetweenRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTickerPricesBetween, arg.TickerID, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTickerPricesBetweenRow
+	for rows.Next() {
+		var i ListTickerPricesBetweenRow
+		if err := rows.Scan(&i.Price, &i.RecordedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -7,6 +7,8 @@ package db
 
 import (
 	"context"
+
+	"github.com/lib/pq"
 )
 
 const createUser = `-- name: CreateUser :one
@@ -22,6 +24,48 @@ func (q *Queries) CreateUser(ctx context.Context, username string) (User, error)
 	return i, err
 }
 
+const createUsers = `-- name: CreateUsers :many
+WITH created AS (
+  INSERT INTO users (username)
+  SELECT DISTINCT unnest($1::text[])
+  ON CONFLICT (username) DO NOTHING
+  RETURNING id, username
+)
+SELECT id, username FROM created
+UNION ALL
+SELECT id, username FROM users WHERE username = ANY($1::text[])
+`
+
+type CreateUsersRow struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+// Creates the users of a batch that do not exist yet and returns the ids of
+// all of them.
+func (q *Queries) CreateUsers(ctx context.Context, usernames []string) ([]CreateUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, createUsers, pq.Array(usernames))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CreateUsersRow
+	for rows.Next() {
+		var i CreateUsersRow
+		if err := rows.Scan(&i.ID, &i.Username); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserByUsername = `-- name: GetUserByUsername :one
 SELECT id, username, created_at
 FROM users
@@ -1,6 +1,14 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"flag"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
 	"github.com/stuneak/sopeko/api"
 	"github.com/stuneak/sopeko/config"
 	"github.com/stuneak/sopeko/cron"
@@ -25,11 +33,16 @@ func main() {
 	store := db.New(conn)
 
 	// Initialize and start cron scheduler
-	scheduler, err := cron.NewScheduler(store)
+	scheduler, err := cron.NewScheduler(store, config)
 	if err != nil {
 		fatal("cannot create scheduler: %v", err)
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "import-archive" {
+		importArchive(scheduler, conn, os.Args[2:])
+		return
+	}
+
 	err = scheduler.RegisterJobs()
 	if err != nil {
 		fatal("cannot register cron jobs: %v", err)
@@ -38,10 +51,35 @@ func main() {
 	scheduler.Start()
 	defer scheduler.Stop()
 
-	server := api.NewServer(store, config.GINMode)
+	server := api.NewServer(store, scheduler, config.GINMode, config.BenchmarkSymbols, config.AdminToken)
 
 	err = server.Start(config.ServerAddress)
 	if err != nil {
 		fatal("cannot start server: %v", err)
 	}
 }
+
+// importArchive runs `sopeko import-archive --file RC_2024-01.zst [--subreddit pennystocks ...]`.
+// Interrupting it keeps the progress of the last committed batch.
+func importArchive(scheduler *cron.Scheduler, conn *sql.DB, args []string) {
+	var opts cron.ArchiveImportOptions
+	flags := flag.NewFlagSet("import-archive", flag.ExitOnError)
+	flags.StringVar(&opts.File, "file", "", "Reddit dump to import (.zst or plain NDJSON)")
+	flags.Func("subreddit", "subreddit to import, repeatable or comma-separated (default: enabled subreddits)", func(value string) error {
+		opts.Subreddits = append(opts.Subreddits, strings.Split(value, ",")...)
+		return nil
+	})
+	flags.Parse(args)
+
+	if opts.File == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := scheduler.ImportArchive(ctx, conn, opts); err != nil {
+		fatal("archive import failed: %v", err)
+	}
+}
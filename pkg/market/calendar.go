@@ -0,0 +1,194 @@
+// Package market is the NYSE trading calendar: which days the exchange is
+// open and when its sessions start and end, in New York time.
+package market
+
+import (
+	"fmt"
+	"time"
+
+	// Every lookup is in New York time, so the zone is embedded for hosts
+	// without tzdata
+	_ "time/tzdata"
+)
+
+var nyLoc = func() *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		panic(fmt.Errorf("loading New York time zone: %w", err))
+	}
+	return loc
+}()
+
+// Regular sessions run from 09:30 to 16:00; early closes end at 13:00.
+const (
+	openHour, openMinute = 9, 30
+	closeHour            = 16
+	earlyCloseHour       = 13
+)
+
+// Closures outside the regular holiday rules, e.g. national days of mourning.
+var specialClosures = map[string]bool{
+	"2001-09-11": true,
+	"2001-09-12": true,
+	"2001-09-13": true,
+	"2001-09-14": true,
+	"2004-06-11": true, // Reagan
+	"2007-01-02": true, // Ford
+	"2012-10-29": true, // Hurricane Sandy
+	"2012-10-30": true,
+	"2018-12-05": true, // G. H. W. Bush
+	"2025-01-09": true, // Carter
+}
+
+// IsTradingDay reports whether the exchange is open on day's New York date.
+func IsTradingDay(day time.Time) bool {
+	d := Day(day)
+	if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+		return false
+	}
+	return !isHoliday(d)
+}
+
+// SessionOpen is 09:30 New York time on day's date. Whether the exchange
+// trades that day is up to the caller, see IsTradingDay.
+func SessionOpen(day time.Time) time.Time {
+	y, m, d := Day(day).Date()
+	return time.Date(y, m, d, openHour, openMinute, 0, 0, nyLoc)
+}
+
+// SessionClose is 16:00 New York time on day's date, or 13:00 on early
+// close days.
+func SessionClose(day time.Time) time.Time {
+	d := Day(day)
+	hour := closeHour
+	if isEarlyClose(d) {
+		hour = earlyCloseHour
+	}
+	y, m, dd := d.Date()
+	return time.Date(y, m, dd, hour, 0, 0, 0, nyLoc)
+}
+
+// InSession reports whether t falls within a regular session.
+func InSession(t time.Time) bool {
+	return IsTradingDay(t) && !t.Before(SessionOpen(t)) && t.Before(SessionClose(t))
+}
+
+// NextOpen is the first session open at or after t.
+func NextOpen(t time.Time) time.Time {
+	for d := Day(t); ; d = d.AddDate(0, 0, 1) {
+		if open := SessionOpen(d); IsTradingDay(d) && !open.Before(t) {
+			return open
+		}
+	}
+}
+
+// NextClose is the first session close at or after t, the close of the
+// running session when t is during one.
+func NextClose(t time.Time) time.Time {
+	for d := Day(t); ; d = d.AddDate(0, 0, 1) {
+		if end := SessionClose(d); IsTradingDay(d) && !end.Before(t) {
+			return end
+		}
+	}
+}
+
+// Day is midnight New York time on t's New York date, the form trading days
+// are passed around in.
+func Day(t time.Time) time.Time {
+	y, m, d := t.In(nyLoc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, nyLoc)
+}
+
+func isHoliday(d time.Time) bool {
+	if specialClosures[d.Format("2006-01-02")] {
+		return true
+	}
+
+	y := d.Year()
+	holidays := []time.Time{
+		// New Year's Day is not moved back into the old year when it falls
+		// on a Saturday
+		observed(time.Date(y, time.January, 1, 0, 0, 0, 0, nyLoc), false),
+		nthWeekday(y, time.January, time.Monday, 3),  // Martin Luther King Jr. Day
+		nthWeekday(y, time.February, time.Monday, 3), // Washington's Birthday
+		easter(y).AddDate(0, 0, -2),                  // Good Friday
+		lastWeekday(y, time.May, time.Monday),        // Memorial Day
+		observed(time.Date(y, time.July, 4, 0, 0, 0, 0, nyLoc), true),
+		nthWeekday(y, time.September, time.Monday, 1),  // Labor Day
+		nthWeekday(y, time.November, time.Thursday, 4), // Thanksgiving
+		observed(time.Date(y, time.December, 25, 0, 0, 0, 0, nyLoc), true),
+	}
+	if y >= 2022 {
+		holidays = append(holidays, observed(time.Date(y, time.June, 19, 0, 0, 0, 0, nyLoc), true))
+	}
+	for _, h := range holidays {
+		if h.Equal(d) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEarlyClose reports the 13:00 closes: the day before Independence Day,
+// the day after Thanksgiving and Christmas Eve, when they are trading days.
+func isEarlyClose(d time.Time) bool {
+	if !IsTradingDay(d) {
+		return false
+	}
+	y, m, day := d.Date()
+	switch {
+	case m == time.July && day == 3:
+		return true
+	case m == time.December && day == 24:
+		return true
+	case m == time.November:
+		return d.Equal(nthWeekday(y, time.November, time.Thursday, 4).AddDate(0, 0, 1))
+	}
+	return false
+}
+
+// observed moves a holiday on a Sunday to the Monday after, and one on a
+// Saturday to the Friday before when saturdayToFriday is set.
+func observed(d time.Time, saturdayToFriday bool) time.Time {
+	switch d.Weekday() {
+	case time.Sunday:
+		return d.AddDate(0, 0, 1)
+	case time.Saturday:
+		if saturdayToFriday {
+			return d.AddDate(0, 0, -1)
+		}
+		return time.Time{}
+	}
+	return d
+}
+
+// nthWeekday is the n-th weekday of the month, e.g. the third Monday.
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	d := time.Date(year, month, 1, 0, 0, 0, 0, nyLoc)
+	offset := (int(weekday) - int(d.Weekday()) + 7) % 7
+	return d.AddDate(0, 0, offset+7*(n-1))
+}
+
+// lastWeekday is the last weekday of the month, e.g. the last Monday.
+func lastWeekday(year int, month time.Month, weekday time.Weekday) time.Time {
+	d := time.Date(year, month+1, 0, 0, 0, 0, 0, nyLoc)
+	offset := (int(d.Weekday()) - int(weekday) + 7) % 7
+	return d.AddDate(0, 0, -offset)
+}
+
+// easter is Easter Sunday of the Gregorian calendar (anonymous Gregorian
+// algorithm).
+func easter(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, nyLoc)
+}
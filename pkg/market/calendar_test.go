@@ -0,0 +1,138 @@
+package market
+
+import (
+	"testing"
+	"time"
+)
+
+func ny(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, nyLoc)
+}
+
+func TestIsTradingDay(t *testing.T) {
+	tests := []struct {
+		name string
+		day  time.Time
+		want bool
+	}{
+		{"regular monday", ny(2024, time.June, 17, 0, 0), true},
+		{"saturday", ny(2024, time.June, 15, 0, 0), false},
+		{"sunday", ny(2024, time.June, 16, 0, 0), false},
+		{"new year's day", ny(2024, time.January, 1, 0, 0), false},
+		{"new year's day on saturday is not observed", ny(2021, time.December, 31, 0, 0), true},
+		{"martin luther king jr. day", ny(2024, time.January, 15, 0, 0), false},
+		{"washington's birthday", ny(2024, time.February, 19, 0, 0), false},
+		{"good friday", ny(2024, time.March, 29, 0, 0), false},
+		{"thursday before good friday", ny(2024, time.March, 28, 0, 0), true},
+		{"memorial day", ny(2024, time.May, 27, 0, 0), false},
+		{"juneteenth", ny(2024, time.June, 19, 0, 0), false},
+		{"juneteenth before 2022", ny(2021, time.June, 18, 0, 0), true},
+		{"independence day on saturday", ny(2026, time.July, 3, 0, 0), false},
+		{"labor day", ny(2024, time.September, 2, 0, 0), false},
+		{"thanksgiving", ny(2024, time.November, 28, 0, 0), false},
+		{"christmas", ny(2024, time.December, 25, 0, 0), false},
+		{"christmas on sunday", ny(2022, time.December, 26, 0, 0), false},
+		{"day of mourning", ny(2025, time.January, 9, 0, 0), false},
+		{"hurricane sandy", ny(2012, time.October, 29, 0, 0), false},
+		{"late evening utc is the new york date", time.Date(2024, time.June, 18, 2, 0, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTradingDay(tt.day); got != tt.want {
+				t.Errorf("IsTradingDay(%s) = %v, want %v", tt.day.Format("2006-01-02"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionClose(t *testing.T) {
+	tests := []struct {
+		name string
+		day  time.Time
+		want time.Time
+	}{
+		{"regular day", ny(2024, time.December, 23, 0, 0), ny(2024, time.December, 23, 16, 0)},
+		{"day before independence day", ny(2024, time.July, 3, 0, 0), ny(2024, time.July, 3, 13, 0)},
+		{"day after thanksgiving", ny(2024, time.November, 29, 0, 0), ny(2024, time.November, 29, 13, 0)},
+		{"christmas eve", ny(2024, time.December, 24, 0, 0), ny(2024, time.December, 24, 13, 0)},
+		{"july 3rd on a sunday", ny(2022, time.July, 3, 0, 0), ny(2022, time.July, 3, 16, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SessionClose(tt.day); !got.Equal(tt.want) {
+				t.Errorf("SessionClose(%s) = %s, want %s", tt.day.Format("2006-01-02"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInSession(t *testing.T) {
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{"at the open", ny(2024, time.June, 17, 9, 30), true},
+		{"before the open", ny(2024, time.June, 17, 9, 29), false},
+		{"at the close", ny(2024, time.June, 17, 16, 0), false},
+		{"after an early close", ny(2024, time.December, 24, 13, 30), false},
+		{"holiday", ny(2024, time.December, 25, 11, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InSession(tt.t); got != tt.want {
+				t.Errorf("InSession(%s) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextOpenAndClose(t *testing.T) {
+	tests := []struct {
+		name      string
+		t         time.Time
+		wantOpen  time.Time
+		wantClose time.Time
+	}{
+		{
+			"during a session",
+			ny(2024, time.June, 17, 11, 0),
+			ny(2024, time.June, 18, 9, 30),
+			ny(2024, time.June, 17, 16, 0),
+		},
+		{
+			"at the open",
+			ny(2024, time.June, 17, 9, 30),
+			ny(2024, time.June, 17, 9, 30),
+			ny(2024, time.June, 17, 16, 0),
+		},
+		{
+			"over a weekend",
+			ny(2024, time.June, 15, 12, 0),
+			ny(2024, time.June, 17, 9, 30),
+			ny(2024, time.June, 17, 16, 0),
+		},
+		{
+			"before good friday",
+			ny(2024, time.March, 28, 17, 0),
+			ny(2024, time.April, 1, 9, 30),
+			ny(2024, time.April, 1, 16, 0),
+		},
+		{
+			"during an early close",
+			ny(2024, time.December, 24, 10, 0),
+			ny(2024, time.December, 26, 9, 30),
+			ny(2024, time.December, 24, 13, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextOpen(tt.t); !got.Equal(tt.wantOpen) {
+				t.Errorf("NextOpen(%s) = %s, want %s", tt.t, got, tt.wantOpen)
+			}
+			if got := NextClose(tt.t); !got.Equal(tt.wantClose) {
+				t.Errorf("NextClose(%s) = %s, want %s", tt.t, got, tt.wantClose)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Package source names the places comments are scraped from.
+package source
+
+const (
+	Reddit     = "reddit"
+	StockTwits = "stocktwits"
+	RSS        = "rss"
+)
+
+var known = map[string]bool{Reddit: true, StockTwits: true, RSS: true}
+
+// Valid reports whether name is a known source.
+func Valid(name string) bool {
+	return known[name]
+}
+
+// Username returns the name an author is stored under in users. Reddit
+// authors keep their plain name so existing users and links stay valid;
+// authors from other sources are prefixed with the source, e.g.
+// "stocktwits:alice", so the same name on two sites is two users.
+func Username(name, author string) string {
+	if name == Reddit || name == "" {
+		return author
+	}
+	return name + ":" + author
+}
+
+// ExcludedUsernames are mods, bots and special accounts. Their mentions are
+// left out of scoring, leaderboards and early calls.
+var ExcludedUsernames = []string{
+	"OhWowMuchFunYouGuys",
+	"miamihausjunkie",
+	"AnnArchist",
+	"Immersions-",
+	"butthoofer",
+	"AutoModerator",
+	"PennyBotWeekly",
+	"PennyPumper",
+	"TransSpeciesDog",
+	"the_male_nurse",
+	"VisualMod",
+	"OPINION_IS_UNPOPULAR",
+	"zjz",
+	"OSRSkarma",
+	"Dan_inKuwait",
+	"Swiifttx",
+	"teddy_riesling",
+	"Stylux",
+	"Latter-day_weeb",
+	"ShopBitter",
+	"CHAINSAW_VASECTOMY",
+}